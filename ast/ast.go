@@ -3,10 +3,14 @@ package ast
 import (
 	"fmt"
 	"github.com/carsonip/monkey-interpreter/token"
+	"math/big"
 )
 
 type Node interface {
 	TokenLiteral() string
+	// Children returns the direct child nodes in source order, leaving out
+	// optional children that are absent.
+	Children() []Node
 }
 
 type Statement interface {
@@ -19,8 +23,13 @@ type Expression interface {
 	expression()
 }
 
+type Pattern interface {
+	Node
+	pattern()
+}
+
 type Program struct {
-	Statements []Statement
+	Statements []Node
 }
 
 func (p *Program) TokenLiteral() string {
@@ -31,6 +40,10 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Children() []Node {
+	return p.Statements
+}
+
 type Identifier struct {
 	Token token.Token
 }
@@ -39,11 +52,19 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifier) Children() []Node {
+	return nil
+}
+
 func (i *Identifier) expression() {}
 
+func (i *Identifier) pattern() {}
+
+// LetStatement is a let or, if its token is const, a const declaration.
 type LetStatement struct {
 	Token token.Token
-	Name *Identifier
+	Name Pattern
+	Type *TypeAnnotation
 	Value Expression
 }
 
@@ -51,8 +72,51 @@ func (l *LetStatement) TokenLiteral() string {
 	return l.Token.Literal
 }
 
+func (l *LetStatement) Children() []Node {
+	if l.Type == nil {
+		return []Node{l.Name, l.Value}
+	}
+	return []Node{l.Name, l.Type, l.Value}
+}
+
 func (l *LetStatement) statement() {}
 
+// IsConst reports whether l declares constants, which cannot be assigned to.
+func (l *LetStatement) IsConst() bool {
+	return l.Token.Type == token.TOKEN_CONST
+}
+
+type ImportStatement struct {
+	Token token.Token
+	Path *String
+	Alias *Identifier
+}
+
+func (i *ImportStatement) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *ImportStatement) Children() []Node {
+	return []Node{i.Path, i.Alias}
+}
+
+func (i *ImportStatement) statement() {}
+
+type ExportStatement struct {
+	Token token.Token
+	Statement *LetStatement
+}
+
+func (e *ExportStatement) TokenLiteral() string {
+	return e.Token.Literal
+}
+
+func (e *ExportStatement) Children() []Node {
+	return []Node{e.Statement}
+}
+
+func (e *ExportStatement) statement() {}
+
 type ReturnStatement struct {
 	Token token.Token
 	Value Expression
@@ -62,6 +126,10 @@ func (r *ReturnStatement) TokenLiteral() string {
 	return r.Token.Literal
 }
 
+func (r *ReturnStatement) Children() []Node {
+	return []Node{r.Value}
+}
+
 func (r *ReturnStatement) statement() {}
 
 type IfStatement struct {
@@ -69,25 +137,58 @@ type IfStatement struct {
 	Condition Expression
 	Then []Node
 	Else []Node
+	ThenRBrace token.Token
+	ElseRBrace token.Token
 }
 
 func (s *IfStatement) TokenLiteral() string {
 	return s.Token.Literal
 }
 
+func (s *IfStatement) Children() []Node {
+	children := []Node{s.Condition}
+	children = append(children, s.Then...)
+	return append(children, s.Else...)
+}
+
 func (s *IfStatement) statement() {}
 
+type WhileStatement struct {
+	Token token.Token
+	Condition Expression
+	Body []Node
+	RBrace token.Token
+}
+
+func (s *WhileStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *WhileStatement) Children() []Node {
+	return append([]Node{s.Condition}, s.Body...)
+}
+
+func (s *WhileStatement) statement() {}
+
 type NumberLiteral struct {
 	Token token.Token
 	Value int
+	// Big holds the value instead of Value if it is too large for an int.
+	Big *big.Int
 }
 
 func (n *NumberLiteral) TokenLiteral() string {
 	return n.Token.Literal
 }
 
+func (n *NumberLiteral) Children() []Node {
+	return nil
+}
+
 func (n *NumberLiteral) expression() {}
 
+func (n *NumberLiteral) pattern() {}
+
 type InfixExpression struct {
 	Token token.Token
 	Left Expression
@@ -98,6 +199,10 @@ func (i *InfixExpression) TokenLiteral() string {
 	return fmt.Sprintf("(%s %s %s)", i.Left.TokenLiteral(), i.Token.Literal, i.Right.TokenLiteral())
 }
 
+func (i *InfixExpression) Children() []Node {
+	return []Node{i.Left, i.Right}
+}
+
 func (i *InfixExpression) expression() {}
 
 type PrefixExpression struct {
@@ -109,8 +214,27 @@ func (p *PrefixExpression) TokenLiteral() string {
 	return fmt.Sprintf("(%s%s)", p.Token.Literal, p.Right.TokenLiteral())
 }
 
+func (p *PrefixExpression) Children() []Node {
+	return []Node{p.Right}
+}
+
 func (p *PrefixExpression) expression() {}
 
+type PostfixExpression struct {
+	Token token.Token
+	Left Expression
+}
+
+func (p *PostfixExpression) TokenLiteral() string {
+	return fmt.Sprintf("(%s%s)", p.Left.TokenLiteral(), p.Token.Literal)
+}
+
+func (p *PostfixExpression) Children() []Node {
+	return []Node{p.Left}
+}
+
+func (p *PostfixExpression) expression() {}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -120,32 +244,114 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Children() []Node {
+	return nil
+}
+
 func (b *Boolean) expression() {}
 
+func (b *Boolean) pattern() {}
+
+type Parameter struct {
+	Pattern Pattern
+	// Type is the type of each argument collected by a variadic parameter.
+	Type *TypeAnnotation
+	Default Expression
+	Variadic bool
+}
+
+func (p *Parameter) TokenLiteral() string {
+	return p.Pattern.TokenLiteral()
+}
+
+func (p *Parameter) Children() []Node {
+	children := []Node{p.Pattern}
+	if p.Type != nil {
+		children = append(children, p.Type)
+	}
+	if p.Default != nil {
+		children = append(children, p.Default)
+	}
+	return children
+}
+
 type Function struct {
-	Token  token.Token
-	Params []*Identifier
-	Body   []Node
+	Token      token.Token
+	Params     []*Parameter
+	ReturnType *TypeAnnotation
+	Body       []Node
+	RBrace     token.Token
 }
 
 func (f *Function) TokenLiteral() string {
 	return f.Token.Literal
 }
 
+func (f *Function) Children() []Node {
+	var children []Node
+	for _, param := range f.Params {
+		children = append(children, param)
+	}
+	if f.ReturnType != nil {
+		children = append(children, f.ReturnType)
+	}
+	return append(children, f.Body...)
+}
+
 func (f *Function) expression() {}
 
 type FunctionCall struct {
 	Token token.Token
 	FunctionExpr Expression
 	Arguments []Expression
+	RParen token.Token
 }
 
 func (f *FunctionCall) TokenLiteral() string {
 	return f.Token.Literal
 }
 
+func (f *FunctionCall) Children() []Node {
+	children := []Node{f.FunctionExpr}
+	for _, arg := range f.Arguments {
+		children = append(children, arg)
+	}
+	return children
+}
+
 func (f *FunctionCall) expression() {}
 
+type Spread struct {
+	Token token.Token
+	Value Expression
+}
+
+func (s *Spread) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *Spread) Children() []Node {
+	return []Node{s.Value}
+}
+
+func (s *Spread) expression() {}
+
+type KeywordArgument struct {
+	Token token.Token
+	Name *Identifier
+	Value Expression
+}
+
+func (k *KeywordArgument) TokenLiteral() string {
+	return k.Token.Literal
+}
+
+func (k *KeywordArgument) Children() []Node {
+	return []Node{k.Name, k.Value}
+}
+
+func (k *KeywordArgument) expression() {}
+
 type String struct {
 	Token token.Token
 	Value string
@@ -155,17 +361,57 @@ func (s *String) TokenLiteral() string {
 	return fmt.Sprintf("\"%s\"", s.Token.Literal)
 }
 
+func (s *String) Children() []Node {
+	return nil
+}
+
 func (s *String) expression() {}
 
+func (s *String) pattern() {}
+
+// Template is a template string, `text ${value} text`. Text holds the text
+// around the interpolated values, one more than there are values, with
+// Text[i] coming before Values[i].
+type Template struct {
+	Token token.Token
+	Text []string
+	Values []Expression
+	// End is the closing backtick.
+	End token.Token
+}
+
+func (t *Template) TokenLiteral() string {
+	return "`"
+}
+
+func (t *Template) Children() []Node {
+	children := make([]Node, len(t.Values))
+	for i, value := range t.Values {
+		children[i] = value
+	}
+	return children
+}
+
+func (t *Template) expression() {}
+
 type Array struct {
 	Token token.Token
 	Elements []Expression
+	RBracket token.Token
 }
 
 func (a *Array) TokenLiteral() string {
 	return a.Token.Literal
 }
 
+func (a *Array) Children() []Node {
+	var children []Node
+	for _, element := range a.Elements {
+		children = append(children, element)
+	}
+	return children
+}
+
 func (a *Array) expression() {}
 
 type Index struct {
@@ -178,16 +424,194 @@ func (in *Index) TokenLiteral() string {
 	return in.Token.Literal
 }
 
+func (in *Index) Children() []Node {
+	return []Node{in.Left, in.Index}
+}
+
 func (in *Index) expression() {}
 
+// Slice is left[start:end] or left[start:end:step]. Omitted bounds and step
+// are nil.
+type Slice struct {
+	Token token.Token
+	Left Expression
+	Start Expression
+	End Expression
+	Step Expression
+}
+
+func (s *Slice) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *Slice) Children() []Node {
+	children := []Node{s.Left}
+	for _, expr := range []Expression{s.Start, s.End, s.Step} {
+		if expr != nil {
+			children = append(children, expr)
+		}
+	}
+	return children
+}
+
+func (s *Slice) expression() {}
+
+type Dot struct {
+	Token token.Token
+	Left Expression
+	Name *Identifier
+}
+
+func (d *Dot) TokenLiteral() string {
+	return fmt.Sprintf("%s.%s", d.Left.TokenLiteral(), d.Name.TokenLiteral())
+}
+
+func (d *Dot) Children() []Node {
+	return []Node{d.Left, d.Name}
+}
+
+func (d *Dot) expression() {}
+
 type Map struct {
 	Token token.Token
 	Pairs [][2]Expression
+	RBrace token.Token
 }
 
 func (m *Map) TokenLiteral() string {
 	return m.Token.Literal
 }
 
+func (m *Map) Children() []Node {
+	var children []Node
+	for _, pair := range m.Pairs {
+		children = append(children, pair[0], pair[1])
+	}
+	return children
+}
+
 func (m *Map) expression() {}
 
+
+type ArrayPattern struct {
+	Token token.Token
+	Elements []Pattern
+	Rest *Identifier
+}
+
+func (a *ArrayPattern) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *ArrayPattern) Children() []Node {
+	var children []Node
+	for _, element := range a.Elements {
+		children = append(children, element)
+	}
+	if a.Rest != nil {
+		children = append(children, a.Rest)
+	}
+	return children
+}
+
+func (a *ArrayPattern) pattern() {}
+
+type MapPatternPair struct {
+	Key Expression
+	Value Pattern
+}
+
+type MapPattern struct {
+	Token token.Token
+	Pairs []MapPatternPair
+}
+
+func (m *MapPattern) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *MapPattern) Children() []Node {
+	var children []Node
+	for _, pair := range m.Pairs {
+		children = append(children, pair.Key, pair.Value)
+	}
+	return children
+}
+
+func (m *MapPattern) pattern() {}
+
+// PatternNames lists the names a pattern binds, leaving out _.
+func PatternNames(pattern Pattern) []string {
+	var names []string
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		if name := pattern.TokenLiteral(); name != "_" {
+			names = append(names, name)
+		}
+	case *ArrayPattern:
+		for _, element := range pattern.Elements {
+			names = append(names, PatternNames(element)...)
+		}
+		if pattern.Rest != nil {
+			names = append(names, PatternNames(pattern.Rest)...)
+		}
+	case *MapPattern:
+		for _, pair := range pattern.Pairs {
+			names = append(names, PatternNames(pair.Value)...)
+		}
+	}
+	return names
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard Expression
+	Body Expression
+}
+
+func (m *MatchArm) TokenLiteral() string {
+	return m.Pattern.TokenLiteral()
+}
+
+func (m *MatchArm) Children() []Node {
+	if m.Guard == nil {
+		return []Node{m.Pattern, m.Body}
+	}
+	return []Node{m.Pattern, m.Guard, m.Body}
+}
+
+type MatchExpression struct {
+	Token token.Token
+	Value Expression
+	Arms []*MatchArm
+	RBrace token.Token
+}
+
+func (m *MatchExpression) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *MatchExpression) Children() []Node {
+	children := []Node{m.Value}
+	for _, arm := range m.Arms {
+		children = append(children, arm)
+	}
+	return children
+}
+
+func (m *MatchExpression) expression() {}
+
+// TypeAnnotation is the declared type of a binding, parameter or function
+// result, such as the int in let x: int = 1. Annotations are only read by the
+// type checker; the evaluator ignores them.
+type TypeAnnotation struct {
+	Token token.Token
+}
+
+func (t *TypeAnnotation) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *TypeAnnotation) Children() []Node {
+	return nil
+}
@@ -0,0 +1,419 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Nodes serialize to JSON objects holding a "kind" discriminator, the Go type
+// name of the node, followed by the node's fields under lowerCamelCase keys.
+// Tokens carry the line and column of each node.
+//
+//	{"kind": "Identifier", "token": {"type": "IDENTIFIER", "literal": "x", "line": 1, "column": 5}}
+//
+// UnmarshalNode reads such an object back into a node of the right kind.
+
+var kinds = map[string]func() Node{
+	"Program":           func() Node { return &Program{} },
+	"Identifier":        func() Node { return &Identifier{} },
+	"LetStatement":      func() Node { return &LetStatement{} },
+	"ImportStatement":   func() Node { return &ImportStatement{} },
+	"ExportStatement":   func() Node { return &ExportStatement{} },
+	"ReturnStatement":   func() Node { return &ReturnStatement{} },
+	"IfStatement":       func() Node { return &IfStatement{} },
+	"WhileStatement":    func() Node { return &WhileStatement{} },
+	"NumberLiteral":     func() Node { return &NumberLiteral{} },
+	"InfixExpression":   func() Node { return &InfixExpression{} },
+	"PrefixExpression":  func() Node { return &PrefixExpression{} },
+	"PostfixExpression": func() Node { return &PostfixExpression{} },
+	"Boolean":           func() Node { return &Boolean{} },
+	"Parameter":         func() Node { return &Parameter{} },
+	"Function":          func() Node { return &Function{} },
+	"FunctionCall":      func() Node { return &FunctionCall{} },
+	"Spread":            func() Node { return &Spread{} },
+	"KeywordArgument":   func() Node { return &KeywordArgument{} },
+	"String":            func() Node { return &String{} },
+	"Template":          func() Node { return &Template{} },
+	"Array":             func() Node { return &Array{} },
+	"Index":             func() Node { return &Index{} },
+	"Slice":             func() Node { return &Slice{} },
+	"Dot":               func() Node { return &Dot{} },
+	"Map":               func() Node { return &Map{} },
+	"ArrayPattern":      func() Node { return &ArrayPattern{} },
+	"MapPattern":        func() Node { return &MapPattern{} },
+	"MatchArm":          func() Node { return &MatchArm{} },
+	"MatchExpression":   func() Node { return &MatchExpression{} },
+	"TypeAnnotation":    func() Node { return &TypeAnnotation{} },
+}
+
+// UnmarshalNode decodes a node of any kind from JSON.
+func UnmarshalNode(data []byte) (Node, error) {
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	newNode, ok := kinds[header.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown node kind %q", header.Kind)
+	}
+	node := newNode()
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func marshalNode(node Node) ([]byte, error) {
+	kind, err := json.Marshal(reflect.TypeOf(node).Elem().Name())
+	if err != nil {
+		return nil, err
+	}
+	return marshalFields(reflect.ValueOf(node).Elem(), []byte(`"kind":`+string(kind)))
+}
+
+func marshalFields(v reflect.Value, prefix []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	buf.Write(prefix)
+	for i := 0; i < v.NumField(); i++ {
+		value, err := json.Marshal(v.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", fieldKey(v.Type().Field(i).Name))
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func unmarshalNode(data []byte, node Node) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	want := reflect.TypeOf(node).Elem().Name()
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil || kind != want {
+		return fmt.Errorf("expected node kind %q, got %s", want, fields["kind"])
+	}
+	return unmarshalFields(fields, reflect.ValueOf(node).Elem())
+}
+
+func unmarshalFields(fields map[string]json.RawMessage, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		key := fieldKey(v.Type().Field(i).Name)
+		if raw, ok := fields[key]; ok {
+			if err := unmarshalValue(raw, v.Field(i)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalValue decodes into v, going through UnmarshalNode wherever v or
+// its elements are interfaces such as Expression, which encoding/json cannot
+// fill in by itself.
+func unmarshalValue(raw json.RawMessage, v reflect.Value) error {
+	if string(raw) == "null" {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		node, err := UnmarshalNode(raw)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(node).AssignableTo(v.Type()) {
+			return fmt.Errorf("%T is not %s", node, v.Type().Name())
+		}
+		v.Set(reflect.ValueOf(node))
+		return nil
+	case reflect.Slice, reflect.Array:
+		var elements []json.RawMessage
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(elements), len(elements)))
+		} else if len(elements) != v.Len() {
+			return fmt.Errorf("expected %d elements, got %d", v.Len(), len(elements))
+		}
+		for i, element := range elements {
+			if err := unmarshalValue(element, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return json.Unmarshal(raw, v.Addr().Interface())
+}
+
+func fieldKey(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return marshalNode(p)
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, p)
+}
+
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return marshalNode(i)
+}
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, i)
+}
+
+func (l *LetStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(l)
+}
+
+func (l *LetStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, l)
+}
+
+func (i *ImportStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(i)
+}
+
+func (i *ImportStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, i)
+}
+
+func (e *ExportStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(e)
+}
+
+func (e *ExportStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, e)
+}
+
+func (r *ReturnStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(r)
+}
+
+func (r *ReturnStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, r)
+}
+
+func (s *IfStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(s)
+}
+
+func (s *IfStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, s)
+}
+
+func (s *WhileStatement) MarshalJSON() ([]byte, error) {
+	return marshalNode(s)
+}
+
+func (s *WhileStatement) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, s)
+}
+
+func (n *NumberLiteral) MarshalJSON() ([]byte, error) {
+	return marshalNode(n)
+}
+
+func (n *NumberLiteral) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, n)
+}
+
+func (i *InfixExpression) MarshalJSON() ([]byte, error) {
+	return marshalNode(i)
+}
+
+func (i *InfixExpression) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, i)
+}
+
+func (p *PrefixExpression) MarshalJSON() ([]byte, error) {
+	return marshalNode(p)
+}
+
+func (p *PrefixExpression) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, p)
+}
+
+func (p *PostfixExpression) MarshalJSON() ([]byte, error) {
+	return marshalNode(p)
+}
+
+func (p *PostfixExpression) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, p)
+}
+
+func (b *Boolean) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+
+func (p *Parameter) MarshalJSON() ([]byte, error) {
+	return marshalNode(p)
+}
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, p)
+}
+
+func (f *Function) MarshalJSON() ([]byte, error) {
+	return marshalNode(f)
+}
+
+func (f *Function) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, f)
+}
+
+func (f *FunctionCall) MarshalJSON() ([]byte, error) {
+	return marshalNode(f)
+}
+
+func (f *FunctionCall) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, f)
+}
+
+func (s *Spread) MarshalJSON() ([]byte, error) {
+	return marshalNode(s)
+}
+
+func (s *Spread) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, s)
+}
+
+func (k *KeywordArgument) MarshalJSON() ([]byte, error) {
+	return marshalNode(k)
+}
+
+func (k *KeywordArgument) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, k)
+}
+
+func (s *String) MarshalJSON() ([]byte, error) {
+	return marshalNode(s)
+}
+
+func (s *String) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, s)
+}
+
+func (t *Template) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+
+func (t *Template) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+
+func (a *Array) MarshalJSON() ([]byte, error) {
+	return marshalNode(a)
+}
+
+func (a *Array) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, a)
+}
+
+func (in *Index) MarshalJSON() ([]byte, error) {
+	return marshalNode(in)
+}
+
+func (in *Index) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, in)
+}
+
+func (s *Slice) MarshalJSON() ([]byte, error) {
+	return marshalNode(s)
+}
+
+func (s *Slice) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, s)
+}
+
+func (d *Dot) MarshalJSON() ([]byte, error) {
+	return marshalNode(d)
+}
+
+func (d *Dot) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, d)
+}
+
+func (m *Map) MarshalJSON() ([]byte, error) {
+	return marshalNode(m)
+}
+
+func (m *Map) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, m)
+}
+
+func (a *ArrayPattern) MarshalJSON() ([]byte, error) {
+	return marshalNode(a)
+}
+
+func (a *ArrayPattern) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, a)
+}
+
+func (m *MapPattern) MarshalJSON() ([]byte, error) {
+	return marshalNode(m)
+}
+
+func (m *MapPattern) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, m)
+}
+
+func (m *MatchArm) MarshalJSON() ([]byte, error) {
+	return marshalNode(m)
+}
+
+func (m *MatchArm) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, m)
+}
+
+func (m *MatchExpression) MarshalJSON() ([]byte, error) {
+	return marshalNode(m)
+}
+
+func (m *MatchExpression) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, m)
+}
+
+func (t *TypeAnnotation) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+
+func (t *TypeAnnotation) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+
+// MapPatternPair is not a node and has no kind, but its fields are encoded
+// the same way.
+func (m MapPatternPair) MarshalJSON() ([]byte, error) {
+	return marshalFields(reflect.ValueOf(m), nil)
+}
+
+func (m *MapPatternPair) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	return unmarshalFields(fields, reflect.ValueOf(m).Elem())
+}
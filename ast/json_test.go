@@ -0,0 +1,50 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJSON_Identifier(t *testing.T) {
+	program := parse(`x;`)
+	data, err := json.Marshal(program.Statements[0])
+	assert.NoError(t, err)
+	assert.Equal(t, `{"kind":"Identifier","token":{"type":"IDENTIFIER","literal":"x","line":1,"column":1}}`, string(data))
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	program := parse(`
+import "std/list.mk" as list;
+export let [a, b, ...rest] = [1, -2, "three"];
+let f = fn(x, {"k": v, name} = {}, ...more) {
+	if (x > 1) { return x % 2; } else { x += 1; }
+	while (!x) { x++; }
+	return match (x) { 0 => true, n if n < 3 => list.map(more, fn(y) { y }), _ => false };
+};
+f(1, ...rest, name: m["key"]);
+`)
+	data, err := json.Marshal(program)
+	assert.NoError(t, err)
+	node, err := ast.UnmarshalNode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, program, node)
+
+	var decoded ast.Program
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, program, &decoded)
+}
+
+func TestJSON_Errors(t *testing.T) {
+	_, err := ast.UnmarshalNode([]byte(`{"kind":"Nope"}`))
+	assert.EqualError(t, err, `unknown node kind "Nope"`)
+
+	var ident ast.Identifier
+	err = json.Unmarshal([]byte(`{"kind":"String"}`), &ident)
+	assert.EqualError(t, err, `expected node kind "Identifier", got "String"`)
+
+	var let ast.LetStatement
+	err = json.Unmarshal([]byte(`{"kind":"LetStatement","value":{"kind":"Parameter"}}`), &let)
+	assert.EqualError(t, err, `value: *ast.Parameter is not Expression`)
+}
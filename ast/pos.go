@@ -0,0 +1,73 @@
+package ast
+
+import "github.com/carsonip/monkey-interpreter/token"
+
+// Start returns the first token of node in the source, or the zero Token for
+// nodes that record no position, such as an empty Program.
+func Start(node Node) token.Token {
+	switch node := node.(type) {
+	case *Program:
+		if len(node.Statements) > 0 {
+			return Start(node.Statements[0])
+		}
+	case *Identifier:
+		return node.Token
+	case *InfixExpression:
+		return Start(node.Left)
+	case *PostfixExpression:
+		return Start(node.Left)
+	case *FunctionCall:
+		return Start(node.FunctionExpr)
+	case *Index:
+		return Start(node.Left)
+	case *Slice:
+		return Start(node.Left)
+	case *Dot:
+		return Start(node.Left)
+	case *LetStatement:
+		return node.Token
+	case *ExportStatement:
+		return node.Token
+	case *ImportStatement:
+		return node.Token
+	case *ReturnStatement:
+		return node.Token
+	case *IfStatement:
+		return node.Token
+	case *WhileStatement:
+		return node.Token
+	case *NumberLiteral:
+		return node.Token
+	case *PrefixExpression:
+		return node.Token
+	case *Boolean:
+		return node.Token
+	case *Function:
+		return node.Token
+	case *String:
+		return node.Token
+	case *Template:
+		return node.Token
+	case *Array:
+		return node.Token
+	case *Map:
+		return node.Token
+	case *MatchExpression:
+		return node.Token
+	case *Spread:
+		return node.Token
+	case *KeywordArgument:
+		return Start(node.Name)
+	case *ArrayPattern:
+		return node.Token
+	case *MapPattern:
+		return node.Token
+	case *Parameter:
+		return Start(node.Pattern)
+	case *MatchArm:
+		return Start(node.Pattern)
+	case *TypeAnnotation:
+		return node.Token
+	}
+	return token.Token{}
+}
@@ -0,0 +1,184 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk. If
+// the result visitor w is not nil, Walk visits each of the children of node
+// with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, like go/ast.Walk.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range node.Children() {
+		Walk(v, child)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order, calling f for each node and
+// then f(nil) once its children are done. The children of a node are skipped
+// if f returns false for it.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite transforms an AST bottom-up: the children of node are rewritten
+// first, then f is called with node and its result takes node's place. f
+// returns its argument to leave a node unchanged. A replacement must fit
+// where it is put, e.g. an expression's replacement must be an Expression.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch node := node.(type) {
+	case *Program:
+		node.Statements = rewriteNodes(node.Statements, f)
+	case *LetStatement:
+		node.Name = rewritePattern(node.Name, f)
+		node.Type = rewriteType(node.Type, f)
+		node.Value = rewriteExpression(node.Value, f)
+	case *ImportStatement:
+		node.Path = rewriteAs(node.Path, f).(*String)
+		node.Alias = rewriteAs(node.Alias, f).(*Identifier)
+	case *ExportStatement:
+		node.Statement = rewriteAs(node.Statement, f).(*LetStatement)
+	case *ReturnStatement:
+		node.Value = rewriteExpression(node.Value, f)
+	case *IfStatement:
+		node.Condition = rewriteExpression(node.Condition, f)
+		node.Then = rewriteNodes(node.Then, f)
+		node.Else = rewriteNodes(node.Else, f)
+	case *WhileStatement:
+		node.Condition = rewriteExpression(node.Condition, f)
+		node.Body = rewriteNodes(node.Body, f)
+	case *InfixExpression:
+		node.Left = rewriteExpression(node.Left, f)
+		node.Right = rewriteExpression(node.Right, f)
+	case *PrefixExpression:
+		node.Right = rewriteExpression(node.Right, f)
+	case *PostfixExpression:
+		node.Left = rewriteExpression(node.Left, f)
+	case *Parameter:
+		node.Pattern = rewritePattern(node.Pattern, f)
+		node.Type = rewriteType(node.Type, f)
+		node.Default = rewriteExpression(node.Default, f)
+	case *Function:
+		for i, param := range node.Params {
+			node.Params[i] = rewriteAs(param, f).(*Parameter)
+		}
+		node.ReturnType = rewriteType(node.ReturnType, f)
+		node.Body = rewriteNodes(node.Body, f)
+	case *FunctionCall:
+		node.FunctionExpr = rewriteExpression(node.FunctionExpr, f)
+		node.Arguments = rewriteExpressions(node.Arguments, f)
+	case *Spread:
+		node.Value = rewriteExpression(node.Value, f)
+	case *KeywordArgument:
+		node.Name = rewriteAs(node.Name, f).(*Identifier)
+		node.Value = rewriteExpression(node.Value, f)
+	case *Array:
+		node.Elements = rewriteExpressions(node.Elements, f)
+	case *Template:
+		node.Values = rewriteExpressions(node.Values, f)
+	case *Index:
+		node.Left = rewriteExpression(node.Left, f)
+		node.Index = rewriteExpression(node.Index, f)
+	case *Slice:
+		node.Left = rewriteExpression(node.Left, f)
+		node.Start = rewriteExpression(node.Start, f)
+		node.End = rewriteExpression(node.End, f)
+		node.Step = rewriteExpression(node.Step, f)
+	case *Dot:
+		node.Left = rewriteExpression(node.Left, f)
+		node.Name = rewriteAs(node.Name, f).(*Identifier)
+	case *Map:
+		for i, pair := range node.Pairs {
+			node.Pairs[i] = [2]Expression{rewriteExpression(pair[0], f), rewriteExpression(pair[1], f)}
+		}
+	case *ArrayPattern:
+		for i, element := range node.Elements {
+			node.Elements[i] = rewritePattern(element, f)
+		}
+		if node.Rest != nil {
+			node.Rest = rewriteAs(node.Rest, f).(*Identifier)
+		}
+	case *MapPattern:
+		for i, pair := range node.Pairs {
+			node.Pairs[i] = MapPatternPair{Key: rewriteExpression(pair.Key, f), Value: rewritePattern(pair.Value, f)}
+		}
+	case *MatchArm:
+		node.Pattern = rewritePattern(node.Pattern, f)
+		node.Guard = rewriteExpression(node.Guard, f)
+		node.Body = rewriteExpression(node.Body, f)
+	case *MatchExpression:
+		node.Value = rewriteExpression(node.Value, f)
+		for i, arm := range node.Arms {
+			node.Arms[i] = rewriteAs(arm, f).(*MatchArm)
+		}
+	}
+	return f(node)
+}
+
+// rewriteAs rewrites a child whose field has a concrete node type, checking
+// that the replacement has that type too.
+func rewriteAs(node Node, f func(Node) Node) Node {
+	result := Rewrite(node, f)
+	if fmt.Sprintf("%T", result) != fmt.Sprintf("%T", node) {
+		panic(fmt.Sprintf("cannot replace %T with %T", node, result))
+	}
+	return result
+}
+
+func rewriteNodes(nodes []Node, f func(Node) Node) []Node {
+	for i, node := range nodes {
+		nodes[i] = Rewrite(node, f)
+	}
+	return nodes
+}
+
+func rewriteExpression(expr Expression, f func(Node) Node) Expression {
+	if expr == nil {
+		return nil
+	}
+	result, ok := Rewrite(expr, f).(Expression)
+	if !ok {
+		panic(fmt.Sprintf("cannot replace expression %T with a non-expression", expr))
+	}
+	return result
+}
+
+func rewriteExpressions(exprs []Expression, f func(Node) Node) []Expression {
+	for i, expr := range exprs {
+		exprs[i] = rewriteExpression(expr, f)
+	}
+	return exprs
+}
+
+func rewritePattern(pattern Pattern, f func(Node) Node) Pattern {
+	if pattern == nil {
+		return nil
+	}
+	result, ok := Rewrite(pattern, f).(Pattern)
+	if !ok {
+		panic(fmt.Sprintf("cannot replace pattern %T with a non-pattern", pattern))
+	}
+	return result
+}
+
+func rewriteType(t *TypeAnnotation, f func(Node) Node) *TypeAnnotation {
+	if t == nil {
+		return nil
+	}
+	return rewriteAs(t, f).(*TypeAnnotation)
+}
@@ -0,0 +1,111 @@
+package ast_test
+
+import (
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/printer"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func parse(str string) *ast.Program {
+	lex := token.NewLexer(str)
+	p := parser.NewParser(&lex)
+	return p.ParseProgram()
+}
+
+type countVisitor map[string]int
+
+func (v countVisitor) Visit(node ast.Node) ast.Visitor {
+	if ident, ok := node.(*ast.Identifier); ok {
+		v[ident.TokenLiteral()]++
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	program := parse(`let f = fn(a, b = 1) { return a + b; }; f(2, b: a);`)
+	v := countVisitor{}
+	ast.Walk(v, program)
+	assert.Equal(t, 3, v["a"])
+	assert.Equal(t, 3, v["b"])
+	assert.Equal(t, 2, v["f"])
+}
+
+func TestInspect(t *testing.T) {
+	program := parse(`let x = match (y) { [a, ...rest] if a => a, {"k": v} => v, _ => 0 };`)
+	var idents []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			idents = append(idents, ident.TokenLiteral())
+		}
+		return true
+	})
+	assert.Equal(t, []string{"x", "y", "a", "rest", "a", "a", "v", "v", "_"}, idents)
+}
+
+func TestInspect_Prune(t *testing.T) {
+	program := parse(`let x = 1; let f = fn() { let y = 2; };`)
+	var lets int
+	ast.Inspect(program, func(node ast.Node) bool {
+		if _, ok := node.(*ast.Function); ok {
+			return false
+		}
+		if _, ok := node.(*ast.LetStatement); ok {
+			lets++
+		}
+		return true
+	})
+	assert.Equal(t, 2, lets)
+}
+
+func TestInspect_Nil(t *testing.T) {
+	program := parse(`1 + 2;`)
+	var depth, maxDepth int
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node == nil {
+			depth--
+			return true
+		}
+		depth++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return true
+	})
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 3, maxDepth)
+}
+
+func TestRewrite(t *testing.T) {
+	program := parse(`let x = 1 + 2; if (x) { return y * 3; } else { x = 4; }`)
+	result := ast.Rewrite(program, func(node ast.Node) ast.Node {
+		if num, ok := node.(*ast.NumberLiteral); ok {
+			num.Value *= 10
+			num.Token.Literal += "0"
+		}
+		if ident, ok := node.(*ast.Identifier); ok && ident.TokenLiteral() == "y" {
+			return &ast.NumberLiteral{Token: token.Token{Type: token.TOKEN_NUMBER, Literal: "5"}, Value: 5}
+		}
+		return node
+	})
+	assert.Equal(t, "let x = 10 + 20;\nif (x) {\n\treturn 5 * 30;\n} else {\n\tx = 40;\n}\n", printer.Print(result))
+}
+
+func TestRewrite_Mismatch(t *testing.T) {
+	program := parse(`let x = 1;`)
+	assert.Panics(t, func() {
+		ast.Rewrite(program, func(node ast.Node) ast.Node {
+			if _, ok := node.(*ast.NumberLiteral); ok {
+				return &ast.ReturnStatement{}
+			}
+			return node
+		})
+	})
+}
+
+func TestPatternNames(t *testing.T) {
+	let := parse(`let [a, _, {"k": [b], c}, ...rest] = x;`).Statements[0].(*ast.LetStatement)
+	assert.Equal(t, []string{"a", "b", "c", "rest"}, ast.PatternNames(let.Name))
+}
@@ -0,0 +1,217 @@
+// Package coverage measures which statements of Monkey programs run and which
+// way their branches go, and reports it as text, HTML or LCOV.
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/object"
+	"io"
+	"sort"
+)
+
+// Profile holds the coverage of a set of files. It is saved as JSON so the
+// coverage of several runs can be merged.
+type Profile struct {
+	Files []*File `json:"files"`
+}
+
+// File is the coverage of one file. Statements and branches are identified
+// by the position of the node they belong to, and sorted by it.
+type File struct {
+	Path       string       `json:"path"`
+	Source     string       `json:"source"`
+	Statements []*Statement `json:"statements"`
+	Branches   []*Branch    `json:"branches"`
+}
+
+// Statement counts the runs of a statement, including expressions used as
+// statements.
+type Statement struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Count  int `json:"count"`
+}
+
+// Branch counts how often each branch of an if statement, while statement
+// or match expression was taken, in the order eval.BranchHooks numbers them.
+type Branch struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Kind   string `json:"kind"`
+	Counts []int  `json:"counts"`
+}
+
+// Name describes branch i of b.
+func (b *Branch) Name(i int) string {
+	switch b.Kind {
+	case "if":
+		return [...]string{"then", "else"}[i]
+	case "while":
+		return [...]string{"body", "exit"}[i]
+	}
+	return fmt.Sprintf("arm %d", i+1)
+}
+
+// Collector implements eval.BranchHooks, counting the statements and branches
+// of the programs added to it.
+type Collector struct {
+	files      []*File
+	statements map[ast.Node]*Statement
+	branches   map[ast.Node]*Branch
+}
+
+func NewCollector() *Collector {
+	return &Collector{statements: make(map[ast.Node]*Statement), branches: make(map[ast.Node]*Branch)}
+}
+
+// Add measures program, the parsed source of the file at path, when it runs.
+// Statements of other programs, such as imported modules, are not counted.
+// Each path is added once.
+func (c *Collector) Add(path string, source string, program *ast.Program) {
+	file := &File{Path: path, Source: source}
+	addStatements := func(nodes []ast.Node) {
+		for _, node := range nodes {
+			start := ast.Start(node)
+			s := &Statement{Line: start.Line, Column: start.Column}
+			c.statements[node] = s
+			file.Statements = append(file.Statements, s)
+		}
+	}
+	addBranch := func(node ast.Node, kind string, n int) {
+		start := ast.Start(node)
+		b := &Branch{Line: start.Line, Column: start.Column, Kind: kind, Counts: make([]int, n)}
+		c.branches[node] = b
+		file.Branches = append(file.Branches, b)
+	}
+	addStatements(program.Statements)
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStatement:
+			addStatements(node.Then)
+			addStatements(node.Else)
+			addBranch(node, "if", 2)
+		case *ast.WhileStatement:
+			addStatements(node.Body)
+			addBranch(node, "while", 2)
+		case *ast.Function:
+			addStatements(node.Body)
+		case *ast.MatchExpression:
+			addBranch(node, "match", len(node.Arms))
+		}
+		return true
+	})
+	file.sort()
+	c.files = append(c.files, file)
+}
+
+func (c *Collector) Before(node ast.Node, env *object.Env) {
+	if s, ok := c.statements[node]; ok {
+		s.Count++
+	}
+}
+
+func (c *Collector) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {}
+
+func (c *Collector) Return(call *ast.FunctionCall, result object.Object) {}
+
+func (c *Collector) Branch(node ast.Node, branch int) {
+	if b, ok := c.branches[node]; ok {
+		b.Counts[branch]++
+	}
+}
+
+// Profile returns the coverage measured so far.
+func (c *Collector) Profile() *Profile {
+	p := &Profile{}
+	for _, f := range c.files {
+		p.Files = append(p.Files, f.copy())
+	}
+	sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Path < p.Files[j].Path })
+	return p
+}
+
+func (f *File) sort() {
+	sort.Slice(f.Statements, func(i, j int) bool {
+		a, b := f.Statements[i], f.Statements[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	sort.Slice(f.Branches, func(i, j int) bool {
+		a, b := f.Branches[i], f.Branches[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+}
+
+// Merge adds the counts of other to p. A file in both must have the same
+// source in each.
+func (p *Profile) Merge(other *Profile) error {
+	for _, f := range other.Files {
+		if err := p.merge(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Profile) merge(f *File) error {
+	i := sort.Search(len(p.Files), func(i int) bool { return p.Files[i].Path >= f.Path })
+	if i == len(p.Files) || p.Files[i].Path != f.Path {
+		p.Files = append(p.Files, nil)
+		copy(p.Files[i+1:], p.Files[i:])
+		p.Files[i] = f.copy()
+		return nil
+	}
+	existing := p.Files[i]
+	if existing.Source != f.Source {
+		return fmt.Errorf("%s changed between runs", f.Path)
+	}
+	if len(existing.Statements) != len(f.Statements) || len(existing.Branches) != len(f.Branches) {
+		return fmt.Errorf("%s has different statements in each profile", f.Path)
+	}
+	for j, s := range f.Statements {
+		existing.Statements[j].Count += s.Count
+	}
+	for j, b := range f.Branches {
+		if len(existing.Branches[j].Counts) != len(b.Counts) {
+			return fmt.Errorf("%s has different branches in each profile", f.Path)
+		}
+		for k, count := range b.Counts {
+			existing.Branches[j].Counts[k] += count
+		}
+	}
+	return nil
+}
+
+func (f *File) copy() *File {
+	c := &File{Path: f.Path, Source: f.Source}
+	for _, s := range f.Statements {
+		copied := *s
+		c.Statements = append(c.Statements, &copied)
+	}
+	for _, b := range f.Branches {
+		copied := *b
+		copied.Counts = append([]int{}, b.Counts...)
+		c.Branches = append(c.Branches, &copied)
+	}
+	return c
+}
+
+// ReadProfile reads a profile saved by Write.
+func ReadProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("invalid coverage profile: %w", err)
+	}
+	for _, f := range p.Files {
+		f.sort()
+	}
+	sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Path < p.Files[j].Path })
+	return p, nil
+}
+
+func (p *Profile) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
@@ -0,0 +1,132 @@
+package coverage
+
+import (
+	"bytes"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const source = `let sign = fn(n) {
+	if (n < 0) {
+		return -1;
+	}
+	let i = 0;
+	while (i < n) {
+		i++;
+	}
+	return match (n) { 0 => 0, _ => 1 };
+};
+sign(2);
+`
+
+// run measures a run of source, with extra appended to it.
+func run(t *testing.T, extra string) *Profile {
+	lex := token.NewLexer(source + extra)
+	parse := parser.NewParser(&lex)
+	program := parse.ParseProgram()
+	c := NewCollector()
+	c.Add("sign.mk", source+extra, program)
+	env := object.NewEnv()
+	ev := eval.NewEvaluator(nil, env)
+	ev.SetHooks(c)
+	for _, node := range program.Statements {
+		_, isErr := ev.Eval(node, env).(object.Error)
+		assert.False(t, isErr)
+	}
+	return c.Profile()
+}
+
+func TestCollector(t *testing.T) {
+	p := run(t, "")
+	if !assert.Len(t, p.Files, 1) {
+		return
+	}
+	f := p.Files[0]
+	assert.Equal(t, []*Statement{
+		{Line: 1, Column: 1, Count: 1},
+		{Line: 2, Column: 2, Count: 1},
+		{Line: 3, Column: 3, Count: 0},
+		{Line: 5, Column: 2, Count: 1},
+		{Line: 6, Column: 2, Count: 1},
+		{Line: 7, Column: 3, Count: 2},
+		{Line: 9, Column: 2, Count: 1},
+		{Line: 11, Column: 1, Count: 1},
+	}, f.Statements)
+	assert.Equal(t, []*Branch{
+		{Line: 2, Column: 2, Kind: "if", Counts: []int{0, 1}},
+		{Line: 6, Column: 2, Kind: "while", Counts: []int{2, 1}},
+		{Line: 9, Column: 9, Kind: "match", Counts: []int{0, 1}},
+	}, f.Branches)
+	statements, ran, branches, taken := f.Summary()
+	assert.Equal(t, []int{8, 7, 6, 4}, []int{statements, ran, branches, taken})
+}
+
+func TestProfile_Merge(t *testing.T) {
+	p := run(t, "")
+	assert.NoError(t, p.Merge(run(t, "")))
+	assert.Equal(t, 4, p.Files[0].Branches[1].Counts[0])
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	saved, err := ReadProfile(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, p, saved)
+
+	assert.EqualError(t, p.Merge(run(t, "sign(-1);\n")), "sign.mk changed between runs")
+	_, err = ReadProfile(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestProfile_WriteText(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, run(t, "").WriteText(&buf))
+	assert.Equal(t, `file     statements   branches
+sign.mk  87.5% (7/8)  66.7% (4/6)
+total    87.5% (7/8)  66.7% (4/6)
+sign.mk:3:3: statement never ran
+sign.mk:2:2: if branch then never taken
+sign.mk:9:9: match branch arm 1 never taken
+`, buf.String())
+}
+
+func TestProfile_WriteLCOV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, run(t, "").WriteLCOV(&buf))
+	assert.Equal(t, `TN:
+SF:sign.mk
+DA:1,1
+DA:2,1
+DA:3,0
+DA:5,1
+DA:6,1
+DA:7,2
+DA:9,1
+DA:11,1
+LF:8
+LH:7
+BRDA:2,0,0,0
+BRDA:2,0,1,1
+BRDA:6,1,0,2
+BRDA:6,1,1,1
+BRDA:9,2,0,0
+BRDA:9,2,1,1
+BRF:6
+BRH:4
+end_of_record
+`, buf.String())
+}
+
+func TestProfile_WriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, run(t, "").WriteHTML(&buf))
+	html := buf.String()
+	assert.Contains(t, html, `<option value="file0">sign.mk (statements 87.5%, branches 66.7%)</option>`)
+	assert.Contains(t, html, `<span class="line partial" title="ran 1 times; if else taken 1 times; if then never taken"><span class="number">2</span>	if (n &lt; 0) {`)
+	assert.Contains(t, html, `<span class="line missed" title="ran 0 times"><span class="number">3</span>`)
+	assert.Contains(t, html, `<span class="line"><span class="number">4</span>	}`)
+}
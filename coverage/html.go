@@ -0,0 +1,107 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+type htmlFile struct {
+	Path    string
+	Summary string
+	Lines   []htmlLine
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	// Class is "run", "missed" or "partial" for lines statements start on,
+	// partial if one of their branches was never taken.
+	Class string
+	Title string
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Monkey coverage</title>
+<style>
+body { background: #fff; color: #222; font-family: sans-serif; margin: 0; }
+#header { background: #eee; padding: 8px; position: sticky; top: 0; }
+pre { font-family: monospace; margin: 0; padding: 8px 0; }
+.line { display: block; padding-right: 8px; }
+.number { color: #999; display: inline-block; padding: 0 8px; text-align: right; user-select: none; width: 4em; }
+.run { background: #d7f5d7; }
+.missed { background: #f8d0d0; }
+.partial { background: #f8ecc0; }
+.legend span { padding: 0 6px; }
+</style>
+</head>
+<body>
+<div id="header">
+<select id="files">
+{{range $i, $f := .}}<option value="file{{$i}}">{{$f.Path}} ({{$f.Summary}})</option>
+{{end}}</select>
+<span class="legend"><span class="run">run</span><span class="partial">branch not taken</span><span class="missed">never ran</span></span>
+</div>
+{{range $i, $f := .}}<pre class="file" id="file{{$i}}"{{if $i}} style="display: none"{{end}}>
+{{range $f.Lines}}<span class="line{{if .Class}} {{.Class}}{{end}}"{{if .Title}} title="{{.Title}}"{{end}}><span class="number">{{.Number}}</span>{{.Text}}</span>{{end}}</pre>
+{{end}}<script>
+document.getElementById("files").addEventListener("change", function() {
+	for (const pre of document.querySelectorAll("pre.file")) {
+		pre.style.display = pre.id === this.value ? "" : "none";
+	}
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes a page showing the source of each file with the lines
+// statements start on highlighted by whether they ran and whether their
+// branches were all taken. Hovering a line shows its counts.
+func (p *Profile) WriteHTML(w io.Writer) error {
+	var files []htmlFile
+	for _, f := range p.Files {
+		statements, run, branches, taken := f.Summary()
+		file := htmlFile{
+			Path:    f.Path,
+			Summary: fmt.Sprintf("statements %s, branches %s", percent(run, statements), percent(taken, branches)),
+		}
+		counts := f.lines()
+		missing := make(map[int][]string)
+		reached := make(map[int][]string)
+		for _, b := range f.Branches {
+			for i, count := range b.Counts {
+				name := fmt.Sprintf("%s %s", b.Kind, b.Name(i))
+				if count == 0 {
+					missing[b.Line] = append(missing[b.Line], name+" never taken")
+				} else {
+					reached[b.Line] = append(reached[b.Line], fmt.Sprintf("%s taken %d times", name, count))
+				}
+			}
+		}
+		for i, text := range strings.Split(strings.TrimSuffix(f.Source, "\n"), "\n") {
+			line := htmlLine{Number: i + 1, Text: text + "\n"}
+			if count, ok := counts[line.Number]; ok {
+				title := []string{fmt.Sprintf("ran %d times", count)}
+				title = append(title, reached[line.Number]...)
+				title = append(title, missing[line.Number]...)
+				line.Title = strings.Join(title, "; ")
+				switch {
+				case count == 0:
+					line.Class = "missed"
+				case len(missing[line.Number]) > 0:
+					line.Class = "partial"
+				default:
+					line.Class = "run"
+				}
+			}
+			file.Lines = append(file.Lines, line)
+		}
+		files = append(files, file)
+	}
+	return htmlTemplate.Execute(w, files)
+}
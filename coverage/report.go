@@ -0,0 +1,134 @@
+package coverage
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Summary counts the statements of f and how many ran, and the branches of f
+// and how many were taken.
+func (f *File) Summary() (statements int, run int, branches int, taken int) {
+	for _, s := range f.Statements {
+		statements++
+		if s.Count > 0 {
+			run++
+		}
+	}
+	for _, b := range f.Branches {
+		for _, count := range b.Counts {
+			branches++
+			if count > 0 {
+				taken++
+			}
+		}
+	}
+	return
+}
+
+// lines returns the run count of each line a statement starts on, the
+// largest if there are several.
+func (f *File) lines() map[int]int {
+	lines := make(map[int]int)
+	for _, s := range f.Statements {
+		if count, ok := lines[s.Line]; !ok || s.Count > count {
+			lines[s.Line] = s.Count
+		}
+	}
+	return lines
+}
+
+func percent(n int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+// WriteText writes the statement and branch coverage of each file and in
+// total, followed by the statements that never ran and the branches that
+// were never taken.
+func (p *Profile) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tstatements\tbranches")
+	var statements, run, branches, taken int
+	for _, f := range p.Files {
+		s, r, b, t := f.Summary()
+		statements, run, branches, taken = statements+s, run+r, branches+b, taken+t
+		fmt.Fprintf(tw, "%s\t%s (%d/%d)\t%s (%d/%d)\n", f.Path, percent(r, s), r, s, percent(t, b), t, b)
+	}
+	fmt.Fprintf(tw, "total\t%s (%d/%d)\t%s (%d/%d)\n", percent(run, statements), run, statements, percent(taken, branches), taken, branches)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, f := range p.Files {
+		for _, s := range f.Statements {
+			if s.Count == 0 {
+				fmt.Fprintf(w, "%s:%d:%d: statement never ran\n", f.Path, s.Line, s.Column)
+			}
+		}
+		for _, b := range f.Branches {
+			for i, count := range b.Counts {
+				if count == 0 {
+					fmt.Fprintf(w, "%s:%d:%d: %s branch %s never taken\n", f.Path, b.Line, b.Column, b.Kind, b.Name(i))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WriteLCOV writes the profile in the LCOV tracefile format read by genhtml
+// and most CI coverage services. Lines are those statements start on, and
+// each if, while and match is a block of branches.
+func (p *Profile) WriteLCOV(w io.Writer) error {
+	for _, f := range p.Files {
+		fmt.Fprintf(w, "TN:\nSF:%s\n", f.Path)
+		lines := f.lines()
+		hit := 0
+		for line, last := 1, maxLine(lines); line <= last; line++ {
+			count, ok := lines[line]
+			if !ok {
+				continue
+			}
+			if count > 0 {
+				hit++
+			}
+			fmt.Fprintf(w, "DA:%d,%d\n", line, count)
+		}
+		fmt.Fprintf(w, "LF:%d\nLH:%d\n", len(lines), hit)
+		branches, taken := 0, 0
+		for block, b := range f.Branches {
+			reached := false
+			for _, count := range b.Counts {
+				reached = reached || count > 0
+			}
+			for i, count := range b.Counts {
+				branches++
+				if count > 0 {
+					taken++
+				}
+				if reached {
+					fmt.Fprintf(w, "BRDA:%d,%d,%d,%d\n", b.Line, block, i, count)
+				} else {
+					fmt.Fprintf(w, "BRDA:%d,%d,%d,-\n", b.Line, block, i)
+				}
+			}
+		}
+		if _, err := fmt.Fprintf(w, "BRF:%d\nBRH:%d\nend_of_record\n", branches, taken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func maxLine(lines map[int]int) int {
+	max := 0
+	for line := range lines {
+		if line > max {
+			max = line
+		}
+	}
+	return max
+}
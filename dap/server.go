@@ -0,0 +1,432 @@
+// Package dap implements a Debug Adapter Protocol server, letting editors
+// launch Monkey programs under the debug package's control.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/debug"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/framing"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// threadID identifies the only thread a Monkey program has.
+const threadID = 1
+
+// Server debugs one program for one client connection.
+type Server struct {
+	// mu guards the writer and the fields the goroutine forwarding the
+	// session's events shares with the one handling requests.
+	mu          sync.Mutex
+	w           io.Writer
+	seq         int
+	stopped     bool
+	terminating bool
+
+	program     string
+	stopOnEntry bool
+	session     *debug.Session
+	// done is closed once the program has exited and that was reported.
+	done chan struct{}
+	// refs holds the variables behind each variablesReference handed out
+	// since the program last stopped.
+	refs []func() []Variable
+}
+
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Serve reads requests from r and writes responses and events to w until
+// the client disconnects or closes r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	reader := bufio.NewReader(r)
+	for {
+		data, err := framing.Read(reader)
+		if errors.Is(err, io.EOF) {
+			s.terminate()
+			return nil
+		} else if err != nil {
+			s.terminate()
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return err
+		}
+		if err := s.handle(req); err != nil {
+			return err
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// send numbers msg, a response or an event, and writes it.
+func (s *Server) send(msg interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	switch msg := msg.(type) {
+	case *response:
+		msg.Seq = s.seq
+	case *event:
+		msg.Seq = s.seq
+	}
+	return framing.Write(s.w, msg)
+}
+
+func (s *Server) respond(req request, body interface{}) error {
+	return s.send(&response{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (s *Server) fail(req request, message string) error {
+	return s.send(&response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Message: message})
+}
+
+func (s *Server) event(name string, body interface{}) error {
+	return s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) handle(req request) error {
+	switch req.Command {
+	case "initialize":
+		return s.respond(req, Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		})
+	case "launch":
+		var args LaunchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		if err := s.launch(args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		if err := s.respond(req, nil); err != nil {
+			return err
+		}
+		// Breakpoints can only be checked against the program, so the
+		// client is asked for them once it is loaded.
+		return s.event("initialized", nil)
+	case "setBreakpoints":
+		var args SetBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		return s.respond(req, map[string]interface{}{"breakpoints": s.setBreakpoints(args)})
+	case "configurationDone":
+		if s.session == nil {
+			return s.fail(req, "no program launched")
+		}
+		if err := s.respond(req, nil); err != nil {
+			return err
+		}
+		s.done = make(chan struct{})
+		s.session.Start(s.stopOnEntry)
+		go s.forward()
+		return nil
+	case "threads":
+		return s.respond(req, map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}})
+	case "stackTrace":
+		if !s.isStopped() {
+			return s.fail(req, "program is running")
+		}
+		frames := []StackFrame{}
+		source := &Source{Name: filepath.Base(s.program), Path: s.program}
+		for i, frame := range s.session.Stack() {
+			frames = append(frames, StackFrame{ID: i + 1, Name: frame.Name, Source: source, Line: frame.Line, Column: frame.Column})
+		}
+		return s.respond(req, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+	case "scopes":
+		var args ScopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		frame, ok := s.frame(args.FrameID)
+		if !ok {
+			return s.fail(req, fmt.Sprintf("no frame %d", args.FrameID))
+		}
+		scopes := []Scope{}
+		for _, scope := range s.session.Scopes(frame) {
+			vars := scope.Variables
+			scopes = append(scopes, Scope{Name: scope.Name, VariablesReference: s.reference(func() []Variable {
+				var variables []Variable
+				for _, v := range vars {
+					variables = append(variables, s.variable(v.Name, v.Value))
+				}
+				return variables
+			})})
+		}
+		return s.respond(req, map[string]interface{}{"scopes": scopes})
+	case "variables":
+		var args VariablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		if args.VariablesReference < 1 || args.VariablesReference > len(s.refs) {
+			return s.fail(req, fmt.Sprintf("no variables %d", args.VariablesReference))
+		}
+		variables := s.refs[args.VariablesReference-1]()
+		if variables == nil {
+			variables = []Variable{}
+		}
+		return s.respond(req, map[string]interface{}{"variables": variables})
+	case "evaluate":
+		var args EvaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return s.fail(req, err.Error())
+		}
+		if !s.isStopped() {
+			return s.fail(req, "program is running")
+		}
+		frame := 0
+		if args.FrameID > 0 {
+			frame = args.FrameID - 1
+		}
+		val, err := s.session.Evaluate(args.Expression, frame)
+		if err != nil {
+			return s.fail(req, err.Error())
+		}
+		result := s.variable("", val)
+		return s.respond(req, map[string]interface{}{"result": result.Value, "variablesReference": result.VariablesReference})
+	case "continue", "next", "stepIn", "stepOut":
+		if !s.isStopped() {
+			return s.fail(req, "program is running")
+		}
+		var body interface{}
+		if req.Command == "continue" {
+			body = map[string]bool{"allThreadsContinued": true}
+		}
+		if err := s.respond(req, body); err != nil {
+			return err
+		}
+		s.resume(req.Command)
+		return nil
+	case "pause":
+		if s.session != nil {
+			s.session.Pause()
+		}
+		return s.respond(req, nil)
+	case "terminate", "disconnect":
+		s.terminate()
+		return s.respond(req, nil)
+	}
+	return s.fail(req, "unsupported command "+req.Command)
+}
+
+func (s *Server) launch(args LaunchArguments) error {
+	if s.session != nil {
+		return errors.New("a program is already launched")
+	}
+	source, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	// Imports are resolved relative to the directory of the program.
+	dir, file := filepath.Split(args.Program)
+	if dir == "" {
+		dir = "."
+	}
+	globals, loader, err := stdlib.NewGlobals(eval.FSResolver{FS: os.DirFS(dir)})
+	if err != nil {
+		return fmt.Errorf("error loading prelude: %w", err)
+	}
+	session, err := debug.NewSession(string(source), file, object.NewNestedEnv(globals), loader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args.Program, err)
+	}
+	// The program's output is reported to the client, as stdout is taken
+	// by the protocol, and it has no input.
+	session.SetStdio(strings.NewReader(""), outputWriter{s, "stdout"}, outputWriter{s, "stderr"})
+	s.program = args.Program
+	s.stopOnEntry = args.StopOnEntry
+	s.session = session
+	return nil
+}
+
+func (s *Server) setBreakpoints(args SetBreakpointsArguments) []Breakpoint {
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+	if s.session == nil || filepath.Clean(args.Source.Path) != filepath.Clean(s.program) {
+		for i, bp := range args.Breakpoints {
+			breakpoints[i] = Breakpoint{Line: bp.Line, Message: "not part of the program"}
+		}
+		return breakpoints
+	}
+	lines := make([]int, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line
+	}
+	for i, line := range s.session.SetBreakpoints(lines) {
+		if line == 0 {
+			breakpoints[i] = Breakpoint{Line: lines[i], Message: "no statement at or after this line"}
+		} else {
+			breakpoints[i] = Breakpoint{Verified: true, Line: line}
+		}
+	}
+	return breakpoints
+}
+
+// outputWriter reports what is written to it as output events of category.
+type outputWriter struct {
+	s        *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	if err := w.s.event("output", OutputEventBody{Category: w.category, Output: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// forward reports the events of the session to the client.
+func (s *Server) forward() {
+	defer close(s.done)
+	for ev := range s.session.Events() {
+		if ev.Reason == debug.StopExited {
+			exitCode := 0
+			if ev.Err != nil && ev.Err != debug.ErrTerminated {
+				exitCode = 1
+				s.event("output", OutputEventBody{Category: "stderr", Output: fmt.Sprintf("%s:%d: %s\n", s.program, ev.Line, ev.Err)})
+			}
+			s.event("exited", ExitedEventBody{ExitCode: exitCode})
+			s.event("terminated", nil)
+			continue
+		}
+		s.mu.Lock()
+		s.stopped = true
+		terminating := s.terminating
+		s.mu.Unlock()
+		if terminating {
+			s.session.Terminate()
+			continue
+		}
+		s.event("stopped", StoppedEventBody{Reason: ev.Reason, ThreadID: threadID, AllThreadsStopped: true})
+	}
+}
+
+func (s *Server) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session != nil && s.stopped
+}
+
+// resume lets the stopped program run on as command asks.
+func (s *Server) resume(command string) {
+	s.mu.Lock()
+	s.stopped = false
+	s.mu.Unlock()
+	s.refs = nil
+	switch command {
+	case "continue":
+		s.session.Continue()
+	case "next":
+		s.session.StepOver()
+	case "stepIn":
+		s.session.StepIn()
+	case "stepOut":
+		s.session.StepOut()
+	}
+}
+
+// terminate ends the program, if it is running, and waits until its exit
+// has been reported. A running program is paused first, since it can only
+// be ended while stopped.
+func (s *Server) terminate() {
+	if s.done == nil {
+		return
+	}
+	s.mu.Lock()
+	alreadyTerminating := s.terminating
+	s.terminating = true
+	stopped := s.stopped
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if !alreadyTerminating {
+		if stopped {
+			s.session.Terminate()
+		} else {
+			s.session.Pause()
+		}
+	}
+	<-s.done
+}
+
+func (s *Server) frame(id int) (debug.Frame, bool) {
+	if !s.isStopped() {
+		return debug.Frame{}, false
+	}
+	stack := s.session.Stack()
+	if id < 1 || id > len(stack) {
+		return debug.Frame{}, false
+	}
+	return stack[id-1], true
+}
+
+// reference hands out a variablesReference for the variables f returns.
+func (s *Server) reference(f func() []Variable) int {
+	s.refs = append(s.refs, f)
+	return len(s.refs)
+}
+
+// variable describes a value, letting the client expand arrays and maps
+// into their elements.
+func (s *Server) variable(name string, val object.Object) Variable {
+	v := Variable{Name: name, Value: display(val)}
+	switch val := val.(type) {
+	case object.Array:
+		if len(val.Elements) > 0 {
+			v.VariablesReference = s.reference(func() []Variable {
+				var elements []Variable
+				for i, element := range val.Elements {
+					elements = append(elements, s.variable(fmt.Sprintf("[%d]", i), element))
+				}
+				return elements
+			})
+		}
+	case object.Map:
+		if len(val.Elements) > 0 {
+			v.VariablesReference = s.reference(func() []Variable {
+				var pairs []object.KV
+				for _, kvs := range val.Elements {
+					pairs = append(pairs, kvs...)
+				}
+				sort.Slice(pairs, func(i, j int) bool {
+					return display(pairs[i].Key) < display(pairs[j].Key)
+				})
+				var elements []Variable
+				for _, kv := range pairs {
+					elements = append(elements, s.variable(display(kv.Key), kv.Value))
+				}
+				return elements
+			})
+		}
+	}
+	return v
+}
+
+func display(val object.Object) string {
+	if str, ok := val.(object.String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return val.String()
+}
@@ -0,0 +1,310 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/carsonip/monkey-interpreter/framing"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// client talks to a server running in another goroutine, as an editor would.
+type client struct {
+	t        *testing.T
+	w        io.WriteCloser
+	messages chan map[string]json.RawMessage
+	events   []map[string]json.RawMessage
+	done     chan error
+	seq      int
+}
+
+func newClient(t *testing.T) *client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, w: inW, messages: make(chan map[string]json.RawMessage, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer().Serve(inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		reader := bufio.NewReader(outR)
+		for {
+			data, err := framing.Read(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(data, &msg); err == nil {
+				c.messages <- msg
+			}
+		}
+	}()
+	return c
+}
+
+func (c *client) next() map[string]json.RawMessage {
+	msg, ok := <-c.messages
+	if !ok {
+		c.t.Fatal("server closed the connection")
+	}
+	return msg
+}
+
+// call sends a request and returns its response, keeping the events that
+// arrive first for event.
+func (c *client) call(command string, args interface{}) response {
+	c.seq++
+	assert.NoError(c.t, framing.Write(c.w, map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args}))
+	for {
+		msg := c.next()
+		if string(msg["type"]) == `"event"` {
+			c.events = append(c.events, msg)
+			continue
+		}
+		var resp response
+		assert.NoError(c.t, json.Unmarshal(msg["request_seq"], &resp.RequestSeq))
+		assert.NoError(c.t, json.Unmarshal(msg["success"], &resp.Success))
+		assert.NoError(c.t, json.Unmarshal(msg["command"], &resp.Command))
+		if message, ok := msg["message"]; ok {
+			assert.NoError(c.t, json.Unmarshal(message, &resp.Message))
+		}
+		resp.Body = msg["body"]
+		assert.Equal(c.t, c.seq, resp.RequestSeq)
+		assert.Equal(c.t, command, resp.Command)
+		return resp
+	}
+}
+
+// do makes a request that must succeed and decodes its body into body.
+func (c *client) do(command string, args interface{}, body interface{}) {
+	resp := c.call(command, args)
+	assert.True(c.t, resp.Success, resp.Message)
+	if body != nil {
+		assert.NoError(c.t, json.Unmarshal(resp.Body.(json.RawMessage), body))
+	}
+}
+
+// event returns the next event, which must be called name, decoding its
+// body into body.
+func (c *client) event(name string, body interface{}) {
+	var msg map[string]json.RawMessage
+	if len(c.events) > 0 {
+		msg, c.events = c.events[0], c.events[1:]
+	} else {
+		msg = c.next()
+	}
+	assert.Equal(c.t, `"`+name+`"`, string(msg["event"]))
+	if body != nil {
+		assert.NoError(c.t, json.Unmarshal(msg["body"], body))
+	}
+}
+
+func (c *client) stopped() string {
+	var body StoppedEventBody
+	c.event("stopped", &body)
+	assert.Equal(c.t, threadID, body.ThreadID)
+	return body.Reason
+}
+
+func (c *client) exited() int {
+	var body ExitedEventBody
+	c.event("exited", &body)
+	c.event("terminated", nil)
+	return body.ExitCode
+}
+
+func (c *client) disconnect() {
+	c.do("disconnect", nil, nil)
+	assert.NoError(c.t, <-c.done)
+}
+
+// launch starts a debugging session of source, returning its path.
+func (c *client) launch(source string, stopOnEntry bool) string {
+	program := filepath.Join(c.t.TempDir(), "main.mk")
+	assert.NoError(c.t, os.WriteFile(program, []byte(source), 0o644))
+	var capabilities Capabilities
+	c.do("initialize", map[string]string{"adapterID": "monkey"}, &capabilities)
+	assert.True(c.t, capabilities.SupportsConfigurationDoneRequest)
+	c.do("launch", LaunchArguments{Program: program, StopOnEntry: stopOnEntry}, nil)
+	c.event("initialized", nil)
+	c.do("configurationDone", nil, nil)
+	return program
+}
+
+func (c *client) variables(ref int) map[string]Variable {
+	var body struct {
+		Variables []Variable `json:"variables"`
+	}
+	c.do("variables", VariablesArguments{VariablesReference: ref}, &body)
+	vars := make(map[string]Variable)
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+const source = `let add = fn(a, b) {
+	let sum = a + b;
+	return sum;
+};
+let xs = [1, "two", {"k": 3}];
+let total = add(1, 2);
+total;
+`
+
+func TestServer_Session(t *testing.T) {
+	c := newClient(t)
+	program := filepath.Join(t.TempDir(), "main.mk")
+	assert.NoError(t, os.WriteFile(program, []byte(source), 0o644))
+	c.do("initialize", map[string]string{"adapterID": "monkey"}, nil)
+	c.do("launch", LaunchArguments{Program: program}, nil)
+	c.event("initialized", nil)
+
+	var breakpoints struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.do("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: program},
+		Breakpoints: []SourceBreakpoint{{Line: 1}, {Line: 3}, {Line: 100}},
+	}, &breakpoints)
+	assert.Equal(t, []Breakpoint{
+		{Verified: true, Line: 1},
+		{Verified: true, Line: 3},
+		{Line: 100, Message: "no statement at or after this line"},
+	}, breakpoints.Breakpoints)
+	c.do("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: program},
+		Breakpoints: []SourceBreakpoint{{Line: 3}},
+	}, nil)
+	c.do("configurationDone", nil, nil)
+	assert.Equal(t, "breakpoint", c.stopped())
+
+	var threads struct {
+		Threads []Thread `json:"threads"`
+	}
+	c.do("threads", nil, &threads)
+	assert.Equal(t, []Thread{{ID: threadID, Name: "main"}}, threads.Threads)
+
+	var trace struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.do("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	src := &Source{Name: "main.mk", Path: program}
+	assert.Equal(t, []StackFrame{
+		{ID: 1, Name: "add", Source: src, Line: 3, Column: 2},
+		{ID: 2, Name: "<main>", Source: src, Line: 6, Column: 1},
+	}, trace.StackFrames)
+
+	var scopes struct {
+		Scopes []Scope `json:"scopes"`
+	}
+	c.do("scopes", ScopesArguments{FrameID: 1}, &scopes)
+	if assert.Len(t, scopes.Scopes, 2) {
+		assert.Equal(t, "Locals", scopes.Scopes[0].Name)
+		assert.Equal(t, map[string]Variable{
+			"a":   {Name: "a", Value: "1"},
+			"b":   {Name: "b", Value: "2"},
+			"sum": {Name: "sum", Value: "3"},
+		}, c.variables(scopes.Scopes[0].VariablesReference))
+		assert.Equal(t, "Globals", scopes.Scopes[1].Name)
+		globals := c.variables(scopes.Scopes[1].VariablesReference)
+		assert.Equal(t, "fn", globals["add"].Value)
+		xs := globals["xs"]
+		assert.Equal(t, `[1, "two", {"k": 3}]`, xs.Value)
+		elements := c.variables(xs.VariablesReference)
+		assert.Equal(t, Variable{Name: "[1]", Value: `"two"`}, elements["[1]"])
+		assert.Equal(t, map[string]Variable{`"k"`: {Name: `"k"`, Value: "3"}}, c.variables(elements["[2]"].VariablesReference))
+	}
+	c.do("scopes", ScopesArguments{FrameID: 2}, &scopes)
+	assert.Equal(t, []string{"Globals"}, []string{scopes.Scopes[0].Name})
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	c.do("evaluate", EvaluateArguments{Expression: "sum * 10", FrameID: 1, Context: "watch"}, &result)
+	assert.Equal(t, "30", result.Result)
+	resp := c.call("evaluate", EvaluateArguments{Expression: "a", FrameID: 2})
+	assert.False(t, resp.Success)
+	assert.Equal(t, "unknown identifier", resp.Message)
+
+	c.do("next", map[string]int{"threadId": threadID}, nil)
+	assert.Equal(t, "step", c.stopped())
+	c.do("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	assert.Equal(t, []StackFrame{{ID: 1, Name: "<main>", Source: src, Line: 7, Column: 1}}, trace.StackFrames)
+
+	c.do("continue", map[string]int{"threadId": threadID}, nil)
+	assert.Equal(t, 0, c.exited())
+	resp = c.call("stackTrace", map[string]int{"threadId": threadID})
+	assert.False(t, resp.Success)
+	c.disconnect()
+}
+
+func TestServer_Stepping(t *testing.T) {
+	c := newClient(t)
+	c.launch(source, true)
+	assert.Equal(t, "entry", c.stopped())
+	var lines []int
+	for _, command := range []string{"next", "next", "stepIn", "stepIn", "stepOut"} {
+		c.do(command, map[string]int{"threadId": threadID}, nil)
+		assert.Equal(t, "step", c.stopped())
+		var trace struct {
+			StackFrames []StackFrame `json:"stackFrames"`
+		}
+		c.do("stackTrace", map[string]int{"threadId": threadID}, &trace)
+		lines = append(lines, trace.StackFrames[0].Line)
+	}
+	assert.Equal(t, []int{5, 6, 2, 3, 7}, lines)
+	c.disconnect()
+}
+
+func TestServer_PauseAndTerminate(t *testing.T) {
+	c := newClient(t)
+	c.launch("let i = 0;\nwhile (true) {\n\ti++;\n}\n", false)
+	c.do("pause", map[string]int{"threadId": threadID}, nil)
+	assert.Equal(t, "pause", c.stopped())
+	var result struct {
+		Result string `json:"result"`
+	}
+	c.do("evaluate", EvaluateArguments{Expression: "len([1, 2])"}, &result)
+	assert.Equal(t, "2", result.Result)
+	c.do("continue", map[string]int{"threadId": threadID}, nil)
+	c.do("terminate", nil, nil)
+	assert.Equal(t, 0, c.exited())
+	c.disconnect()
+}
+
+func TestServer_Output(t *testing.T) {
+	c := newClient(t)
+	c.launch("println(\"hello\", 1);\neprint(input());\n", false)
+	var output OutputEventBody
+	c.event("output", &output)
+	assert.Equal(t, OutputEventBody{Category: "stdout", Output: "hello 1\n"}, output)
+	c.event("output", &output)
+	assert.Equal(t, OutputEventBody{Category: "stderr", Output: "null\n"}, output)
+	assert.Equal(t, 0, c.exited())
+	c.disconnect()
+}
+
+func TestServer_Errors(t *testing.T) {
+	c := newClient(t)
+	program := c.launch("let x = 1;\nx / 0;\n", false)
+	var output OutputEventBody
+	c.event("output", &output)
+	assert.Equal(t, OutputEventBody{Category: "stderr", Output: program + ":2: division by zero\n"}, output)
+	assert.Equal(t, 1, c.exited())
+	c.disconnect()
+
+	c = newClient(t)
+	c.do("initialize", nil, nil)
+	resp := c.call("launch", LaunchArguments{Program: filepath.Join(t.TempDir(), "missing.mk")})
+	assert.False(t, resp.Success)
+	resp = c.call("configurationDone", nil)
+	assert.Equal(t, "no program launched", resp.Message)
+	resp = c.call("restart", nil)
+	assert.Equal(t, "unsupported command restart", resp.Message)
+	c.disconnect()
+}
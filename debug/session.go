@@ -0,0 +1,471 @@
+// Package debug runs Monkey programs under the control of a debugger, with
+// line breakpoints, stepping and inspection of the call stack and variables.
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/printer"
+	"github.com/carsonip/monkey-interpreter/token"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Reasons a program stops.
+const (
+	StopEntry      = "entry"
+	StopBreakpoint = "breakpoint"
+	StopStep       = "step"
+	StopPause      = "pause"
+	StopExited     = "exited"
+)
+
+// ErrTerminated is the error of a program ended by Terminate.
+var ErrTerminated = errors.New("terminated")
+
+// Event reports that the program stopped at a statement, or exited with
+// the value of its last statement or an error.
+type Event struct {
+	Reason string
+	Line   int
+	Result object.Object
+	Err    error
+}
+
+// Frame is a call of a function of the program, or its top level.
+type Frame struct {
+	// Name is the called expression, or "<main>" for the top level.
+	Name string
+	// Line and Column locate the statement the frame is running.
+	Line   int
+	Column int
+	// Env is the innermost scope of that statement.
+	Env *object.Env
+	// Scope is the scope the call started in, holding its parameters.
+	Scope *object.Env
+}
+
+type action int
+
+const (
+	actionContinue action = iota
+	actionStepIn
+	actionStepOver
+	actionStepOut
+	actionTerminate
+)
+
+// Session is a program being debugged. The program runs in its own
+// goroutine; while it is stopped, the controlling goroutine inspects it
+// with Stack, Scopes and Evaluate and resumes it with Continue or a step.
+type Session struct {
+	program    *ast.Program
+	env        *object.Env
+	loader     *eval.ModuleLoader
+	path       string
+	stdio      *object.Stdio
+	statements map[ast.Node]bool
+	lines      []int
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       atomic.Bool
+
+	// frames and calls are only touched by the program's goroutine, or by
+	// the controller while the program is stopped.
+	frames []*Frame
+	// failed is the stack when the innermost call that failed did, until
+	// the program carries on, so that a failure is reported where it
+	// happened once the calls have returned.
+	failed []*Frame
+	// calls records for each active call whether it pushed a frame.
+	calls  []bool
+	action action
+	depth  int
+	entry  bool
+
+	events chan Event
+	resume chan action
+}
+
+// NewSession parses source, the program at path, to be run in env with
+// imports resolved by loader.
+func NewSession(source string, path string, env *object.Env, loader *eval.ModuleLoader) (session *Session, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	lex := token.NewLexer(source)
+	p := parser.NewParser(&lex)
+	program := p.ParseProgram()
+	s := &Session{
+		program:     program,
+		env:         env,
+		loader:      loader,
+		path:        path,
+		statements:  make(map[ast.Node]bool),
+		breakpoints: make(map[int]bool),
+		events:      make(chan Event),
+		resume:      make(chan action),
+	}
+	s.findStatements()
+	return s, nil
+}
+
+// findStatements records the statements of the program, where it can stop,
+// and the lines they start on.
+func (s *Session) findStatements() {
+	add := func(nodes []ast.Node) {
+		for _, node := range nodes {
+			s.statements[node] = true
+		}
+	}
+	add(s.program.Statements)
+	ast.Inspect(s.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStatement:
+			add(node.Then)
+			add(node.Else)
+		case *ast.WhileStatement:
+			add(node.Body)
+		case *ast.Function:
+			add(node.Body)
+		}
+		return true
+	})
+	seen := make(map[int]bool)
+	for node := range s.statements {
+		line := ast.Start(node).Line
+		if !seen[line] {
+			seen[line] = true
+			s.lines = append(s.lines, line)
+		}
+	}
+	sort.Ints(s.lines)
+}
+
+// SetBreakpoints replaces the breakpoints. A breakpoint on a line where no
+// statement starts moves to the next line that has one. The returned lines
+// are where the breakpoints ended up, 0 for those past the last statement.
+func (s *Session) SetBreakpoints(lines []int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakpoints = make(map[int]bool)
+	actual := make([]int, len(lines))
+	for i, line := range lines {
+		j := sort.SearchInts(s.lines, line)
+		if j < len(s.lines) {
+			actual[i] = s.lines[j]
+			s.breakpoints[s.lines[j]] = true
+		}
+	}
+	return actual
+}
+
+// SetStdio sets the streams the program's input and output builtins use.
+// Without them those builtins fail. It must be called before Start.
+func (s *Session) SetStdio(in io.Reader, out io.Writer, err io.Writer) {
+	s.stdio = object.NewStdio(in, out, err)
+}
+
+// Start runs the program, stopping before its first statement if
+// stopOnEntry is set. Events reports where it stops.
+func (s *Session) Start(stopOnEntry bool) {
+	s.frames = []*Frame{{Name: "<main>", Env: s.env, Scope: s.env}}
+	s.entry = stopOnEntry
+	if stopOnEntry {
+		s.action = actionStepIn
+	}
+	go s.run()
+}
+
+func (s *Session) run() {
+	event := Event{Reason: StopExited, Result: object.NULL}
+	defer func() {
+		if r := recover(); r != nil {
+			if r == ErrTerminated {
+				event.Err = ErrTerminated
+			} else {
+				// A panic that is not an object.Error is a bug in the
+				// evaluator or a builtin, but it only ends the program.
+				event.Err = fmt.Errorf("internal error: %v", r)
+				event.Line = s.failureLine()
+			}
+		}
+		s.events <- event
+		close(s.events)
+	}()
+	ev := s.evaluator(nil, s.env)
+	ev.SetHooks(s)
+	for _, node := range s.program.Statements {
+		result := ev.Eval(node, s.env)
+		if err, ok := result.(object.Error); ok {
+			event.Err = err
+			event.Line = s.failureLine()
+			return
+		}
+		event.Result = result
+	}
+}
+
+// failureLine returns the line of the statement the program failed at.
+func (s *Session) failureLine() int {
+	frames := s.failed
+	if frames == nil {
+		frames = s.frames
+	}
+	return frames[len(frames)-1].Line
+}
+
+// Events delivers an event each time the program stops and a last one when
+// it exits, after which it is closed.
+func (s *Session) Events() <-chan Event {
+	return s.events
+}
+
+// Wait returns the next event.
+func (s *Session) Wait() Event {
+	event, ok := <-s.events
+	if !ok {
+		return Event{Reason: StopExited, Err: ErrTerminated}
+	}
+	return event
+}
+
+// Continue resumes the stopped program until it reaches a breakpoint.
+func (s *Session) Continue() {
+	s.resume <- actionContinue
+}
+
+// StepIn resumes the stopped program until the next statement.
+func (s *Session) StepIn() {
+	s.resume <- actionStepIn
+}
+
+// StepOver resumes the stopped program until the next statement of the
+// current function or a caller.
+func (s *Session) StepOver() {
+	s.resume <- actionStepOver
+}
+
+// StepOut resumes the stopped program until the next statement of a caller
+// of the current function.
+func (s *Session) StepOut() {
+	s.resume <- actionStepOut
+}
+
+// Terminate ends the stopped program.
+func (s *Session) Terminate() {
+	s.resume <- actionTerminate
+}
+
+// Pause asks the running program to stop at the next statement.
+func (s *Session) Pause() {
+	s.pause.Store(true)
+}
+
+// Stack returns the frames of the stopped program, innermost first.
+func (s *Session) Stack() []Frame {
+	stack := make([]Frame, len(s.frames))
+	for i, frame := range s.frames {
+		stack[len(s.frames)-1-i] = *frame
+	}
+	return stack
+}
+
+// Variable is a name bound in a scope.
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+// Locals returns the variables visible in frame that the function declared,
+// innermost scope first, leaving out names shadowed by an inner scope.
+func Locals(frame Frame) []Variable {
+	var vars []Variable
+	seen := make(map[string]bool)
+	for env := frame.Env; env != nil; env = env.Parent() {
+		for _, name := range env.Names() {
+			if !seen[name] {
+				seen[name] = true
+				vars = append(vars, Variable{Name: name, Value: env.MustGet(name)})
+			}
+		}
+		if env == frame.Scope {
+			break
+		}
+	}
+	return vars
+}
+
+// Scope is a group of variables visible in a frame.
+type Scope struct {
+	Name      string
+	Variables []Variable
+}
+
+// Scopes returns the variables visible in frame grouped by the part of the
+// scope chain that declares them: the function itself and the blocks it is
+// running, the functions it is nested in, and the top level of the
+// program. Names shadowed by an inner scope are left out, as are groups
+// with no variables except the top level.
+func (s *Session) Scopes(frame Frame) []Scope {
+	locals := Scope{Name: "Locals"}
+	closure := Scope{Name: "Closure"}
+	globals := Scope{Name: "Globals"}
+	scope := &locals
+	seen := make(map[string]bool)
+	for env := frame.Env; env != nil; env = env.Parent() {
+		if env == s.env {
+			scope = &globals
+		}
+		for _, name := range env.Names() {
+			if !seen[name] {
+				seen[name] = true
+				scope.Variables = append(scope.Variables, Variable{Name: name, Value: env.MustGet(name)})
+			}
+		}
+		if env == s.env {
+			break
+		} else if env == frame.Scope {
+			scope = &closure
+		}
+	}
+	var scopes []Scope
+	for _, scope := range []Scope{locals, closure} {
+		if len(scope.Variables) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	return append(scopes, globals)
+}
+
+// Globals returns the variables declared at the top level of the program.
+func (s *Session) Globals() []Variable {
+	var vars []Variable
+	for _, name := range s.env.Names() {
+		vars = append(vars, Variable{Name: name, Value: s.env.MustGet(name)})
+	}
+	return vars
+}
+
+// Evaluate evaluates source, one or more statements, in the innermost scope
+// of frame, the index of a frame from Stack, and returns the value of the
+// last one.
+func (s *Session) Evaluate(source string, frame int) (result object.Object, err error) {
+	if frame < 0 || frame >= len(s.frames) {
+		return nil, fmt.Errorf("no frame %d", frame)
+	}
+	env := s.frames[len(s.frames)-1-frame].Env
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	lex := token.NewLexer(source)
+	p := parser.NewParser(&lex)
+	ev := s.evaluator(&p, env)
+	result = object.NULL
+	for obj := ev.EvalNext(env); obj != nil; obj = ev.EvalNext(env) {
+		if err, ok := obj.(object.Error); ok {
+			return nil, err
+		}
+		result = obj
+	}
+	return result, nil
+}
+
+// Before implements eval.Hooks, stopping the program at statements as the
+// breakpoints and the last step ask.
+func (s *Session) Before(node ast.Node, env *object.Env) {
+	s.failed = nil
+	if !s.statements[node] {
+		return
+	}
+	frame := s.frames[len(s.frames)-1]
+	start := ast.Start(node)
+	frame.Line, frame.Column, frame.Env = start.Line, start.Column, env
+
+	reason := ""
+	switch {
+	case s.pause.Swap(false):
+		reason = StopPause
+	case s.action == actionStepIn,
+		s.action == actionStepOver && len(s.frames) <= s.depth,
+		s.action == actionStepOut && len(s.frames) < s.depth:
+		reason = StopStep
+		if s.entry {
+			reason = StopEntry
+			s.entry = false
+		}
+	}
+	if reason == "" {
+		s.mu.Lock()
+		if s.breakpoints[start.Line] {
+			reason = StopBreakpoint
+		}
+		s.mu.Unlock()
+	}
+	if reason == "" {
+		return
+	}
+
+	s.events <- Event{Reason: reason, Line: start.Line}
+	s.action = <-s.resume
+	s.depth = len(s.frames)
+	if s.action == actionTerminate {
+		panic(ErrTerminated)
+	}
+}
+
+// Call implements eval.Hooks. Calls of functions defined outside the
+// program, such as those of the prelude, get no frame of their own.
+func (s *Session) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {
+	if !s.defines(fn) {
+		s.calls = append(s.calls, false)
+		return
+	}
+	s.calls = append(s.calls, true)
+	start := ast.Start(call)
+	s.frames = append(s.frames, &Frame{Name: printer.Print(call.FunctionExpr), Line: start.Line, Column: start.Column, Env: env, Scope: env})
+}
+
+// Return implements eval.Hooks.
+func (s *Session) Return(call *ast.FunctionCall, result object.Object) {
+	pushed := s.calls[len(s.calls)-1]
+	s.calls = s.calls[:len(s.calls)-1]
+	if result == nil && s.failed == nil {
+		s.failed = append([]*Frame{}, s.frames...)
+	}
+	if pushed {
+		s.frames = s.frames[:len(s.frames)-1]
+	}
+}
+
+// defines reports whether fn was defined by the program, in its top-level
+// scope or one nested in it.
+func (s *Session) defines(fn object.Function) bool {
+	for env := fn.Env; env != nil; env = env.Parent() {
+		if env == s.env {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluator returns an evaluator for code of the program running in env.
+func (s *Session) evaluator(p *parser.Parser, env *object.Env) eval.Evaluator {
+	ev := eval.NewEvaluator(p, env)
+	ev.SetModuleLoader(s.loader, s.path)
+	if s.stdio != nil {
+		ev.SetStdio(s.stdio.In, s.stdio.Out, s.stdio.Err)
+	}
+	return ev
+}
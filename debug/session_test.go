@@ -0,0 +1,215 @@
+package debug
+
+import (
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newSession(t *testing.T, source string) *Session {
+	globals, loader, err := stdlib.NewGlobals(eval.MapResolver{})
+	assert.NoError(t, err)
+	session, err := NewSession(source, "main.mk", object.NewNestedEnv(globals), loader)
+	assert.NoError(t, err)
+	return session
+}
+
+func names(stack []Frame) []string {
+	var names []string
+	for _, frame := range stack {
+		names = append(names, frame.Name)
+	}
+	return names
+}
+
+func TestSession_Breakpoints(t *testing.T) {
+	s := newSession(t, `let i = 0;
+
+while (i < 3) {
+	i++;
+}
+i;`)
+	assert.Equal(t, []int{3, 4, 0}, s.SetBreakpoints([]int{2, 4, 7}))
+	s.Start(false)
+	assert.Equal(t, Event{Reason: StopBreakpoint, Line: 3}, s.Wait())
+	s.Continue()
+	for want := 0; want < 3; want++ {
+		assert.Equal(t, Event{Reason: StopBreakpoint, Line: 4}, s.Wait())
+		i, err := s.Evaluate("i", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, object.NewInteger(want), i)
+		s.Continue()
+	}
+	assert.Equal(t, Event{Reason: StopExited, Result: object.NewInteger(3)}, s.Wait())
+}
+
+func TestSession_Stack(t *testing.T) {
+	s := newSession(t, `let twice = fn(x) {
+	return x * 2;
+};
+let xs = map([1, 2], fn(x) { return twice(x); });
+xs;`)
+	s.SetBreakpoints([]int{2})
+	s.Start(false)
+	assert.Equal(t, Event{Reason: StopBreakpoint, Line: 2}, s.Wait())
+	// The call of the prelude's map gets no frame, but the callback, named
+	// as map calls it, does.
+	stack := s.Stack()
+	assert.Equal(t, []string{"twice", "f", "<main>"}, names(stack))
+	assert.Equal(t, []Variable{{Name: "x", Value: object.NewInteger(1)}}, Locals(stack[0]))
+	assert.Equal(t, 4, stack[1].Line)
+	x, err := s.Evaluate("x + 10", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, object.NewInteger(11), x)
+	_, err = s.Evaluate("y", 0)
+	assert.EqualError(t, err, "unknown identifier")
+	_, err = s.Evaluate("x", 3)
+	assert.EqualError(t, err, "no frame 3")
+
+	s.SetBreakpoints(nil)
+	s.StepOut()
+	assert.Equal(t, Event{Reason: StopStep, Line: 4}, s.Wait())
+	assert.Equal(t, []string{"f", "<main>"}, names(s.Stack()))
+	s.StepOut()
+	assert.Equal(t, Event{Reason: StopStep, Line: 5}, s.Wait())
+	assert.Equal(t, []string{"<main>"}, names(s.Stack()))
+	assert.Equal(t, []string{"twice", "xs"}, varNames(s.Globals()))
+	s.Continue()
+	assert.Equal(t, "[2, 4]", s.Wait().Result.String())
+}
+
+func varNames(vars []Variable) []string {
+	var names []string
+	for _, v := range vars {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func TestSession_Stepping(t *testing.T) {
+	s := newSession(t, `let f = fn() {
+	let a = 1;
+	return a;
+};
+f();
+let b = f();`)
+	s.Start(true)
+	lines := func(step func()) []int {
+		var lines []int
+		for {
+			event := s.Wait()
+			if event.Reason == StopExited {
+				return lines
+			}
+			lines = append(lines, event.Line)
+			step()
+		}
+	}
+	assert.Equal(t, []int{1, 5, 2, 3, 6, 2, 3}, lines(s.StepIn))
+
+	s = newSession(t, `let f = fn() {
+	let a = 1;
+	return a;
+};
+f();
+let b = f();`)
+	s.Start(true)
+	assert.Equal(t, []int{1, 5, 6}, lines(s.StepOver))
+}
+
+func TestSession_PauseAndTerminate(t *testing.T) {
+	s := newSession(t, `let i = 0;
+while (true) {
+	i++;
+}`)
+	s.Start(false)
+	s.Pause()
+	event := s.Wait()
+	assert.Equal(t, StopPause, event.Reason)
+	s.Terminate()
+	assert.Equal(t, Event{Reason: StopExited, Result: object.NULL, Err: ErrTerminated}, s.Wait())
+	assert.Equal(t, Event{Reason: StopExited, Err: ErrTerminated}, s.Wait())
+}
+
+func TestSession_Error(t *testing.T) {
+	s := newSession(t, "let x = 1;\nx / 0;\n")
+	s.Start(false)
+	event := s.Wait()
+	assert.Equal(t, StopExited, event.Reason)
+	assert.Equal(t, 2, event.Line)
+	assert.EqualError(t, event.Err, "division by zero")
+
+	_, err := NewSession("let = 1;", "main.mk", object.NewEnv(), nil)
+	assert.Error(t, err)
+
+	s = newSession(t, "let f = fn() {\n\treturn 1 / 0;\n};\nf();\n")
+	s.Start(false)
+	event = s.Wait()
+	assert.Equal(t, 2, event.Line)
+	assert.EqualError(t, event.Err, "division by zero")
+}
+
+func TestSession_CaughtError(t *testing.T) {
+	s := newSession(t, `let fail = fn() { return 1 / 0; };
+let f = fn() {
+	assertThrows(fail);
+	return 1;
+};
+f();`)
+	s.SetBreakpoints([]int{4})
+	s.Start(false)
+	assert.Equal(t, Event{Reason: StopBreakpoint, Line: 4}, s.Wait())
+	assert.Equal(t, []string{"f", "<main>"}, names(s.Stack()))
+	s.Continue()
+	assert.Equal(t, Event{Reason: StopExited, Result: object.NewInteger(1)}, s.Wait())
+}
+
+// panicResolver panics with a value that is not an object.Error.
+type panicResolver struct{}
+
+func (panicResolver) Resolve(from string, importPath string) (string, string, error) {
+	panic("resolver bug")
+}
+
+func TestSession_InternalError(t *testing.T) {
+	session, err := NewSession("let x = 1;\nimport \"a.mk\" as a;\n", "main.mk", object.NewEnv(), eval.NewModuleLoader(panicResolver{}))
+	assert.NoError(t, err)
+	session.Start(false)
+	event := session.Wait()
+	assert.Equal(t, StopExited, event.Reason)
+	assert.EqualError(t, event.Err, "internal error: resolver bug")
+}
+
+func TestSession_Scopes(t *testing.T) {
+	s := newSession(t, `let x = 1;
+let counter = fn(start) {
+	let count = start;
+	return fn(step) {
+		if (true) {
+			let x = 2;
+			count += step + x;
+		}
+		return count;
+	};
+};
+let inc = counter(10);
+inc(1);`)
+	s.SetBreakpoints([]int{7})
+	s.Start(false)
+	assert.Equal(t, Event{Reason: StopBreakpoint, Line: 7}, s.Wait())
+	var scopes []string
+	for _, scope := range s.Scopes(s.Stack()[0]) {
+		scopes = append(scopes, scope.Name+" "+strings.Join(varNames(scope.Variables), ","))
+	}
+	assert.Equal(t, []string{"Locals x,step", "Closure count,start", "Globals counter,inc"}, scopes)
+	scopes = nil
+	for _, scope := range s.Scopes(s.Stack()[1]) {
+		scopes = append(scopes, scope.Name+" "+strings.Join(varNames(scope.Variables), ","))
+	}
+	assert.Equal(t, []string{"Globals counter,inc,x"}, scopes)
+	s.Continue()
+	assert.Equal(t, object.NewInteger(13), s.Wait().Result)
+}
@@ -1,24 +1,325 @@
 package eval
 
 import (
+	"fmt"
+	"io"
 	"github.com/carsonip/monkey-interpreter/object"
+	"strings"
 )
 
 var BUILTINS = map[string]object.BuiltinFunction{
 	"len": {Fn: _len},
+	"arity": {Fn: _arity},
+	"push": {Fn: _push},
+	"keys": {Fn: _keys},
+	"chars": {Fn: _chars},
+	"freeze": {Fn: _freeze},
+	"str": {Fn: _str},
+	"print": {StdioFn: _print},
+	"println": {StdioFn: _println},
+	"printf": {StdioFn: _printf},
+	"eprint": {StdioFn: _eprint},
+	"input": {StdioFn: _input},
+	"assert": {Fn: _assert},
+	"assertEqual": {Fn: _assertEqual},
+	"assertThrows": {CallFn: _assertThrows},
 }
 
 func _len(args ...object.Object) object.Object {
 	if len(args) != 1 {
-		panic("bad args len for Len")
+		panic(object.NewError("bad args len for len"))
 	}
 	obj := args[0]
 	switch obj := obj.(type) {
 	case object.Array:
 		return object.NewInteger(len(obj.Elements))
 	case object.String:
-		return object.NewInteger(len(obj.Value))
+		return object.NewInteger(obj.Len())
 	default:
-		panic("unsupported type for Len")
+		panic(object.NewError("unsupported type for len"))
 	}
 }
+
+// _arity returns the least and most arguments a function accepts, the most
+// being null for functions with a variadic parameter.
+func _arity(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		panic(object.NewError("bad args len for arity"))
+	}
+	fn, ok := args[0].(object.Function)
+	if !ok {
+		panic(object.NewError("unsupported type for arity"))
+	}
+	min, max := fn.Arity()
+	var most object.Object = object.NULL
+	if max >= 0 {
+		most = object.NewInteger(max)
+	}
+	return object.NewArray([]object.Object{object.NewInteger(min), most})
+}
+
+func _push(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		panic(object.NewError("bad args len for push"))
+	}
+	arr, ok := args[0].(object.Array)
+	if !ok {
+		panic(object.NewError("unsupported type for push"))
+	}
+	elements := make([]object.Object, len(arr.Elements), len(arr.Elements)+1)
+	copy(elements, arr.Elements)
+	return object.NewArray(append(elements, args[1]))
+}
+
+func _keys(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		panic(object.NewError("bad args len for keys"))
+	}
+	m, ok := args[0].(object.Map)
+	if !ok {
+		panic(object.NewError("unsupported type for keys"))
+	}
+	var keys []object.Object
+	for _, pairs := range m.Elements {
+		for _, kv := range pairs {
+			keys = append(keys, kv.Key)
+		}
+	}
+	return object.NewArray(keys)
+}
+
+func _chars(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		panic(object.NewError("bad args len for chars"))
+	}
+	s, ok := args[0].(object.String)
+	if !ok {
+		panic(object.NewError("unsupported type for chars"))
+	}
+	var chars []object.Object
+	for _, r := range s.Value {
+		chars = append(chars, object.NewString(string(r)))
+	}
+	return object.NewArray(chars)
+}
+
+func _freeze(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		panic(object.NewError("bad args len for freeze"))
+	}
+	return object.Freeze(args[0])
+}
+
+// _str returns the display form of its argument, which for a string is the
+// string itself.
+func _str(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		panic(object.NewError("bad args len for str"))
+	}
+	return object.NewString(args[0].String())
+}
+
+// display joins the display forms of values with spaces.
+func display(values []object.Object) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = value.String()
+	}
+	return strings.Join(strs, " ")
+}
+
+func _print(stdio *object.Stdio, args ...object.Object) object.Object {
+	fmt.Fprint(stdio.Out, display(args))
+	return object.NULL
+}
+
+func _println(stdio *object.Stdio, args ...object.Object) object.Object {
+	fmt.Fprintln(stdio.Out, display(args))
+	return object.NULL
+}
+
+// _eprint writes a line to standard error, like println.
+func _eprint(stdio *object.Stdio, args ...object.Object) object.Object {
+	fmt.Fprintln(stdio.Err, display(args))
+	return object.NULL
+}
+
+// printfVerbs returns the verbs printf accepts for obj, which are those Go
+// supports for the value it is passed as. Values of other types are
+// formatted in their display form, as by %s.
+func printfVerbs(obj object.Object) string {
+	switch obj.(type) {
+	case object.Integer:
+		return "vdbcoOqxXU"
+	case object.BigInt:
+		return "vdboOxX"
+	case object.String:
+		return "vsqxX"
+	case object.Boolean:
+		return "vt"
+	}
+	return "vs"
+}
+
+// checkFormat fails unless format has a verb printf accepts for each of
+// args, in order, and no more. Verbs may have flags, a width and a
+// precision, but not argument indexes or * for a width taken from args.
+func checkFormat(format string, args []object.Object) {
+	var verbs []byte
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i == len(format) {
+			panic(object.NewError("printf format ends in the middle of a verb"))
+		}
+		if format[i] != '%' {
+			verbs = append(verbs, format[i])
+		}
+	}
+	if len(verbs) != len(args) {
+		panic(object.NewError(fmt.Sprintf("printf format has %d verbs for %d values", len(verbs), len(args))))
+	}
+	for i, verb := range verbs {
+		if strings.IndexByte(printfVerbs(args[i]), verb) < 0 {
+			panic(object.NewError(fmt.Sprintf("printf verb %%%c cannot format %s", verb, args[i].Type())))
+		}
+	}
+}
+
+// _printf formats its arguments like Go's fmt.Printf. Ints, strings and
+// bools are formatted as those Go types, and other values in their display
+// form.
+func _printf(stdio *object.Stdio, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		panic(object.NewError("bad args len for printf"))
+	}
+	format, ok := args[0].(object.String)
+	if !ok {
+		panic(object.NewError("unsupported type for printf format"))
+	}
+	checkFormat(format.Value, args[1:])
+	values := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		switch arg := arg.(type) {
+		case object.Integer:
+			values[i] = arg.Value
+		case object.BigInt:
+			values[i] = arg.Value
+		case object.String:
+			values[i] = arg.Value
+		case object.Boolean:
+			values[i] = arg.Value
+		default:
+			values[i] = arg
+		}
+	}
+	fmt.Fprintf(stdio.Out, format.Value, values...)
+	return object.NULL
+}
+
+// _input writes the optional prompt and reads a line, returning it without
+// its line ending, or null at the end of the input.
+func _input(stdio *object.Stdio, args ...object.Object) object.Object {
+	if len(args) > 1 {
+		panic(object.NewError("bad args len for input"))
+	}
+	if len(args) == 1 {
+		fmt.Fprint(stdio.Out, args[0].String())
+	}
+	line, err := stdio.In.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return object.NULL
+	} else if err != nil && err != io.EOF {
+		panic(object.NewError("cannot read input: " + err.Error()))
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return object.NewString(strings.TrimSuffix(line, "\r"))
+}
+
+// assertionMessage returns the optional message argument of an assertion at
+// index i, prefixed for appending to the failure.
+func assertionMessage(name string, args []object.Object, i int) string {
+	if len(args) <= i {
+		return ""
+	}
+	message, ok := args[i].(object.String)
+	if !ok {
+		panic(object.NewError("unsupported type for " + name + " message"))
+	}
+	return ": " + message.Value
+}
+
+func _assert(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		panic(object.NewError("bad args len for assert"))
+	}
+	message := assertionMessage("assert", args, 1)
+	if !isTruthy(args[0]) {
+		panic(object.NewError("assertion failed" + message))
+	}
+	return object.NULL
+}
+
+func _assertEqual(args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		panic(object.NewError("bad args len for assertEqual"))
+	}
+	message := assertionMessage("assertEqual", args, 2)
+	actual, expected := args[0], args[1]
+	if object.Equal(actual, expected) {
+		return object.NULL
+	}
+	var failure string
+	if actual.Type() != expected.Type() {
+		failure = fmt.Sprintf("expected %s %s, got %s %s", expected.Type(), expected.Inspect(), actual.Type(), actual.Inspect())
+	} else if strings.Contains(expected.Inspect(), "\n") || strings.Contains(actual.Inspect(), "\n") {
+		failure = fmt.Sprintf("%s values differ", expected.Type())
+	} else {
+		failure = fmt.Sprintf("expected %s, got %s", expected.Inspect(), actual.Inspect())
+	}
+	err := object.NewError("assertEqual failed" + message + ": " + failure)
+	err.Expected, err.Actual = expected.Inspect(), actual.Inspect()
+	panic(err)
+}
+
+// _assertThrows calls its first argument with no arguments, failing unless
+// the call fails, and with a message containing the optional second
+// argument. It returns the message of the error.
+func _assertThrows(call func(fn object.Object, args ...object.Object) object.Object, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		panic(object.NewError("bad args len for assertThrows"))
+	}
+	var want string
+	if len(args) == 2 {
+		s, ok := args[1].(object.String)
+		if !ok {
+			panic(object.NewError("unsupported type for assertThrows message"))
+		}
+		want = s.Value
+	}
+	err, failed := throws(call, args[0])
+	if !failed {
+		panic(object.NewError("assertThrows failed: no error"))
+	}
+	if !strings.Contains(err.Message, want) {
+		panic(object.NewError(fmt.Sprintf("assertThrows failed: error %q does not contain %q", err.Message, want)))
+	}
+	return object.NewString(err.Message)
+}
+
+func throws(call func(fn object.Object, args ...object.Object) object.Object, fn object.Object) (err object.Error, failed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, failed = r.(object.Error); !failed {
+				panic(r)
+			}
+		}
+	}()
+	call(fn)
+	return
+}
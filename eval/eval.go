@@ -1,19 +1,112 @@
 package eval
 
 import (
+	"fmt"
 	"github.com/carsonip/monkey-interpreter/ast"
 	"github.com/carsonip/monkey-interpreter/object"
 	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/printer"
 	"github.com/carsonip/monkey-interpreter/token"
+	"io"
+	"reflect"
+	"strings"
 )
 
 type Evaluator struct {
 	parser *parser.Parser
 	env *object.Env
+	loader *ModuleLoader
+	path string
+	exports map[string]bool
+	hooks Hooks
+	branchHooks BranchHooks
+	trace io.Writer
+	traceDepth int
+	stdio *object.Stdio
+}
+
+// Hooks let a debugger follow an Evaluator as it runs. They are called
+// synchronously, so a hook may block to pause the program, and a panic that
+// is not an object.Error aborts it.
+type Hooks interface {
+	// Before is called before each statement and expression is evaluated.
+	Before(node ast.Node, env *object.Env)
+	// Call is called when call enters fn, once the arguments are bound in
+	// env, the scope its body runs in.
+	Call(call *ast.FunctionCall, fn object.Function, env *object.Env)
+	// Return is called when the function entered by call returns, with its
+	// result, or nil if it failed.
+	Return(call *ast.FunctionCall, result object.Object)
+}
+
+// BranchHooks are Hooks that are also told which way each branch goes, e.g.
+// to measure branch coverage.
+type BranchHooks interface {
+	Hooks
+	// Branch is called when node takes one of its branches: for an if
+	// statement 0 for then and 1 for else, for a while statement 0 each time
+	// the body runs and 1 when the condition ends the loop, and for a match
+	// expression the index of the arm that matched.
+	Branch(node ast.Node, branch int)
+}
+
+// MultiHooks calls each of its hooks in turn, so that several can follow
+// one evaluator. Return is called in reverse order, so that each hook's
+// Call and Return nest within those of the hooks before it.
+type MultiHooks []Hooks
+
+func (m MultiHooks) Before(node ast.Node, env *object.Env) {
+	for _, hooks := range m {
+		hooks.Before(node, env)
+	}
+}
+
+func (m MultiHooks) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {
+	for _, hooks := range m {
+		hooks.Call(call, fn, env)
+	}
+}
+
+func (m MultiHooks) Return(call *ast.FunctionCall, result object.Object) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].Return(call, result)
+	}
+}
+
+// Branch calls the hooks that implement BranchHooks.
+func (m MultiHooks) Branch(node ast.Node, branch int) {
+	for _, hooks := range m {
+		if branchHooks, ok := hooks.(BranchHooks); ok {
+			branchHooks.Branch(node, branch)
+		}
+	}
 }
 
 func NewEvaluator(parser *parser.Parser, env *object.Env) Evaluator {
-	return Evaluator{parser: parser, env: env}
+	return Evaluator{parser: parser, env: env, exports: make(map[string]bool)}
+}
+
+// SetStdio enables the input and output builtins, which read from in and
+// write to out and err. Modules imported afterwards use the same streams.
+// Evaluators that read from the same stream should be given the same
+// *bufio.Reader, so that input read ahead by one is not lost to the others.
+func (ev *Evaluator) SetStdio(in io.Reader, out io.Writer, err io.Writer) {
+	ev.stdio = object.NewStdio(in, out, err)
+}
+
+// SetModuleLoader enables import statements, resolving them with loader
+// relative to the module at path. path is empty for code that does not come
+// from a file, such as REPL input.
+func (ev *Evaluator) SetModuleLoader(loader *ModuleLoader, path string) {
+	ev.loader = loader
+	ev.path = path
+}
+
+// SetHooks installs hooks that are called as the evaluator runs. Branch is
+// called too if hooks implement BranchHooks.
+func (ev *Evaluator) SetHooks(hooks Hooks) {
+	ev.hooks = hooks
+	ev.branchHooks, _ = hooks.(BranchHooks)
 }
 
 func (ev *Evaluator) EvalNext(env *object.Env) object.Object {
@@ -35,6 +128,9 @@ func (ev *Evaluator) Eval(node ast.Node, env *object.Env) (ret object.Object) {
 		}
 	}()
 	if statement, ok := node.(ast.Statement); ok {
+		if ev.hooks != nil {
+			ev.hooks.Before(statement, env)
+		}
 		ev.evalStatement(statement, env)
 		return object.NULL
 	} else if expr, ok := node.(ast.Expression); ok {
@@ -51,15 +147,47 @@ func (ev *Evaluator) evalStatement(statement ast.Statement, env *object.Env) {
 		ev.evalReturnStatement(statement, env)
 	case *ast.IfStatement:
 		ev.evalIfStatement(statement, env)
+	case *ast.WhileStatement:
+		ev.evalWhileStatement(statement, env)
+	case *ast.ImportStatement:
+		ev.evalImportStatement(statement, env)
+	case *ast.ExportStatement:
+		ev.evalExportStatement(statement, env)
 	default:
 		panic(object.NewError("not implemented"))
 	}
 }
 
 func (ev *Evaluator) evalLetStatement(statement *ast.LetStatement, env *object.Env) {
-	name := statement.Name.TokenLiteral()
 	val := ev.evalExpression(statement.Value, env)
-	env.SetNew(name, val)
+	if fn, ok := val.(object.Function); ok {
+		if _, isLiteral := statement.Value.(*ast.Function); isLiteral {
+			fn.Name = statement.Name.TokenLiteral()
+			val = fn
+		}
+	}
+	checkRedeclaration(ast.PatternNames(statement.Name), env)
+	if !statement.IsConst() {
+		ev.destructure(statement.Name, val, env)
+		return
+	}
+	// Bind the names in a scope of their own first, to then declare each of
+	// them in env as a constant.
+	scope := object.NewNestedEnv(env)
+	ev.destructure(statement.Name, val, scope)
+	for _, name := range scope.Names() {
+		env.SetConst(name, scope.MustGet(name))
+	}
+}
+
+// checkRedeclaration fails if any of names is a constant of env itself,
+// which cannot be declared again in the same scope.
+func checkRedeclaration(names []string, env *object.Env) {
+	for _, name := range names {
+		if env.IsConst(name) {
+			panic(object.NewError("cannot redeclare constant"))
+		}
+	}
 }
 
 func (ev *Evaluator) evalReturnStatement(statement *ast.ReturnStatement, env *object.Env) {
@@ -72,19 +200,44 @@ func (ev *Evaluator) evalIfStatement(statement *ast.IfStatement, env *object.Env
 	ok := isTruthy(ev.evalExpression(statement.Condition, env))
 	if ok {
 		nodes = statement.Then
+		ev.branch(statement, 0)
 	} else {
 		nodes = statement.Else
+		ev.branch(statement, 1)
 	}
+	ev.evalBlock(nodes, env)
+}
+
+func (ev *Evaluator) evalWhileStatement(statement *ast.WhileStatement, env *object.Env) {
+	for isTruthy(ev.evalExpression(statement.Condition, env)) {
+		ev.branch(statement, 0)
+		if ev.evalBlock(statement.Body, env) {
+			return
+		}
+	}
+	ev.branch(statement, 1)
+}
+
+func (ev *Evaluator) branch(node ast.Node, branch int) {
+	if ev.branchHooks != nil {
+		ev.branchHooks.Branch(node, branch)
+	}
+}
+
+// evalBlock runs nodes in a new scope nested in env, propagating a return to
+// env and reporting whether one happened.
+func (ev *Evaluator) evalBlock(nodes []ast.Node, env *object.Env) bool {
 	newEnv := object.NewNestedEnv(env)
 	for _, node := range nodes {
 		result := ev.Eval(node, newEnv)
 		if returnValue, ok := newEnv.Returned(); ok {
 			env.Return(returnValue)
-			return
+			return true
 		} else if err, ok := result.(object.Error); ok {
 			panic(err)
 		}
 	}
+	return false
 }
 
 func isTruthy(obj object.Object) bool {
@@ -99,8 +252,14 @@ func isTruthy(obj object.Object) bool {
 }
 
 func (ev *Evaluator) evalExpression(expr ast.Expression, env *object.Env) object.Object {
+	if ev.hooks != nil {
+		ev.hooks.Before(expr, env)
+	}
 	switch expr := expr.(type) {
 	case *ast.NumberLiteral:
+		if expr.Big != nil {
+			return object.NewBigInt(expr.Big)
+		}
 		return object.NewInteger(expr.Value)
 	case *ast.Boolean:
 		return object.NewBoolean(expr.Value)
@@ -110,6 +269,8 @@ func (ev *Evaluator) evalExpression(expr ast.Expression, env *object.Env) object
 		return ev.evalInfixExpression(expr, env)
 	case *ast.PrefixExpression:
 		return ev.evalPrefixExpression(expr, env)
+	case *ast.PostfixExpression:
+		return ev.evalPostfixExpression(expr, env)
 	case *ast.Identifier:
 		return ev.evalIdentifier(expr, env)
 	case *ast.Function:
@@ -118,10 +279,18 @@ func (ev *Evaluator) evalExpression(expr ast.Expression, env *object.Env) object
 		return ev.evalFunctionCall(expr, env)
 	case *ast.Array:
 		return ev.evalArray(expr, env)
+	case *ast.Template:
+		return ev.evalTemplate(expr, env)
 	case *ast.Index:
 		return ev.evalIndex(expr, env)
+	case *ast.Slice:
+		return ev.evalSlice(expr, env)
+	case *ast.Dot:
+		return ev.evalDot(expr, env)
 	case *ast.Map:
 		return ev.evalMap(expr, env)
+	case *ast.MatchExpression:
+		return ev.evalMatch(expr, env)
 	}
 	panic(object.NewError("not implemented"))
 }
@@ -137,14 +306,6 @@ func (ev *Evaluator) evalIdentifier(expr ast.Expression, env *object.Env) object
 	}
 }
 
-func (ev *Evaluator) evalNumber(expr ast.Expression, env *object.Env) int {
-	obj := ev.evalExpression(expr, env)
-	if num, ok := obj.(object.Integer); ok {
-		return num.Value
-	}
-	panic(object.NewError("not int"))
-}
-
 func (ev *Evaluator) evalBoolean(expr ast.Expression, env *object.Env) bool {
 	obj := ev.evalExpression(expr, env)
 	if boolean, ok := obj.(object.Boolean); ok {
@@ -155,12 +316,14 @@ func (ev *Evaluator) evalBoolean(expr ast.Expression, env *object.Env) bool {
 
 func (ev *Evaluator) evalInfixExpression(infix *ast.InfixExpression, env *object.Env) object.Object {
 	switch infix.Token.Type {
-	case token.TOKEN_PLUS, token.TOKEN_MINUS, token.TOKEN_ASTERISK, token.TOKEN_SLASH:
+	case token.TOKEN_PLUS, token.TOKEN_MINUS, token.TOKEN_ASTERISK, token.TOKEN_SLASH, token.TOKEN_PERCENT:
 		return ev.evalArithmetic(infix.Left, infix.Right, infix.Token.Type, env)
-	case token.TOKEN_EQUAL, token.TOKEN_NOTEQUAL, token.TOKEN_LT, token.TOKEN_GT:
+	case token.TOKEN_EQUAL, token.TOKEN_NOTEQUAL, token.TOKEN_LT, token.TOKEN_GT, token.TOKEN_LTE, token.TOKEN_GTE:
 		return ev.evalComparison(infix.Left, infix.Right, infix.Token.Type, env)
 	case token.TOKEN_ASSIGNMENT:
 		return ev.evalAssignment(infix.Left, infix.Right, env)
+	case token.TOKEN_PLUS_ASSIGNMENT, token.TOKEN_MINUS_ASSIGNMENT, token.TOKEN_ASTERISK_ASSIGNMENT, token.TOKEN_SLASH_ASSIGNMENT, token.TOKEN_PERCENT_ASSIGNMENT:
+		return ev.evalCompoundAssignment(infix.Left, infix.Right, compoundAssignmentOperators[infix.Token.Type], env)
 	}
 	panic(object.NewError("unknown infix operator type"))
 }
@@ -168,22 +331,14 @@ func (ev *Evaluator) evalInfixExpression(infix *ast.InfixExpression, env *object
 func (ev *Evaluator) evalArithmetic(leftExpr ast.Expression, rightExpr ast.Expression, tokenType token.TokenType, env *object.Env) object.Object {
 	left := ev.evalExpression(leftExpr, env)
 	right := ev.evalExpression(rightExpr, env)
+	return arithmetic(left, right, tokenType)
+}
+
+func arithmetic(left object.Object, right object.Object, tokenType token.TokenType) object.Object {
+	if isInteger(left) && isInteger(right) {
+		return integerArithmetic(left, right, tokenType)
+	}
 	switch left := left.(type) {
-	case object.Integer:
-		if right, ok := right.(object.Integer); ok {
-			switch tokenType {
-			case token.TOKEN_PLUS:
-				return object.NewInteger(left.Value + right.Value)
-			case token.TOKEN_MINUS:
-				return object.NewInteger(left.Value - right.Value)
-			case token.TOKEN_ASTERISK:
-				return object.NewInteger(left.Value * right.Value)
-			case token.TOKEN_SLASH:
-				return object.NewInteger(left.Value / right.Value)
-			default:
-				panic(object.NewError("unsupported arithmetic operator"))
-			}
-		}
 	case object.String:
 		if right, ok := right.(object.String); ok {
 			switch tokenType {
@@ -200,148 +355,340 @@ func (ev *Evaluator) evalArithmetic(leftExpr ast.Expression, rightExpr ast.Expre
 func (ev *Evaluator) evalComparison(leftExpr ast.Expression, rightExpr ast.Expression, tokenType token.TokenType, env *object.Env) object.Object {
 	left := ev.evalExpression(leftExpr, env)
 	right := ev.evalExpression(rightExpr, env)
+	switch tokenType {
+	case token.TOKEN_EQUAL:
+		return object.NewBoolean(object.Equal(left, right))
+	case token.TOKEN_NOTEQUAL:
+		return object.NewBoolean(!object.Equal(left, right))
+	}
+	c := compare(left, right, make(map[[2]uintptr]bool))
+	switch tokenType {
+	case token.TOKEN_LT:
+		return object.NewBoolean(c < 0)
+	case token.TOKEN_GT:
+		return object.NewBoolean(c > 0)
+	case token.TOKEN_LTE:
+		return object.NewBoolean(c <= 0)
+	case token.TOKEN_GTE:
+		return object.NewBoolean(c >= 0)
+	}
+	panic(object.NewError("unsupported comparison operator on type"))
+}
+
+// compare orders integers by value, and strings and arrays lexicographically,
+// returning a negative number, zero or a positive number as left is less
+// than, equal to or greater than right. Pairs of arrays in seen are being
+// compared further up, and count as equal.
+func compare(left object.Object, right object.Object, seen map[[2]uintptr]bool) int {
+	if isInteger(left) && isInteger(right) {
+		return compareIntegers(left, right)
+	}
 	switch left := left.(type) {
-	case object.Integer:
-		if right, ok := right.(object.Integer); ok {
-			switch tokenType {
-			case token.TOKEN_EQUAL:
-				return object.NewBoolean(left.Value == right.Value)
-			case token.TOKEN_NOTEQUAL:
-				return object.NewBoolean(left.Value != right.Value)
-			case token.TOKEN_LT:
-				return object.NewBoolean(left.Value < right.Value)
-			case token.TOKEN_GT:
-				return object.NewBoolean(left.Value > right.Value)
-			default:
-				panic(object.NewError("unsupported comparison operator on type"))
-			}
-		}
-	case object.Boolean:
-		if right, ok := right.(object.Boolean); ok {
-			switch tokenType {
-			case token.TOKEN_EQUAL:
-				return object.NewBoolean(left.Value == right.Value)
-			case token.TOKEN_NOTEQUAL:
-				return object.NewBoolean(left.Value != right.Value)
-			default:
-				panic(object.NewError("unsupported comparison operator on type"))
-			}
-		}
 	case object.String:
 		if right, ok := right.(object.String); ok {
-			switch tokenType {
-			case token.TOKEN_EQUAL:
-				return object.NewBoolean(left.Value == right.Value)
-			case token.TOKEN_NOTEQUAL:
-				return object.NewBoolean(left.Value != right.Value)
-			default:
-				panic(object.NewError("unsupported comparison operator on type"))
+			return strings.Compare(left.Value, right.Value)
+		}
+	case object.Array:
+		if right, ok := right.(object.Array); ok {
+			pair := [2]uintptr{reflect.ValueOf(left.Elements).Pointer(), reflect.ValueOf(right.Elements).Pointer()}
+			if seen[pair] {
+				return 0
+			}
+			seen[pair] = true
+			for i := 0; i < len(left.Elements) && i < len(right.Elements); i++ {
+				if object.Equal(left.Elements[i], right.Elements[i]) {
+					continue
+				}
+				if c := compare(left.Elements[i], right.Elements[i], seen); c != 0 {
+					return c
+				}
 			}
+			return len(left.Elements) - len(right.Elements)
 		}
 	}
+	if left.Type() == right.Type() {
+		panic(object.NewError("unsupported comparison operator on type"))
+	}
 	panic(object.NewError("unsupported types for comparison"))
 }
 
+var compoundAssignmentOperators = map[token.TokenType]token.TokenType{
+	token.TOKEN_PLUS_ASSIGNMENT: token.TOKEN_PLUS,
+	token.TOKEN_MINUS_ASSIGNMENT: token.TOKEN_MINUS,
+	token.TOKEN_ASTERISK_ASSIGNMENT: token.TOKEN_ASTERISK,
+	token.TOKEN_SLASH_ASSIGNMENT: token.TOKEN_SLASH,
+	token.TOKEN_PERCENT_ASSIGNMENT: token.TOKEN_PERCENT,
+}
+
 func (ev *Evaluator) evalAssignment(left ast.Expression, right ast.Expression, env *object.Env) object.Object {
+	_, set := ev.evalAssignmentTarget(left, env)
 	val := ev.evalExpression(right, env)
-	switch left := left.(type) {
+	set(val)
+	return val
+}
+
+func (ev *Evaluator) evalCompoundAssignment(left ast.Expression, right ast.Expression, tokenType token.TokenType, env *object.Env) object.Object {
+	get, set := ev.evalAssignmentTarget(left, env)
+	val := arithmetic(get(), ev.evalExpression(right, env), tokenType)
+	set(val)
+	return val
+}
+
+// evalAssignmentTarget evaluates the sub-expressions of an assignable
+// expression exactly once and returns accessors for the location it denotes.
+func (ev *Evaluator) evalAssignmentTarget(target ast.Expression, env *object.Env) (get func() object.Object, set func(object.Object)) {
+	switch target := target.(type) {
 	case *ast.Identifier:
-		name := left.TokenLiteral()
-		env.Set(name, val)
+		name := target.TokenLiteral()
+		get = func() object.Object {
+			return ev.evalIdentifier(target, env)
+		}
+		set = func(val object.Object) {
+			if err := env.Set(name, val); err != nil {
+				panic(err)
+			}
+		}
 	case *ast.Index:
-		ev.evalAssignmentIndex(left, right, env)
+		left := ev.evalExpression(target.Left, env)
+		index := ev.evalExpression(target.Index, env)
+		switch left := left.(type) {
+		case object.Array:
+			get = func() object.Object {
+				return left.Get(index)
+			}
+			set = func(val object.Object) {
+				left.Set(index, val)
+			}
+		case object.Map:
+			get = func() object.Object {
+				return left.MustGet(index)
+			}
+			set = func(val object.Object) {
+				left.Set(index, val)
+			}
+		default:
+			panic(object.NewError("invalid type for index operation"))
+		}
+	case *ast.Dot:
+		left, ok := ev.evalExpression(target.Left, env).(object.Map)
+		if !ok {
+			panic(object.NewError("invalid type for dot operation"))
+		}
+		key := object.NewString(target.Name.TokenLiteral())
+		get = func() object.Object {
+			return left.MustGet(key)
+		}
+		set = func(val object.Object) {
+			left.Set(key, val)
+		}
 	default:
 		panic(object.NewError("bad lvalue"))
 	}
-	return val
-}
-
-func (ev *Evaluator) evalAssignmentIndex(ind *ast.Index, right ast.Expression, env *object.Env) {
-	left := ev.evalExpression(ind.Left, env)
-	indVal := ev.evalExpression(ind.Index, env)
-	value := ev.evalExpression(right, env)
-	switch left := left.(type) {
-	case object.Array:
-		left.Set(indVal, value)
-	case object.Map:
-		left.Set(indVal, value)
-	}
+	return get, set
 }
 
 func (ev *Evaluator) evalPrefixExpression(prefix *ast.PrefixExpression, env *object.Env) object.Object {
-	switch prefix.Right.(type) {
-	case *ast.NumberLiteral:
-		var result int
-		switch prefix.Token.Type {
-		case token.TOKEN_PLUS:
-			result = ev.evalNumber(prefix.Right, env)
-		case token.TOKEN_MINUS:
-			result = -ev.evalNumber(prefix.Right, env)
-		}
-		return object.NewInteger(result)
-	case *ast.Boolean:
-		switch prefix.Token.Type {
-		case token.TOKEN_NOT:
-			return object.NewBoolean(!ev.evalBoolean(prefix.Right, env))
+	right := ev.evalExpression(prefix.Right, env)
+	switch prefix.Token.Type {
+	case token.TOKEN_PLUS:
+		if isInteger(right) {
+			return right
+		}
+	case token.TOKEN_MINUS:
+		if isInteger(right) {
+			return arithmetic(object.NewInteger(0), right, token.TOKEN_MINUS)
+		}
+	case token.TOKEN_NOT:
+		if boolean, ok := right.(object.Boolean); ok {
+			return object.NewBoolean(!boolean.Value)
 		}
 	}
 	panic(object.NewError("unsupported prefix operator on type"))
 }
 
-func (ev *Evaluator) evalFunction(fn *ast.Function, env *object.Env) object.Function {
-	var params []string
-	for _, p := range fn.Params {
-		params = append(params, p.TokenLiteral())
+func (ev *Evaluator) evalPostfixExpression(postfix *ast.PostfixExpression, env *object.Env) object.Object {
+	get, set := ev.evalAssignmentTarget(postfix.Left, env)
+	old := get()
+	if !isInteger(old) {
+		panic(object.NewError("unsupported postfix operator on type"))
+	}
+	switch postfix.Token.Type {
+	case token.TOKEN_INCREMENT:
+		set(integerArithmetic(old, object.NewInteger(1), token.TOKEN_PLUS))
+	case token.TOKEN_DECREMENT:
+		set(integerArithmetic(old, object.NewInteger(1), token.TOKEN_MINUS))
 	}
-	fnObj := object.NewFunction(params, fn.Body, object.NewNestedEnv(env))
+	return old
+}
+
+func (ev *Evaluator) evalFunction(fn *ast.Function, env *object.Env) object.Function {
+	fnObj := object.NewFunction(fn.Params, fn.Body, env)
+	fnObj.Definition = fn
+	fnObj.Printer = printer.Print
+	fnObj.Path = ev.path
 	return fnObj
 }
 
-func (ev *Evaluator) convertFnArgs(argExprs []ast.Expression, env *object.Env) []object.Object {
+func (ev *Evaluator) convertFnArgs(argExprs []ast.Expression, env *object.Env) ([]object.Object, map[string]object.Object) {
 	var args []object.Object
+	var kwargs map[string]object.Object
 	for _, argExpr := range argExprs {
-		args = append(args, ev.evalExpression(argExpr, env))
+		switch argExpr := argExpr.(type) {
+		case *ast.Spread:
+			arr, ok := ev.evalExpression(argExpr.Value, env).(object.Array)
+			if !ok {
+				panic(object.NewError("spread argument not an array"))
+			}
+			args = append(args, arr.Elements...)
+		case *ast.KeywordArgument:
+			if kwargs == nil {
+				kwargs = make(map[string]object.Object)
+			}
+			name := argExpr.Name.TokenLiteral()
+			if _, ok := kwargs[name]; ok {
+				panic(object.NewError("duplicate keyword argument"))
+			}
+			kwargs[name] = ev.evalExpression(argExpr.Value, env)
+		default:
+			args = append(args, ev.evalExpression(argExpr, env))
+		}
 	}
-	return args
+	return args, kwargs
 }
 
-func (ev *Evaluator) evalFunctionCall(fnCall *ast.FunctionCall, env *object.Env) object.Object {
+func (ev *Evaluator) evalFunctionCall(fnCall *ast.FunctionCall, env *object.Env) (result object.Object) {
 	expr := ev.evalExpression(fnCall.FunctionExpr, env)
 	switch fn := expr.(type) {
 	case object.Function:
-		args := ev.convertFnArgs(fnCall.Arguments, env)
-		return ev.callFunction(fn, args)
+		args, kwargs := ev.convertFnArgs(fnCall.Arguments, env)
+		if ev.trace != nil {
+			ev.traceCall(fnCall, args, kwargs)
+			defer func() {
+				ev.traceReturn(fnCall, result, recover())
+			}()
+		}
+		return ev.callFunction(fnCall, fn, args, kwargs)
 	case object.BuiltinFunction:
-		args := ev.convertFnArgs(fnCall.Arguments, env)
-		return ev.callBuiltinFunction(fn, args, env)
+		args, kwargs := ev.convertFnArgs(fnCall.Arguments, env)
+		if kwargs != nil {
+			panic(object.NewError("builtin does not accept keyword arguments"))
+		}
+		if ev.trace != nil {
+			ev.traceCall(fnCall, args, nil)
+			defer func() {
+				ev.traceReturn(fnCall, result, recover())
+			}()
+		}
+		return ev.callBuiltinFunction(fnCall, fn, args)
 	default:
 		panic(object.NewError("not a function"))
 	}
 }
 
-func (ev *Evaluator) callFunction(fn object.Function, args []object.Object) object.Object {
-	env := fn.Env
-	if len(fn.Params) != len(args) {
+// bindArguments binds positional and keyword arguments to the parameters of
+// fn, filling in defaults and collecting extra arguments for a variadic
+// parameter.
+func (ev *Evaluator) bindArguments(fn object.Function, args []object.Object, kwargs map[string]object.Object, env *object.Env) {
+	used := 0
+	for _, param := range fn.Params {
+		name := ""
+		if ident, ok := param.Pattern.(*ast.Identifier); ok {
+			name = ident.TokenLiteral()
+		}
+		kwarg, hasKwarg := kwargs[name]
+		if hasKwarg {
+			delete(kwargs, name)
+		}
+		if param.Variadic {
+			if hasKwarg {
+				panic(object.NewError("variadic parameter passed as keyword argument"))
+			}
+			rest := make([]object.Object, len(args)-used)
+			copy(rest, args[used:])
+			used = len(args)
+			env.SetNew(name, object.NewArray(rest))
+			continue
+		}
+		var val object.Object
+		switch {
+		case used < len(args):
+			if hasKwarg {
+				panic(object.NewError("multiple values for argument"))
+			}
+			val = args[used]
+			used++
+		case hasKwarg:
+			val = kwarg
+		case param.Default != nil:
+			val = ev.evalExpression(param.Default, env)
+		default:
+			panic(object.NewError("argument length mismatch"))
+		}
+		ev.destructure(param.Pattern, val, env)
+	}
+	if used < len(args) {
 		panic(object.NewError("argument length mismatch"))
 	}
-	for i, name := range fn.Params {
-		env.SetNew(name, args[i])
+	if len(kwargs) > 0 {
+		panic(object.NewError("unknown keyword argument"))
+	}
+}
+
+func (ev *Evaluator) callFunction(call *ast.FunctionCall, fn object.Function, args []object.Object, kwargs map[string]object.Object) (result object.Object) {
+	env := object.NewNestedEnv(fn.Env)
+	ev.bindArguments(fn, args, kwargs, env)
+	if ev.hooks != nil {
+		ev.hooks.Call(call, fn, env)
+		defer func() {
+			ev.hooks.Return(call, result)
+		}()
 	}
 	for _, node := range fn.Body {
-		result := ev.Eval(node, env)
+		obj := ev.Eval(node, env)
 		if val, ok := env.Returned(); ok {
 			return val
-		} else if err, ok := result.(object.Error); ok {
+		} else if err, ok := obj.(object.Error); ok {
 			panic(err)
 		}
 	}
 	return object.NULL
 }
 
-func (ev *Evaluator) callBuiltinFunction(fn object.BuiltinFunction, args []object.Object, parentEnv *object.Env) object.Object {
+func (ev *Evaluator) callBuiltinFunction(call *ast.FunctionCall, fn object.BuiltinFunction, args []object.Object) object.Object {
+	if fn.CallFn != nil {
+		return fn.CallFn(func(callee object.Object, args ...object.Object) object.Object {
+			switch callee := callee.(type) {
+			case object.Function:
+				return ev.callFunction(call, callee, args, nil)
+			case object.BuiltinFunction:
+				return ev.callBuiltinFunction(call, callee, args)
+			}
+			panic(object.NewError("not a function"))
+		}, args...)
+	}
+	if fn.StdioFn != nil {
+		if ev.stdio == nil {
+			panic(object.NewError("input and output not supported"))
+		}
+		return fn.StdioFn(ev.stdio, args...)
+	}
 	return fn.Fn(args...)
 }
 
+// evalTemplate joins the text of a template string with its values, strings
+// as they are and other values as they print.
+func (ev *Evaluator) evalTemplate(t *ast.Template, env *object.Env) object.String {
+	var sb strings.Builder
+	for i, text := range t.Text {
+		sb.WriteString(text)
+		if i == len(t.Values) {
+			break
+		}
+		sb.WriteString(ev.evalExpression(t.Values[i], env).String())
+	}
+	return object.NewString(sb.String())
+}
+
 func (ev *Evaluator) evalArray(arr *ast.Array, env *object.Env) object.Array {
 	var elements []object.Object
 	for _, expr := range arr.Elements {
@@ -358,6 +705,8 @@ func (ev *Evaluator) evalIndex(ind *ast.Index, env *object.Env) object.Object {
 	case object.Array:
 		index := ev.evalExpression(ind.Index, env)
 		obj = left.Get(index)
+	case object.String:
+		obj = left.Index(ev.evalExpression(ind.Index, env))
 	case object.Map:
 		key := ev.evalExpression(ind.Index, env)
 		obj = left.MustGet(key)
@@ -367,6 +716,36 @@ func (ev *Evaluator) evalIndex(ind *ast.Index, env *object.Env) object.Object {
 	return obj
 }
 
+func (ev *Evaluator) evalSlice(slice *ast.Slice, env *object.Env) object.Object {
+	left := ev.evalExpression(slice.Left, env)
+	var bounds [3]object.Object
+	for i, expr := range []ast.Expression{slice.Start, slice.End, slice.Step} {
+		if expr != nil {
+			bounds[i] = ev.evalExpression(expr, env)
+		}
+	}
+	switch left := left.(type) {
+	case object.Array:
+		return left.Slice(bounds[0], bounds[1], bounds[2])
+	case object.String:
+		return left.Slice(bounds[0], bounds[1], bounds[2])
+	}
+	panic(object.NewError("invalid type for slice operation"))
+}
+
+func (ev *Evaluator) evalDot(dot *ast.Dot, env *object.Env) object.Object {
+	switch left := ev.evalExpression(dot.Left, env).(type) {
+	case object.Map:
+		return left.MustGet(object.NewString(dot.Name.TokenLiteral()))
+	case object.Module:
+		if val, ok := left.Member(dot.Name.TokenLiteral()); ok {
+			return val
+		}
+		panic(object.NewError("name not exported by module"))
+	}
+	panic(object.NewError("invalid type for dot operation"))
+}
+
 func (ev *Evaluator) evalMap(m *ast.Map, env *object.Env) object.Map {
 	var pairs [][2]object.Object
 	for _, kvExprs := range m.Pairs {
@@ -378,3 +757,82 @@ func (ev *Evaluator) evalMap(m *ast.Map, env *object.Env) object.Map {
 	}
 	return object.NewMap(pairs)
 }
+
+func (ev *Evaluator) evalMatch(m *ast.MatchExpression, env *object.Env) object.Object {
+	val := ev.evalExpression(m.Value, env)
+	for i, arm := range m.Arms {
+		armEnv := object.NewNestedEnv(env)
+		if ev.bindPattern(arm.Pattern, val, armEnv) != "" {
+			continue
+		}
+		if arm.Guard != nil && !isTruthy(ev.evalExpression(arm.Guard, armEnv)) {
+			continue
+		}
+		ev.branch(m, i)
+		return ev.evalExpression(arm.Body, armEnv)
+	}
+	panic(object.NewError("no matching pattern"))
+}
+
+// destructure binds the names in pattern to the matching parts of val,
+// failing with an error if val does not have the shape of the pattern.
+func (ev *Evaluator) destructure(pattern ast.Pattern, val object.Object, env *object.Env) {
+	if mismatch := ev.bindPattern(pattern, val, env); mismatch != "" {
+		panic(object.NewError(mismatch))
+	}
+}
+
+// bindPattern binds the names in pattern to the matching parts of val and
+// returns an empty string, or describes why val does not fit the pattern.
+func (ev *Evaluator) bindPattern(pattern ast.Pattern, val object.Object, env *object.Env) string {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if name := pattern.TokenLiteral(); name != "_" {
+			env.SetNew(name, val)
+		}
+		return ""
+	case *ast.NumberLiteral, *ast.String, *ast.Boolean:
+		if !object.Equal(ev.evalExpression(pattern.(ast.Expression), env), val) {
+			return "pattern literal mismatch"
+		}
+		return ""
+	case *ast.ArrayPattern:
+		arr, ok := val.(object.Array)
+		if !ok {
+			return fmt.Sprintf("cannot destructure %s: expected array", val.Type())
+		}
+		if pattern.Rest != nil && len(arr.Elements) < len(pattern.Elements) {
+			return fmt.Sprintf("cannot destructure array of length %d: expected length at least %d", len(arr.Elements), len(pattern.Elements))
+		} else if pattern.Rest == nil && len(arr.Elements) != len(pattern.Elements) {
+			return fmt.Sprintf("cannot destructure array of length %d: expected length %d", len(arr.Elements), len(pattern.Elements))
+		}
+		for i, elementPattern := range pattern.Elements {
+			if mismatch := ev.bindPattern(elementPattern, arr.Elements[i], env); mismatch != "" {
+				return mismatch
+			}
+		}
+		if pattern.Rest != nil {
+			rest := make([]object.Object, len(arr.Elements)-len(pattern.Elements))
+			copy(rest, arr.Elements[len(pattern.Elements):])
+			return ev.bindPattern(pattern.Rest, object.NewArray(rest), env)
+		}
+		return ""
+	case *ast.MapPattern:
+		m, ok := val.(object.Map)
+		if !ok {
+			return fmt.Sprintf("cannot destructure %s: expected map", val.Type())
+		}
+		for _, pair := range pattern.Pairs {
+			key := ev.evalExpression(pair.Key, env)
+			v, ok := m.Get(key)
+			if !ok {
+				return fmt.Sprintf("cannot destructure map: key %s not found", key.Inspect())
+			}
+			if mismatch := ev.bindPattern(pair.Value, v, env); mismatch != "" {
+				return mismatch
+			}
+		}
+		return ""
+	}
+	panic(object.NewError("not implemented"))
+}
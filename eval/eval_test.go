@@ -1,10 +1,13 @@
 package eval
 
 import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
 	"github.com/carsonip/monkey-interpreter/object"
 	"github.com/carsonip/monkey-interpreter/parser"
 	"github.com/carsonip/monkey-interpreter/token"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -21,7 +24,7 @@ func runTests(t *testing.T, tests [][]string) {
 		outputs := inputOutput[1:]
 		eval := getEvaluator(input)
 		for _, output := range outputs {
-			assert.Equal(t, output, eval.EvalNext(eval.env).String())
+			assert.Equal(t, output, eval.EvalNext(eval.env).Inspect())
 		}
 		assert.Nil(t, eval.EvalNext(eval.env))
 	}
@@ -33,6 +36,7 @@ func TestEvaluator_evalPrefixExpression(t *testing.T) {
 		{"-1", "-1"},
 		{"!true", "false"},
 		{"!false", "true"},
+		{"let x = 2; let b = false; [-x, +x, - -x, !b, -(x + 1)]", "null", "null", "[-2, 2, 2, true, -3]"},
 	}
 	runTests(t, tests)
 }
@@ -41,21 +45,22 @@ func TestEvaluator_evalPrefixExpression_Error(t *testing.T) {
 	tests := [][]string{
 		{`+true`, "error: unsupported prefix operator on type"},
 		{`+"foo"`, "error: unsupported prefix operator on type"},
+		{`!1`, "error: unsupported prefix operator on type"},
 	}
 	runTests(t, tests)
 }
 
 func TestEvaluator_evalLetStatement(t *testing.T) {
 	tests := [][]string{
-		{`let x = 100; x`, "", "100"},
+		{`let x = 100; x`, "null", "100"},
 	}
 	runTests(t, tests)
 }
 
 func TestEvaluator_evalIdentifier(t *testing.T) {
 	tests := [][]string{
-		{`let x = 100; x; x+x; 3*x`, "", "100", "200", "300"},
-		{`let len = 100; len`, "", "100"},
+		{`let x = 100; x; x+x; 3*x`, "null", "100", "200", "300"},
+		{`let len = 100; len`, "null", "100"},
 	}
 	runTests(t, tests)
 }
@@ -69,18 +74,18 @@ func TestEvaluator_evalIdentifier_Error(t *testing.T) {
 
 func TestEvaluator_evalFunction(t *testing.T) {
 	tests := [][]string{
-		{`fn(x, y){100; x+200;}`, "fn"},
+		{`fn(x, y){100; x+200;}`, "fn(x, y) {\n\t100;\n\tx + 200;\n}"},
 	}
 	runTests(t, tests)
 }
 
 func TestEvaluator_evalFunctionCall(t *testing.T) {
 	tests := [][]string{
-		{"fn(){1;}()", ""},
+		{"fn(){1;}()", "null"},
 		{"fn(){1; return 2;}()", "2"},
 		{"fn(x){1; return 2; return true;}(100)", "2"},
 		{"fn(x, y){100; x+200; return x+y; 300;}(1, 2)", "3"},
-		{"fn(){fn(){return 1;}()}()", ""},
+		{"fn(){fn(){return 1;}()}()", "null"},
 		{"fn(){return fn(){return 1;}()}()", "1"},
 		{"fn(){return fn(){return 1;}}()()", "1"},
 	}
@@ -108,23 +113,23 @@ func TestEvaluator_evalFunctionCall_Scope(t *testing.T) {
 	tests := [][]string{
 		{"fn(){let x=1; fn(){let x = 2;}(); return x;}()", "1"},
 		{"fn(){let x=1; return fn(x){return x;}(x+1);}()", "2"},
-		{"let x=1; let f=fn(){let x=2; return fn(){return x;}}(); f();", "", "", "2"},
-		{"let x=1; let f=fn(x){return fn(){return x;}}(2); f();", "", "", "2"},
-		{"let x=1; let f=fn(){return x;}; x=2; f();", "", "", "2", "2"},
+		{"let x=1; let f=fn(){let x=2; return fn(){return x;}}(); f();", "null", "null", "2"},
+		{"let x=1; let f=fn(x){return fn(){return x;}}(2); f();", "null", "null", "2"},
+		{"let x=1; let f=fn(){return x;}; x=2; f();", "null", "null", "2", "2"},
 	}
 	runTests(t, tests)
 }
 
 func TestEvaluator_evalIfStatement(t *testing.T) {
 	tests := [][]string{
-		{"let x = 1; if (true) {x=2;}; x", "", "", "2"},
-		{"let x = 1; if (false) {x=2;}; x", "", "", "1"},
-		{"let x = 1; if (false) {x=2;} else {x=3;}; x", "", "", "3"},
-		{"let x = 1; if (0) {x=2;} else {x=3;}; x", "", "", "2"},
-		{"let x = 1; if (1) {x=2;} else {x=3;}; x", "", "", "2"},
-		{"let x = 1; if (fn(){}) {x=2;} else {x=3;}; x", "", "", "2"},
-		{"let x = 1; if (fn(){}()) {x=2;} else {x=3;}; x", "", "", "3"},
-		{"let x = 1; if (true) {let x=2;}; x", "", "", "1"},
+		{"let x = 1; if (true) {x=2;}; x", "null", "null", "2"},
+		{"let x = 1; if (false) {x=2;}; x", "null", "null", "1"},
+		{"let x = 1; if (false) {x=2;} else {x=3;}; x", "null", "null", "3"},
+		{"let x = 1; if (0) {x=2;} else {x=3;}; x", "null", "null", "2"},
+		{"let x = 1; if (1) {x=2;} else {x=3;}; x", "null", "null", "2"},
+		{"let x = 1; if (fn(){}) {x=2;} else {x=3;}; x", "null", "null", "2"},
+		{"let x = 1; if (fn(){}()) {x=2;} else {x=3;}; x", "null", "null", "3"},
+		{"let x = 1; if (true) {let x=2;}; x", "null", "null", "1"},
 		{"fn(){if (true) {return 1; 2;}; return 3;}()", "1"},
 	}
 	runTests(t, tests)
@@ -132,7 +137,7 @@ func TestEvaluator_evalIfStatement(t *testing.T) {
 
 func TestEvaluator_evalIfStatement_Error(t *testing.T) {
 	tests := [][]string{
-		{"let x = 1; if (true) {y; x=2;}; x", "", "error: unknown identifier", "1"},
+		{"let x = 1; if (true) {y; x=2;}; x", "null", "error: unknown identifier", "1"},
 	}
 	runTests(t, tests)
 }
@@ -157,6 +162,36 @@ func TestEvaluator_evalArithmetic_Error(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestEvaluator_evalArithmetic_BigInt(t *testing.T) {
+	tests := [][]string{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"-9223372036854775808", "-9223372036854775808"},
+		{"9223372036854775808 - 1 == 9223372036854775807", "true"},
+		{"4611686018427387904 * 4", "18446744073709551616"},
+		{"123456789012345678901234567890 * 0", "0"},
+		{"-9223372036854775808 / -1", "9223372036854775808"},
+		{"-100000000000000000000 / 7", "-14285714285714285714"},
+		{"-100000000000000000000 % 7", "-2"},
+		{"let x = 9223372036854775807; x++; x", "null", "9223372036854775807", "9223372036854775808"},
+		{"let x = 9223372036854775808; x--; x += 0; x", "null", "9223372036854775808", "9223372036854775807", "9223372036854775807"},
+		{"[100000000000000000000 > 9223372036854775807, -100000000000000000000 < 1, 100000000000000000000 <= 100000000000000000000]", "[true, true, true]"},
+		{"[100000000000000000000 == 100000000000000000000, 100000000000000000000 == 1]", "[true, false]"},
+		{"let m = {100000000000000000000: 1}; m[99999999999999999999 + 1]", "null", "1"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_evalArithmetic_BigInt_Error(t *testing.T) {
+	tests := [][]string{
+		{"100000000000000000000 / 0", "error: division by zero"},
+		{"100000000000000000000 % 0", "error: division by zero"},
+		{"[1][100000000000000000000]", "error: array index out of bounds"},
+		{`100000000000000000000 + "a"`, "error: unsupported types for arithmetic"},
+	}
+	runTests(t, tests)
+}
+
 func TestEvaluator_evalComparison(t *testing.T) {
 	tests := [][]string{
 		{"1 < 2", "true"},
@@ -171,6 +206,36 @@ func TestEvaluator_evalComparison(t *testing.T) {
 		{"true != true", "false"},
 		{`"foo" == "bar"`, "false"},
 		{`"foo" == "foo"`, "true"},
+		{"1 <= 1", "true"},
+		{"2 >= 3", "false"},
+		{`"abc" < "abd"`, "true"},
+		{`"ab" < "abc"`, "true"},
+		{`"b" >= "abc"`, "true"},
+		{"[1, 2] < [1, 3]", "true"},
+		{"[1, 2] < [1, 2, 0]", "true"},
+		{"[2] > [1, 9]", "true"},
+		{`[[1, "a"], true] <= [[1, "a"], true]`, "true"},
+		{"[] >= []", "true"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_evalComparison_Equality(t *testing.T) {
+	tests := [][]string{
+		{"[1, 2] == [1, 2]", "true"},
+		{"[1, 2] == [2, 1]", "false"},
+		{"[1, [2, [3]]] != [1, [2, [3]]]", "false"},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, "true"},
+		{`{"a": 1} == {"a": 1, "b": 2}`, "false"},
+		{`{"a": 1} == {"a": "1"}`, "false"},
+		{`1 == "1"`, "false"},
+		{`[] != {}`, "true"},
+		{`let f = fn() {}; [f == f, f == fn() {}, len == len, len == keys]`, "null", "[true, false, true, false]"},
+		{`[print == print, print == println, printf == input, keys == print, input == input]`, "[true, false, false, false, true]"},
+		{`let cyclic = fn(x) { let a = [x, 0]; a[1] = a; return a; };
+[cyclic(1) == cyclic(1), cyclic(1) == cyclic(2), cyclic(1) < cyclic(2), cyclic(1) <= cyclic(1)]`, "null", "[true, false, true, true]"},
+		{`let cyclic = fn(x) { let m = {"x": x}; m["self"] = m; return m; }; [cyclic(1) == cyclic(1), cyclic(1) == cyclic(2)]`, "null", "[true, false]"},
+		{`match ([1]) { [1] => "one", _ => "other" }`, `"one"`},
 	}
 	runTests(t, tests)
 }
@@ -178,7 +243,9 @@ func TestEvaluator_evalComparison(t *testing.T) {
 func TestEvaluator_evalComparison_Error(t *testing.T) {
 	tests := [][]string{
 		{"1 < true", "error: unsupported types for comparison"},
-		{"[] > []", "error: unsupported types for comparison"},
+		{"[1] > [true]", "error: unsupported types for comparison"},
+		{"[true] > [false]", "error: unsupported comparison operator on type"},
+		{"{} < {}", "error: unsupported comparison operator on type"},
 		{"true > false", "error: unsupported comparison operator on type"},
 	}
 	runTests(t, tests)
@@ -186,7 +253,7 @@ func TestEvaluator_evalComparison_Error(t *testing.T) {
 
 func TestEvaluator_evalAssignment(t *testing.T) {
 	tests := [][]string{
-		{"let x = 1; x = 2;", "", "2"},
+		{"let x = 1; x = 2;", "null", "2"},
 	}
 	runTests(t, tests)
 }
@@ -201,9 +268,9 @@ func TestEvaluator_evalAssignment_Error(t *testing.T) {
 
 func TestEvaluator_evalAssignmentIndex(t *testing.T) {
 	tests := [][]string{
-		{"let x = [1, 2]; x[1] = 3; x", "", "3", "[1, 3]"},
-		{`let x = {"foo": "bar"}; x["foo"] = "baz"; x`, "", `"baz"`, `{"foo": "baz"}`},
-		{`let x = {}; x["foo"] = "baz"; x`, "", `"baz"`, `{"foo": "baz"}`},
+		{"let x = [1, 2]; x[1] = 3; x", "null", "3", "[1, 3]"},
+		{`let x = {"foo": "bar"}; x["foo"] = "baz"; x`, "null", `"baz"`, `{"foo": "baz"}`},
+		{`let x = {}; x["foo"] = "baz"; x`, "null", `"baz"`, `{"foo": "baz"}`},
 	}
 	runTests(t, tests)
 }
@@ -216,6 +283,27 @@ func TestEvaluator_String(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestEvaluator_Template(t *testing.T) {
+	tests := [][]string{
+		{"`hello`", `"hello"`},
+		{"``", `""`},
+		{"let name = \"world\"; `hello ${name}!`", "null", `"hello world!"`},
+		{"`${1 + 2} ${true} ${[1, \"a\"]} ${{\"k\": \"v\"}}`", `"3 true [1, \"a\"] {\"k\": \"v\"}"`},
+		{"let n = 2; `${n} item${match (n) { 1 => \"\", _ => \"s\" }}`", "null", `"2 items"`},
+		{"`a${`b${`c`}`}`", `"abc"`},
+		{"`${100000000000000000000}${\"\"}${fn() {}()}`", `"100000000000000000000null"`},
+		{"`\\` \\${x} $x`", "\"` ${x} $x\""},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Template_Error(t *testing.T) {
+	tests := [][]string{
+		{"`${x}`", "error: unknown identifier"},
+	}
+	runTests(t, tests)
+}
+
 func TestEvaluator_Array(t *testing.T) {
 	tests := [][]string{
 		{`[1+1]`, `[2]`},
@@ -248,6 +336,63 @@ func TestEvaluator_Index(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestEvaluator_Index_Negative(t *testing.T) {
+	tests := [][]string{
+		{`[1, 2, 3][-1]`, "3"},
+		{`[1, 2, 3][-3]`, "1"},
+		{`let a = [1, 2]; a[-1] = 3; a`, "null", "3", "[1, 3]"},
+		{`"abc"[0]`, `"a"`},
+		{`"abc"[-1]`, `"c"`},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Slice(t *testing.T) {
+	tests := [][]string{
+		{`[0, 1, 2, 3, 4][1:3]`, "[1, 2]"},
+		{`"héllo"[::-1]`, `"olléh"`},
+		{`["héllo"[1], "héllo"[-4], "héllo"[1:3], len("héllo")]`, `["é", "é", "él", 5]`},
+		{`[0, 1, 2, 3, 4][:2]`, "[0, 1]"},
+		{`[0, 1, 2, 3, 4][3:]`, "[3, 4]"},
+		{`[0, 1, 2, 3, 4][:]`, "[0, 1, 2, 3, 4]"},
+		{`[0, 1, 2, 3, 4][-2:]`, "[3, 4]"},
+		{`[0, 1, 2, 3, 4][1:-1]`, "[1, 2, 3]"},
+		{`[0, 1, 2, 3, 4][::2]`, "[0, 2, 4]"},
+		{`[0, 1, 2, 3, 4][1::3]`, "[1, 4]"},
+		{`[0, 1, 2, 3, 4][::-1]`, "[4, 3, 2, 1, 0]"},
+		{`[0, 1, 2, 3, 4][3:0:-1]`, "[3, 2, 1]"},
+		{`[0, 1, 2, 3, 4][5::-2]`, "[4, 2, 0]"},
+		{`[0, 1, 2, 3, 4][3:1]`, "[]"},
+		{`[0, 1, 2, 3, 4][5:]`, "[]"},
+		{`[0, 1, 2, 3, 4][::9223372036854775807]`, "[0]"},
+		{`[0, 1, 2, 3, 4][::-100000000000000000000]`, "[4]"},
+		{`[][:]`, "[]"},
+		{`[][::-1]`, "[]"},
+		{`let a = [1, 2]; let b = a[:]; b[0] = 3; a`, "null", "null", "3", "[1, 2]"},
+		{`let m = {}; m[freeze([1, 2, 3])[1:]] = 1; m[freeze([2, 3])]`, "null", "1", "1"},
+		{`"hello"[1:3]`, `"el"`},
+		{`"hello"[::-1]`, `"olleh"`},
+		{`"hello"[-3:]`, `"llo"`},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Slice_Error(t *testing.T) {
+	tests := [][]string{
+		{`[1, 2][3:]`, "error: array index out of bounds"},
+		{`[1, 2][:-3]`, "error: array index out of bounds"},
+		{`"ab"[:3]`, "error: string index out of bounds"},
+		{`"ab"[2]`, "error: string index out of bounds"},
+		{`"ab"["a":]`, "error: string index not an integer"},
+		{`[1][::0]`, "error: slice step cannot be zero"},
+		{`[1][::"a"]`, "error: slice step not an integer"},
+		{`{}[1:]`, "error: invalid type for slice operation"},
+		{`let a = [1]; a[0:1] = [2]`, "null", "error: bad lvalue"},
+		{`freeze([1, 2])[1:][0] = 3`, "error: cannot modify frozen array"},
+	}
+	runTests(t, tests)
+}
+
 func TestEvaluator_Index_Error(t *testing.T) {
 	tests := [][]string{
 		{`1[0]`, "error: invalid type for index operation"},
@@ -267,6 +412,448 @@ func TestEvaluator_Builtin(t *testing.T) {
 	tests := [][]string{
 		{`len([1,2])`, `2`},
 		{`len("foo")`, `3`},
+		{`len(1)`, "error: unsupported type for len"},
+		{`len()`, "error: bad args len for len"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Match(t *testing.T) {
+	tests := [][]string{
+		{`match (1) { 0 => "zero", 1 => "one", _ => "many" }`, `"one"`},
+		{`match (5) { 0 => "zero", 1 => "one", _ => "many" }`, `"many"`},
+		{`match (-1) { -1 => "neg", _ => "other" }`, `"neg"`},
+		{`match (-9223372036854775809) { 9223372036854775809 => "pos", -9223372036854775809 => "neg", _ => "other" }`, `"neg"`},
+		{`match (-9223372036854775807 - 1) { -9223372036854775808 => "min", _ => "other" }`, `"min"`},
+		{`match ("foo") { "bar" => 1, "foo" => 2 }`, "2"},
+		{`match (true) { false => 1, true => 2 }`, "2"},
+		{`match (3) { x => x * 2 }`, "6"},
+		{`match ([1, 2]) { [] => 0, [x] => x, [x, y] => x + y }`, "3"},
+		{`match ([1, 2, 3]) { [x, ...rest] => rest }`, "[2, 3]"},
+		{`match ([1]) { [x, ...rest] => rest }`, "[]"},
+		{`match ([1, [2, 3]]) { [1, [_, z]] => z }`, "3"},
+		{`match ({"name": "foo", "age": 3}) { {"age": a} => a }`, "3"},
+		{`match ({"name": "foo", "age": 3}) { {"name": "bar"} => 1, {name, age} => name }`, `"foo"`},
+		{`match ({"a": 1}) { {"b": x} => x, _ => 0 }`, "0"},
+		{`match (5) { x if x < 3 => "small", x if x > 3 => "big", _ => "three" }`, `"big"`},
+		{`match (3) { x if x < 3 => "small", x if x > 3 => "big", _ => "three" }`, `"three"`},
+		{`let x = 1; match (2) { x => x }; x`, "null", "2", "1"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Match_Error(t *testing.T) {
+	tests := [][]string{
+		{`match (1) { 0 => "zero" }`, "error: no matching pattern"},
+		{`match ([1, 2]) { [x] => x }`, "error: no matching pattern"},
+		{`match ("1") { 1 => 1 }`, "error: no matching pattern"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Destructuring(t *testing.T) {
+	tests := [][]string{
+		{`let [a, b] = [1, 2]; a + b`, "null", "3"},
+		{`let [a, ...rest] = [1, 2, 3]; rest`, "null", "[2, 3]"},
+		{`let [_, [b, c]] = [1, [2, 3]]; b * c`, "null", "6"},
+		{`let {name, age} = {"name": "foo", "age": 3}; name`, "null", `"foo"`},
+		{`let {"age": a} = {"name": "foo", "age": 3}; a`, "null", "3"},
+		{`let {"pos": [x, y]} = {"pos": [1, 2]}; y`, "null", "2"},
+		{`fn([a, b]){ return a - b; }([3, 1])`, "2"},
+		{`fn({name}, [x, ...xs]){ return [name, xs]; }({"name": "n"}, [1, 2])`, `["n", [2]]`},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Destructuring_Error(t *testing.T) {
+	tests := [][]string{
+		{`let [a, b] = [1];`, "error: cannot destructure array of length 1: expected length 2"},
+		{`let [a] = [1, 2];`, "error: cannot destructure array of length 2: expected length 1"},
+		{`let [a, b, ...rest] = [1];`, "error: cannot destructure array of length 1: expected length at least 2"},
+		{`let [a] = 1;`, "error: cannot destructure int: expected array"},
+		{`let {name} = [1];`, "error: cannot destructure array: expected map"},
+		{`let {name} = {"age": 1};`, `error: cannot destructure map: key "name" not found`},
+		{`let [{a}] = [{1: 2}];`, `error: cannot destructure map: key "a" not found`},
+		{`fn([a, b]){}(1)`, "error: cannot destructure int: expected array"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_FunctionParams(t *testing.T) {
+	tests := [][]string{
+		{`fn(x, y = 2){ return x + y; }(1)`, "3"},
+		{`fn(x, y = 2){ return x + y; }(1, 10)`, "11"},
+		{`fn(x, y = x * 3){ return y; }(2)`, "6"},
+		{`fn(first, ...rest){ return rest; }(1, 2, 3)`, "[2, 3]"},
+		{`fn(first, ...rest){ return rest; }(1)`, "[]"},
+		{`fn(x, y){ return x - y; }(...[5, 2])`, "3"},
+		{`fn(...xs){ return xs; }(0, ...[1, 2], 3)`, "[0, 1, 2, 3]"},
+		{`fn(x, y){ return x - y; }(y: 1, x: 3)`, "2"},
+		{`fn(x, y = 1, z = 2){ return [x, y, z]; }(0, z: 5)`, "[0, 1, 5]"},
+		{`len(...[[1, 2]])`, "2"},
+		{`arity(fn(x, y = 2, ...rest){})`, "[1, null]"},
+		{`arity(fn(x, y = 2){})`, "[1, 2]"},
+		{`arity(fn(x){})`, "[1, 1]"},
+		{`arity(fn(x, ...rest){})`, "[1, null]"},
+		{`arity(fn(){})`, "[0, 0]"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_FunctionParams_Error(t *testing.T) {
+	tests := [][]string{
+		{`fn(x, y = 2){}()`, "error: argument length mismatch"},
+		{`fn(x){}(1, x: 2)`, "error: multiple values for argument"},
+		{`fn(x){}(y: 2)`, "error: argument length mismatch"},
+		{`fn(x = 1){}(y: 2)`, "error: unknown keyword argument"},
+		{`fn(x){}(x: 1, x: 2)`, "error: duplicate keyword argument"},
+		{`fn(x){}(...1)`, "error: spread argument not an array"},
+		{`len(x: 1)`, "error: builtin does not accept keyword arguments"},
+		{`arity(len)`, "error: unsupported type for arity"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_evalCompoundAssignment(t *testing.T) {
+	tests := [][]string{
+		{"let x = 1; x += 2; x", "null", "3", "3"},
+		{"let x = 5; x -= 2; x *= 4; x /= 3; x %= 3; x", "null", "3", "12", "4", "1", "1"},
+		{`let s = "a"; s += "b"; s`, "null", `"ab"`, `"ab"`},
+		{"let a = [1, 2]; a[1] += 10; a", "null", "12", "[1, 12]"},
+		{`let m = {"k": 3}; m["k"] *= 2; m`, "null", "6", `{"k": 6}`},
+		{`let m = {"k": 3}; m.k -= 1; m.k`, "null", "2", "2"},
+		{"let n = 0; let a = [0, 0]; let f = fn(){ n += 1; return a; }; f()[0] += 5; [n, a]", "null", "null", "null", "5", "[1, [5, 0]]"},
+		{"let n = 0; let a = [0, 0]; let i = fn(){ n += 1; return 1; }; a[i()] += 5; n", "null", "null", "null", "5", "1"},
+		{"let x = 1; x++; x", "null", "1", "2"},
+		{"let x = 1; x--; x", "null", "1", "0"},
+		{"let a = 5; let b = 3; [5--3, a--b, a - -b, a++b, a]", "null", "null", "[8, 8, 8, 8, 5]"},
+		{"let a = 5; [a -- 2, a ++ 2, a--2, a]", "null", "[7, 7, 7, 5]"},
+		{"let a = [5]; [a[0] -- 2, a[0]--, a]", "null", "[7, 5, [4]]"},
+		{"let a = [1]; a[0]++; a", "null", "1", "[2]"},
+		{"7 % 3", "1"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_evalCompoundAssignment_Error(t *testing.T) {
+	tests := [][]string{
+		{"1 += 1", "error: bad lvalue"},
+		{`let x = "a"; x -= 1`, "null", "error: unsupported types for arithmetic"},
+		{`let m = {}; m["k"] += 1`, "null", "error: key not found"},
+		{`let x = "a"; x++`, "null", "error: unsupported postfix operator on type"},
+		{"1 / 0", "error: division by zero"},
+		{"1 % 0", "error: division by zero"},
+		{"1.foo", "error: invalid type for dot operation"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_evalWhileStatement(t *testing.T) {
+	tests := [][]string{
+		{"let i = 0; let sum = 0; while (i < 5) { sum += i; i++; }; sum", "null", "null", "null", "10"},
+		{"let i = 0; while (false) { i = 1; }; i", "null", "null", "0"},
+		{"fn(){ let i = 0; while (true) { i++; if (i > 2) { return i; } } }()", "3"},
+		{"let i = 0; while (i < 1) { let j = 1; i++; }; j", "null", "null", "error: unknown identifier"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Closures(t *testing.T) {
+	tests := [][]string{
+		// counters keep their own state across calls
+		{"let makeCounter = fn(){ let n = 0; return fn(){ n += 1; return n; }; }; let c = makeCounter(); c(); c(); c()", "null", "null", "1", "2", "3"},
+		{"let makeCounter = fn(){ let n = 0; return fn(){ n += 1; return n; }; }; let a = makeCounter(); let b = makeCounter(); a(); a(); b()", "null", "null", "null", "1", "2", "1"},
+		// factories close over their arguments
+		{"let adder = fn(x){ return fn(y){ return x + y; }; }; let addOne = adder(1); let addTen = adder(10); addOne(1); addTen(1)", "null", "null", "null", "2", "11"},
+		// captured variables are shared by reference
+		{"let x = 1; let get = fn(){ return x; }; let set = fn(v){ x = v; }; set(5); get()", "null", "null", "null", "null", "5"},
+		{"let pair = fn(){ let n = 0; return [fn(){ n++; }, fn(){ return n; }]; }(); pair[0](); pair[0](); pair[1]()", "null", "null", "null", "2"},
+		// closures created in a loop capture that iteration's bindings
+		{"let fs = {}; let i = 0; while (i < 3) { let j = i; fs[i] = fn(){ return j; }; i++; }; [fs[0](), fs[2]()]", "null", "null", "null", "[0, 2]"},
+		{"let fs = {}; let i = 0; while (i < 3) { fs[i] = fn(){ return i; }; i++; }; [fs[0](), fs[2]()]", "null", "null", "null", "[3, 3]"},
+		// recursion gets a fresh scope per call
+		{"let fact = fn(n){ if (n < 2) { return 1; }; return n * fact(n - 1); }; fact(5)", "null", "120"},
+		{"let f = fn(x){ if (x) { return 1; }; return 2; }; f(true); f(false)", "null", "1", "2"},
+		// shadowing
+		{"let x = 1; let f = fn(){ let x = 2; x = 3; return x; }; f(); x", "null", "null", "3", "1"},
+		{"let x = 1; if (true) { let x = 2; x = 3; }; x", "null", "null", "1"},
+		{"let x = 1; if (true) { x = 2; }; x", "null", "null", "2"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Closures_Error(t *testing.T) {
+	tests := [][]string{
+		{"y = 1", "error: unknown identifier"},
+		{"fn(){ z = 1; }()", "error: unknown identifier"},
+		{"fn(){ let z = 1; }(); z", "null", "error: unknown identifier"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Const(t *testing.T) {
+	tests := [][]string{
+		{"const x = 1; x", "null", "1"},
+		{"const [a, ...b] = [1, 2, 3]; [a, b]", "null", "[1, [2, 3]]"},
+		{"const x = 1; fn() { let x = 2; x = 3; return x; }()", "null", "3"},
+		{"const xs = [1]; xs[0] = 2; xs", "null", "2", "[2]"},
+		{"const x = 1; fn() { const x = 2; return x; }()", "null", "2"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Const_Error(t *testing.T) {
+	tests := [][]string{
+		{"const x = 1; x = 2", "null", "error: cannot assign to constant"},
+		{"const x = 1; x += 2", "null", "error: cannot assign to constant"},
+		{"const x = 1; x++", "null", "error: cannot assign to constant"},
+		{"const {a} = {\"a\": 1}; fn() { a = 2; }()", "null", "error: cannot assign to constant"},
+		{"const c = 1; let c = 2; c", "null", "error: cannot redeclare constant", "1"},
+		{"const [p, q] = [1, 2]; let p = 9; p", "null", "error: cannot redeclare constant", "1"},
+		{"const x = 1; const x = 2", "null", "error: cannot redeclare constant"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Builtin_Freeze(t *testing.T) {
+	tests := [][]string{
+		{"let xs = freeze([1, [2], {\"k\": 3}]); xs", "null", `[1, [2], {"k": 3}]`},
+		{"let xs = [1]; let ys = freeze(xs); xs[0] = 2; [xs, ys]", "null", "null", "2", "[[2], [1]]"},
+		{"freeze([1]) == [1]", "true"},
+		{"[freeze(1), freeze(\"a\"), freeze(true)]", `[1, "a", true]`},
+		{"let m = {freeze([1, 2]): \"a\"}; [m[freeze([1, 2])], m[freeze([2, 1])]]", "null", "error: key not found"},
+		{"let m = {freeze([1, 2]): \"a\"}; m[freeze([1, 2])]", "null", `"a"`},
+		{"let m = {}; m[freeze([1, [2]])] = 1; m[freeze([1, [2]])] += 1; m[freeze([1, [2]])]", "null", "1", "2", "2"},
+		{"push(freeze([1]), 2)", "[1, 2]"},
+		{"let cyclic = fn() { let xs = [1, 0]; xs[1] = xs; return freeze(xs); }; let ys = cyclic(); [ys[1][1][0], {ys: 2}[ys]]", "null", "null", "[1, 2]"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Builtin_Str(t *testing.T) {
+	tests := [][]string{
+		{`str("a")`, `"a"`},
+		{`str(1) + str(true)`, `"1true"`},
+		{`str(["a", 1])`, `"[\"a\", 1]"`},
+		{"`tab\there`", `"tab\there"`},
+		{`str(fn() {}())`, `"null"`},
+		{`str(len)`, `"builtin"`},
+		{`let f = fn(x) { x }; [str(f), f]`, "null", "[\"fn\", fn(x) {\n\tx;\n}]"},
+		{`str()`, "error: bad args len for str"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Builtin_Stdio(t *testing.T) {
+	eval := getEvaluator(`print("a", 1); println(["b"], fn() {}());
+printf(` + "`%s=%d %v %q %s|%%\n`" + `, "x", 100000000000000000000, true, "y", {"k": 2});
+let name = input("name? "); let rest = input(); let end = input(); eprint("hi", name);
+[name, rest, end]`)
+	var out, errOut strings.Builder
+	eval.SetStdio(strings.NewReader("ann\r\nbob"), &out, &errOut)
+	var results []string
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+		results = append(results, obj.Inspect())
+	}
+	assert.Equal(t, `["ann", "bob", null]`, results[len(results)-1])
+	assert.Equal(t, "a 1[\"b\"] null\nx=100000000000000000000 true \"y\" {\"k\": 2}|%\nname? ", out.String())
+	assert.Equal(t, "hi ann\n", errOut.String())
+}
+
+func TestEvaluator_Builtin_Stdio_Error(t *testing.T) {
+	tests := [][]string{
+		{`printf()`, "error: bad args len for printf"},
+		{`printf(1)`, "error: unsupported type for printf format"},
+		{`printf("%d %d", 1)`, "error: printf format has 2 verbs for 1 values"},
+		{`printf("%%", 1)`, "error: printf format has 0 verbs for 1 values"},
+		{`printf("%d", "a")`, "error: printf verb %d cannot format string"},
+		{`printf("%t", [])`, "error: printf verb %t cannot format array"},
+		{`printf("%c", 99999999999999999999)`, "error: printf verb %c cannot format int"},
+		{`printf("%U", 99999999999999999999)`, "error: printf verb %U cannot format int"},
+		{`printf("%5", 1)`, "error: printf format ends in the middle of a verb"},
+		{`input("a", "b")`, "error: bad args len for input"},
+	}
+	for _, test := range tests {
+		eval := getEvaluator(test[0])
+		eval.SetStdio(strings.NewReader(""), &strings.Builder{}, &strings.Builder{})
+		assert.Equal(t, test[1], eval.EvalNext(eval.env).Inspect())
+	}
+	runTests(t, [][]string{{`println("a")`, "error: input and output not supported"}})
+}
+
+func TestEvaluator_Builtin_Freeze_Error(t *testing.T) {
+	tests := [][]string{
+		{"freeze([1])[0] = 2", "error: cannot modify frozen array"},
+		{"let xs = freeze([[1]]); xs[0][0] = 2", "null", "error: cannot modify frozen array"},
+		{"let m = freeze({\"a\": [1]}); m.a[0] += 1", "null", "error: cannot modify frozen array"},
+		{"let m = freeze({\"a\": 1}); m[\"b\"] = 2", "null", "error: cannot modify frozen map"},
+		{"let m = freeze({\"a\": 1}); m.a++", "null", "error: cannot modify frozen map"},
+		{"{[1]: 2}", "error: key not hashable"},
+		{"freeze(1, 2)", "error: bad args len for freeze"},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Builtin_Collections(t *testing.T) {
+	tests := [][]string{
+		{`let a = [1]; let b = push(a, 2); [a, b]`, "null", "null", "[[1], [1, 2]]"},
+		{`keys({"foo": 1})`, `["foo"]`},
+		{`chars("ab")`, `["a", "b"]`},
+		{`chars("")`, `[]`},
+	}
+	runTests(t, tests)
+}
+
+func TestEvaluator_Builtin_Assertions(t *testing.T) {
+	tests := [][]string{
+		{`assert(1 == 1)`, "null"},
+		{`assert(false)`, "error: assertion failed"},
+		{`assert(0 == 1, "x is set")`, "error: assertion failed: x is set"},
+		{`assertEqual([1, {"a": [2]}], [1, {"a": [2]}])`, "null"},
+		{`assertEqual(1 + 1, 3)`, "error: assertEqual failed: expected 3, got 2"},
+		{`assertEqual("1", 1, "parsed")`, "error: assertEqual failed: parsed: expected int 1, got string \"1\""},
+		{`assertThrows(fn() { return 1 / 0; })`, `"division by zero"`},
+		{`assertThrows(fn() { return 1 / 0; }, "zero")`, `"division by zero"`},
+		{`assertThrows(fn() { return 1; })`, "error: assertThrows failed: no error"},
+		{`assertThrows(fn() { assert(false); }, "zero")`, `error: assertThrows failed: error "assertion failed" does not contain "zero"`},
+		{`assertThrows(arity)`, `"bad args len for arity"`},
+		{`assertThrows(1)`, `"not a function"`},
+	}
+	runTests(t, tests)
+
+	eval := getEvaluator(`assertEqual([1, 2], [1, 3])`)
+	err, ok := eval.EvalNext(eval.env).(object.Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "[1, 3]", err.Expected)
+		assert.Equal(t, "[1, 2]", err.Actual)
+	}
+}
+
+func TestEvaluator_TypeAnnotations(t *testing.T) {
+	tests := [][]string{
+		{`let x: int = 1; x`, "null", "1"},
+		{`let f = fn(a: string, n: int = 2, ...rest: any): string { return a; }; f("s")`, "null", `"s"`},
+		{`let x: int = "unchecked at runtime"; x`, "null", `"unchecked at runtime"`},
 	}
 	runTests(t, tests)
 }
+
+type recordingHooks struct {
+	events []string
+}
+
+func (h *recordingHooks) Before(node ast.Node, env *object.Env) {
+	if _, ok := node.(ast.Statement); ok {
+		h.events = append(h.events, fmt.Sprintf("statement %d", ast.Start(node).Line))
+	}
+}
+
+func (h *recordingHooks) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {
+	h.events = append(h.events, fmt.Sprintf("call %s %v", call.FunctionExpr.TokenLiteral(), env.Names()))
+}
+
+func (h *recordingHooks) Return(call *ast.FunctionCall, result object.Object) {
+	h.events = append(h.events, fmt.Sprintf("return %s %v", call.FunctionExpr.TokenLiteral(), result))
+}
+
+func TestEvaluator_Hooks(t *testing.T) {
+	eval := getEvaluator(`let f = fn(a) {
+	return a + 1;
+};
+let x = f(1);
+let g = fn() { return 1 / 0; };
+g();`)
+	hooks := &recordingHooks{}
+	eval.SetHooks(hooks)
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+	}
+	assert.Equal(t, []string{
+		"statement 1",
+		"statement 4",
+		"call f [a]",
+		"statement 2",
+		"return f 2",
+		"statement 5",
+		"call g []",
+		"statement 5",
+		"return g <nil>",
+	}, hooks.events)
+}
+
+type branchRecordingHooks struct {
+	recordingHooks
+}
+
+func (h *branchRecordingHooks) Branch(node ast.Node, branch int) {
+	h.events = append(h.events, fmt.Sprintf("branch %s %d", node.TokenLiteral(), branch))
+}
+
+func TestEvaluator_BranchHooks(t *testing.T) {
+	eval := getEvaluator(`let i = 0;
+while (i < 2) {
+	if (i == 0) { i++; } else { i++; }
+}
+match (i) { 1 => 1, 2 => 2 };`)
+	hooks := &branchRecordingHooks{}
+	eval.SetHooks(hooks)
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+	}
+	var branches []string
+	for _, event := range hooks.events {
+		if strings.HasPrefix(event, "branch") {
+			branches = append(branches, event)
+		}
+	}
+	assert.Equal(t, []string{
+		"branch while 0",
+		"branch if 0",
+		"branch while 0",
+		"branch if 1",
+		"branch while 1",
+		"branch match 1",
+	}, branches)
+}
+
+func TestEvaluator_MultiHooks(t *testing.T) {
+	eval := getEvaluator(`let f = fn(x) { if (x) { return 1; } };
+f(true);`)
+	first, second := &recordingHooks{}, &branchRecordingHooks{}
+	eval.SetHooks(MultiHooks{first, second})
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+	}
+	assert.Equal(t, []string{"statement 1", "call f [x]", "statement 1", "statement 1", "return f 1"}, first.events)
+	assert.Equal(t, []string{"statement 1", "call f [x]", "statement 1", "branch if 0", "statement 1", "return f 1"}, second.events)
+}
+
+func TestEvaluator_Trace(t *testing.T) {
+	eval := getEvaluator(`let add = fn(a, b) {
+	return a + len([b]);
+};
+add(1, b: 2);
+let fail = fn() { return 1 / 0; };
+fail();`)
+	var sb strings.Builder
+	eval.SetTrace(&sb)
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+	}
+	assert.Equal(t, `-> add(1, b: 2) at 4:1
+  -> len([2]) at 2:13
+  <- len = 1
+<- add = 2
+-> fail() at 6:1
+<- fail failed: division by zero
+`, sb.String())
+}
+
+func TestEvaluator_FunctionDefinition(t *testing.T) {
+	eval := getEvaluator(`let add = fn(a, b) { return a + b; }; let f = add; let [g] = [fn() {}];`)
+	eval.path = "main.mk"
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+	}
+	add := eval.env.MustGet("add").(object.Function)
+	assert.Equal(t, "add", add.Name)
+	assert.Equal(t, "main.mk", add.Path)
+	assert.Equal(t, 11, add.Definition.Token.Column)
+	assert.Equal(t, "add", eval.env.MustGet("f").(object.Function).Name)
+	assert.Equal(t, "", eval.env.MustGet("g").(object.Function).Name)
+}
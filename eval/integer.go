@@ -0,0 +1,107 @@
+package eval
+
+import (
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/token"
+	"math"
+	"math/big"
+)
+
+// isInteger reports whether obj is an Integer or a BigInt.
+func isInteger(obj object.Object) bool {
+	switch obj.(type) {
+	case object.Integer, object.BigInt:
+		return true
+	}
+	return false
+}
+
+func toBig(obj object.Object) *big.Int {
+	if i, ok := obj.(object.BigInt); ok {
+		return i.Value
+	}
+	return big.NewInt(int64(obj.(object.Integer).Value))
+}
+
+// integerArithmetic applies an arithmetic operator to two integers, working
+// on ints unless the operands or the result do not fit in one.
+func integerArithmetic(left object.Object, right object.Object, tokenType token.TokenType) object.Object {
+	l, lok := left.(object.Integer)
+	r, rok := right.(object.Integer)
+	if lok && rok {
+		if result, ok := intArithmetic(l.Value, r.Value, tokenType); ok {
+			return object.NewInteger(result)
+		}
+	}
+
+	a, b := toBig(left), toBig(right)
+	result := new(big.Int)
+	switch tokenType {
+	case token.TOKEN_PLUS:
+		result.Add(a, b)
+	case token.TOKEN_MINUS:
+		result.Sub(a, b)
+	case token.TOKEN_ASTERISK:
+		result.Mul(a, b)
+	case token.TOKEN_SLASH:
+		if b.Sign() == 0 {
+			panic(object.NewError("division by zero"))
+		}
+		result.Quo(a, b)
+	case token.TOKEN_PERCENT:
+		if b.Sign() == 0 {
+			panic(object.NewError("division by zero"))
+		}
+		result.Rem(a, b)
+	default:
+		panic(object.NewError("unsupported arithmetic operator"))
+	}
+	return object.NewInt(result)
+}
+
+// intArithmetic applies an arithmetic operator to two ints, reporting false
+// if the result overflows.
+func intArithmetic(a int, b int, tokenType token.TokenType) (int, bool) {
+	switch tokenType {
+	case token.TOKEN_PLUS:
+		c := a + b
+		return c, (c > a) == (b > 0)
+	case token.TOKEN_MINUS:
+		c := a - b
+		return c, (c < a) == (b > 0)
+	case token.TOKEN_ASTERISK:
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		c := a * b
+		return c, c/b == a && !(a == -1 && b == math.MinInt) && !(b == -1 && a == math.MinInt)
+	case token.TOKEN_SLASH:
+		if b == 0 {
+			panic(object.NewError("division by zero"))
+		}
+		return a / b, !(a == math.MinInt && b == -1)
+	case token.TOKEN_PERCENT:
+		if b == 0 {
+			panic(object.NewError("division by zero"))
+		}
+		return a % b, true
+	}
+	panic(object.NewError("unsupported arithmetic operator"))
+}
+
+// compareIntegers returns -1, 0 or 1 as the integer left is less than, equal
+// to or greater than right.
+func compareIntegers(left object.Object, right object.Object) int {
+	l, lok := left.(object.Integer)
+	r, rok := right.(object.Integer)
+	if lok && rok {
+		switch {
+		case l.Value < r.Value:
+			return -1
+		case l.Value > r.Value:
+			return 1
+		}
+		return 0
+	}
+	return toBig(left).Cmp(toBig(right))
+}
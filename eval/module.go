@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ModuleResolver locates the source of an imported module. from is the
+// resolved path of the importing module, or empty for code that does not
+// come from a file. The returned path identifies the module in the cache and
+// is passed as from when the module imports others.
+type ModuleResolver interface {
+	Resolve(from string, importPath string) (resolved string, source string, err error)
+}
+
+// resolveModulePath joins a relative import path onto the directory of the
+// importing module. Absolute import paths are taken from the root.
+func resolveModulePath(from string, importPath string) (string, error) {
+	var resolved string
+	if path.IsAbs(importPath) {
+		resolved = path.Clean(strings.TrimLeft(importPath, "/"))
+	} else {
+		resolved = path.Join(path.Dir(from), importPath)
+	}
+	if !fs.ValidPath(resolved) {
+		return "", fmt.Errorf("invalid module path %s", importPath)
+	}
+	return resolved, nil
+}
+
+// FSResolver serves modules from a file system.
+type FSResolver struct {
+	FS fs.FS
+}
+
+func (r FSResolver) Resolve(from string, importPath string) (string, string, error) {
+	resolved, err := resolveModulePath(from, importPath)
+	if err != nil {
+		return "", "", err
+	}
+	source, err := fs.ReadFile(r.FS, resolved)
+	if err != nil {
+		return "", "", err
+	}
+	return resolved, string(source), nil
+}
+
+// MapResolver serves modules from memory, keyed by path.
+type MapResolver map[string]string
+
+func (r MapResolver) Resolve(from string, importPath string) (string, string, error) {
+	resolved, err := resolveModulePath(from, importPath)
+	if err != nil {
+		return "", "", err
+	}
+	source, ok := r[resolved]
+	if !ok {
+		return "", "", fmt.Errorf("module %s not found", resolved)
+	}
+	return resolved, source, nil
+}
+
+// ModuleLoader evaluates imported modules, each in its own Env, and caches
+// them so a module imported from several places is only evaluated once.
+type ModuleLoader struct {
+	resolver ModuleResolver
+	globals *object.Env
+	modules map[string]object.Module
+	loading []string
+}
+
+func NewModuleLoader(resolver ModuleResolver) *ModuleLoader {
+	return &ModuleLoader{resolver: resolver, modules: make(map[string]object.Module)}
+}
+
+// SetGlobals makes the bindings of env, such as the prelude, visible to every
+// module loaded afterwards.
+func (l *ModuleLoader) SetGlobals(env *object.Env) {
+	l.globals = env
+}
+
+// Load loads importPath as imported by the module at from. Its top level
+// has no input or output.
+func (l *ModuleLoader) Load(from string, importPath string) object.Module {
+	return l.load(from, importPath, nil)
+}
+
+// load loads a module whose top level uses stdio for input and output, or
+// none if stdio is nil.
+func (l *ModuleLoader) load(from string, importPath string, stdio *object.Stdio) object.Module {
+	resolved, source, err := l.resolver.Resolve(from, importPath)
+	if err != nil {
+		panic(object.NewError(fmt.Sprintf("cannot import %s: %s", importPath, err)))
+	}
+	if m, ok := l.modules[resolved]; ok {
+		return m
+	}
+	for i, loading := range l.loading {
+		if loading == resolved {
+			cycle := append(append([]string{}, l.loading[i:]...), resolved)
+			panic(object.NewError(fmt.Sprintf("import cycle: %s", strings.Join(cycle, " -> "))))
+		}
+	}
+	l.loading = append(l.loading, resolved)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	lex := token.NewLexer(source)
+	p := parser.NewParser(&lex)
+	env := object.NewEnv()
+	if l.globals != nil {
+		env = object.NewNestedEnv(l.globals)
+	}
+	ev := NewEvaluator(&p, env)
+	ev.SetModuleLoader(l, resolved)
+	ev.stdio = stdio
+	for obj := ev.EvalNext(env); obj != nil; obj = ev.EvalNext(env) {
+		if err, ok := obj.(object.Error); ok {
+			panic(err)
+		}
+	}
+	m := object.NewModule(resolved, env, ev.exports)
+	l.modules[resolved] = m
+	return m
+}
+
+func (ev *Evaluator) evalImportStatement(statement *ast.ImportStatement, env *object.Env) {
+	if ev.loader == nil {
+		panic(object.NewError("imports not supported"))
+	}
+	checkRedeclaration([]string{statement.Alias.TokenLiteral()}, env)
+	m := ev.loader.load(ev.path, statement.Path.Value, ev.stdio)
+	env.SetNew(statement.Alias.TokenLiteral(), m)
+}
+
+func (ev *Evaluator) evalExportStatement(statement *ast.ExportStatement, env *object.Env) {
+	if env != ev.env {
+		panic(object.NewError("export not at top level"))
+	}
+	ev.evalLetStatement(statement.Statement, env)
+	for _, name := range ast.PatternNames(statement.Statement.Name) {
+		ev.exports[name] = true
+	}
+}
@@ -0,0 +1,92 @@
+package eval
+
+import (
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func runModuleTests(t *testing.T, resolver ModuleResolver, tests [][]string) {
+	for _, inputOutput := range tests {
+		input := inputOutput[0]
+		outputs := inputOutput[1:]
+		eval := getEvaluator(input)
+		eval.SetModuleLoader(NewModuleLoader(resolver), "main.mk")
+		for _, output := range outputs {
+			assert.Equal(t, output, eval.EvalNext(eval.env).Inspect())
+		}
+		assert.Nil(t, eval.EvalNext(eval.env))
+	}
+}
+
+func TestEvaluator_Import(t *testing.T) {
+	resolver := MapResolver{
+		"lib/math.mk": `export let double = fn(x){ return helper(x) * 2; }; let helper = fn(x){ return x; }; export let [one, two] = [1, 2];`,
+		"lib/counter.mk": `let n = 0; export let next = fn(){ n++; return n; };`,
+		"lib/uses.mk": `import "counter.mk" as c; export let next = c.next;`,
+		"abs.mk": `import "/lib/math.mk" as m; export let v = m.double(5);`,
+	}
+	tests := [][]string{
+		{`import "lib/math.mk" as m; m.double(2); m.two`, "null", "4", "2"},
+		{`import "lib/counter.mk" as a; import "lib/counter.mk" as b; a.next(); b.next()`, "null", "null", "1", "2"},
+		{`import "lib/counter.mk" as a; import "lib/uses.mk" as u; a.next(); u.next()`, "null", "null", "1", "2"},
+		{`import "abs.mk" as x; x.v`, "null", "10"},
+		{`import "lib/math.mk" as m; m`, "null", `module "lib/math.mk"`},
+	}
+	runModuleTests(t, resolver, tests)
+}
+
+func TestEvaluator_Import_Error(t *testing.T) {
+	resolver := MapResolver{
+		"a.mk": `import "b.mk" as b;`,
+		"b.mk": `import "a.mk" as a;`,
+		"self.mk": `import "self.mk" as s;`,
+		"lib.mk": `let private = 1; export let public = 2;`,
+		"nested.mk": `if (true) { export let x = 1; }`,
+		"broken.mk": `export let x = y;`,
+	}
+	tests := [][]string{
+		{`import "a.mk" as a;`, "error: import cycle: a.mk -> b.mk -> a.mk"},
+		{`import "self.mk" as s;`, "error: import cycle: self.mk -> self.mk"},
+		{`import "missing.mk" as m;`, "error: cannot import missing.mk: module missing.mk not found"},
+		{`import "../up.mk" as m;`, "error: cannot import ../up.mk: invalid module path ../up.mk"},
+		{`import "lib.mk" as l; l.private`, "null", "error: name not exported by module"},
+		{`import "lib.mk" as l; l.public = 3`, "null", "error: invalid type for dot operation"},
+		{`import "nested.mk" as n;`, "error: export not at top level"},
+		{`import "broken.mk" as b;`, "error: unknown identifier"},
+	}
+	runModuleTests(t, resolver, tests)
+	runTests(t, [][]string{{`import "a.mk" as a;`, "error: imports not supported"}})
+}
+
+func TestEvaluator_Import_Stdio(t *testing.T) {
+	eval := getEvaluator(`import "lib.mk" as lib; lib.greet()`)
+	eval.SetModuleLoader(NewModuleLoader(MapResolver{"lib.mk": `println("loading"); export let greet = fn() { print("hi"); };`}), "main.mk")
+	var out strings.Builder
+	eval.SetStdio(strings.NewReader(""), &out, &out)
+	for obj := eval.EvalNext(eval.env); obj != nil; obj = eval.EvalNext(eval.env) {
+		assert.NotEqual(t, "error", obj.Type())
+	}
+	assert.Equal(t, "loading\nhi", out.String())
+}
+
+func TestFSResolver(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scripts/main.mk": {Data: []byte(`import "lib/util.mk" as u;`)},
+		"scripts/lib/util.mk": {Data: []byte(`export let x = 1;`)},
+	}
+	resolver := FSResolver{FS: fsys}
+	resolved, source, err := resolver.Resolve("scripts/main.mk", "lib/util.mk")
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts/lib/util.mk", resolved)
+	assert.Equal(t, `export let x = 1;`, source)
+	_, _, err = resolver.Resolve("scripts/main.mk", "missing.mk")
+	assert.Error(t, err)
+
+	m := NewModuleLoader(resolver).Load("scripts/main.mk", "lib/util.mk")
+	val, ok := m.Member("x")
+	assert.True(t, ok)
+	assert.Equal(t, object.NewInteger(1), val)
+}
@@ -0,0 +1,56 @@
+package eval
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/printer"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SetTrace makes the evaluator log every call of a function or builtin to w,
+// with its arguments and position, and what it returns, indented by the
+// depth of the call.
+func (ev *Evaluator) SetTrace(w io.Writer) {
+	ev.trace = w
+}
+
+func (ev *Evaluator) traceCall(call *ast.FunctionCall, args []object.Object, kwargs map[string]object.Object) {
+	var strs []string
+	for _, arg := range args {
+		strs = append(strs, arg.Inspect())
+	}
+	var names []string
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		strs = append(strs, name+": "+kwargs[name].Inspect())
+	}
+	start := ast.Start(call)
+	position := fmt.Sprintf("%d:%d", start.Line, start.Column)
+	if ev.path != "" {
+		position = ev.path + ":" + position
+	}
+	fmt.Fprintf(ev.trace, "%s-> %s(%s) at %s\n", ev.traceIndent(), printer.Print(call.FunctionExpr), strings.Join(strs, ", "), position)
+	ev.traceDepth++
+}
+
+// traceReturn logs the result of a call, or the error r it failed with,
+// which it passes on.
+func (ev *Evaluator) traceReturn(call *ast.FunctionCall, result object.Object, r interface{}) {
+	ev.traceDepth--
+	name := printer.Print(call.FunctionExpr)
+	if r != nil {
+		fmt.Fprintf(ev.trace, "%s<- %s failed: %v\n", ev.traceIndent(), name, r)
+		panic(r)
+	}
+	fmt.Fprintf(ev.trace, "%s<- %s = %s\n", ev.traceIndent(), name, result.Inspect())
+}
+
+func (ev *Evaluator) traceIndent() string {
+	return strings.Repeat("  ", ev.traceDepth)
+}
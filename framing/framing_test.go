@@ -0,0 +1,27 @@
+package framing
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Write(&sb, map[string]int{"id": 1}))
+	assert.Equal(t, "Content-Length: 8\r\n\r\n{\"id\":1}", sb.String())
+
+	r := bufio.NewReader(strings.NewReader(sb.String() + "Content-Length: 2\r\nContent-Type: x\r\n\r\n[]"))
+	data, err := Read(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(data))
+	data, err = Read(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+	_, err = Read(r)
+	assert.Error(t, err)
+
+	_, err = Read(bufio.NewReader(strings.NewReader("Content-Length: x\r\n\r\n")))
+	assert.EqualError(t, err, `bad Content-Length: strconv.Atoi: parsing "x": invalid syntax`)
+}
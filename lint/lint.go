@@ -0,0 +1,447 @@
+// Package lint reports likely mistakes in Monkey programs without running
+// them.
+package lint
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/token"
+	"sort"
+	"strings"
+)
+
+// Checks reported by Lint.
+const (
+	CheckUndefined            = "undefined"
+	CheckUnused               = "unused"
+	CheckShadow               = "shadow"
+	CheckUnreachable          = "unreachable"
+	CheckUndeclaredAssignment = "undeclared-assignment"
+	CheckArity                = "arity"
+	CheckConstAssignment      = "const-assignment"
+)
+
+// Diagnostic is a problem found at a position in the source.
+type Diagnostic struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Line, d.Column, d.Message, d.Check)
+}
+
+type binding struct {
+	name     string
+	ident    *ast.Identifier
+	token    token.Token
+	kind     string
+	used     bool
+	exported bool
+	// fn is the function literal the name was bound to, used to check the
+	// arity of calls as long as the name is never reassigned.
+	fn         *ast.Function
+	reassigned bool
+	constant   bool
+}
+
+// scope mirrors an object.Env: one per function call, block and match arm.
+type scope struct {
+	parent *scope
+	names  map[string]*binding
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, names: make(map[string]*binding)}
+}
+
+func (s *scope) lookup(name string) (*binding, bool) {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.names[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+type deferredFunction struct {
+	fn    *ast.Function
+	scope *scope
+}
+
+type call struct {
+	binding *binding
+	call    *ast.FunctionCall
+}
+
+type linter struct {
+	// resolved maps each identifier to its declaration, when requested.
+	resolved    map[*ast.Identifier]*ast.Identifier
+	universe    *scope
+	bindings    []*binding
+	functions   []deferredFunction
+	calls       []call
+	diagnostics []Diagnostic
+}
+
+// Lint checks program, treating the builtins and the given globals, such as
+// the names bound by the prelude, as predeclared.
+//
+// Function bodies are checked once the whole program has been seen, since
+// they may refer to names declared after the function, as long as they are
+// declared by the time it is called.
+func Lint(program *ast.Program, globals []string) []Diagnostic {
+	l := newLinter(globals)
+	l.run(program)
+	for _, c := range l.calls {
+		if !c.binding.reassigned {
+			l.arity(c.binding.fn, c.call, c.binding.name)
+		}
+	}
+	for _, b := range l.bindings {
+		if !b.used && !b.exported && !strings.HasPrefix(b.name, "_") {
+			l.report(b.token, CheckUnused, fmt.Sprintf("unused %s %s", b.kind, b.name))
+		}
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+// Resolve maps every identifier in program that is declared in it, either a
+// declaration or a reference to one, to the identifier that declares it.
+// References to builtins, globals and undefined names are left out, as are
+// keyword argument names and the names after a dot.
+func Resolve(program *ast.Program, globals []string) map[*ast.Identifier]*ast.Identifier {
+	l := newLinter(globals)
+	l.resolved = make(map[*ast.Identifier]*ast.Identifier)
+	l.run(program)
+	return l.resolved
+}
+
+func newLinter(globals []string) *linter {
+	l := &linter{universe: newScope(nil)}
+	for name := range eval.BUILTINS {
+		l.universe.names[name] = &binding{name: name, used: true}
+	}
+	for _, name := range globals {
+		l.universe.names[name] = &binding{name: name, used: true}
+	}
+	return l
+}
+
+func (l *linter) run(program *ast.Program) {
+	l.statements(program.Statements, newScope(l.universe))
+	for i := 0; i < len(l.functions); i++ {
+		l.function(l.functions[i].fn, l.functions[i].scope)
+	}
+}
+
+// resolve records that ident refers to the binding b.
+func (l *linter) resolve(ident *ast.Identifier, b *binding) {
+	if l.resolved != nil && b.ident != nil {
+		l.resolved[ident] = b.ident
+	}
+}
+
+// Globals lists the names a program declares at the top level, for use as
+// the globals of programs that run after it.
+func Globals(program *ast.Program) []string {
+	var names []string
+	for _, node := range program.Statements {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			names = append(names, ast.PatternNames(node.Name)...)
+		case *ast.ExportStatement:
+			names = append(names, ast.PatternNames(node.Statement.Name)...)
+		case *ast.ImportStatement:
+			names = append(names, node.Alias.TokenLiteral())
+		}
+	}
+	return names
+}
+
+func (l *linter) report(tok token.Token, check string, message string) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Line: tok.Line, Column: tok.Column, Check: check, Message: message})
+}
+
+func (l *linter) declare(ident *ast.Identifier, kind string, s *scope) *binding {
+	name := ident.TokenLiteral()
+	if name == "_" {
+		return &binding{}
+	}
+	if prev, ok := s.names[name]; ok && prev.constant {
+		l.report(ident.Token, CheckConstAssignment, fmt.Sprintf("redeclaration of constant %s declared at %d:%d", name, prev.token.Line, prev.token.Column))
+	}
+	if outer, ok := s.parent.lookup(name); ok && outer.token.Line > 0 {
+		l.report(ident.Token, CheckShadow, fmt.Sprintf("declaration of %s shadows declaration at %d:%d", name, outer.token.Line, outer.token.Column))
+	}
+	b := &binding{name: name, ident: ident, token: ident.Token, kind: kind}
+	s.names[name] = b
+	l.bindings = append(l.bindings, b)
+	l.resolve(ident, b)
+	return b
+}
+
+func (l *linter) declarePattern(pattern ast.Pattern, kind string, s *scope) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		l.declare(pattern, kind, s)
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			l.declarePattern(element, kind, s)
+		}
+		if pattern.Rest != nil {
+			l.declare(pattern.Rest, kind, s)
+		}
+	case *ast.MapPattern:
+		for _, pair := range pattern.Pairs {
+			l.declarePattern(pair.Value, kind, s)
+		}
+	}
+}
+
+func (l *linter) statements(nodes []ast.Node, s *scope) {
+	terminated := false
+	for _, node := range nodes {
+		if terminated {
+			l.report(ast.Start(node), CheckUnreachable, "unreachable code")
+			terminated = false
+		}
+		if statement, ok := node.(ast.Statement); ok {
+			l.statement(statement, s)
+		} else if expr, ok := node.(ast.Expression); ok {
+			l.expression(expr, s)
+		}
+		terminated = terminates(node)
+	}
+}
+
+// terminates reports whether control never continues past node.
+func terminates(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.IfStatement:
+		return len(node.Then) > 0 && len(node.Else) > 0 &&
+			terminates(node.Then[len(node.Then)-1]) && terminates(node.Else[len(node.Else)-1])
+	}
+	return false
+}
+
+func (l *linter) statement(statement ast.Statement, s *scope) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		l.let(statement, s)
+	case *ast.ExportStatement:
+		l.let(statement.Statement, s)
+		for _, name := range ast.PatternNames(statement.Statement.Name) {
+			s.names[name].exported = true
+		}
+	case *ast.ImportStatement:
+		l.declare(statement.Alias, "import", s)
+	case *ast.ReturnStatement:
+		l.expression(statement.Value, s)
+	case *ast.IfStatement:
+		l.expression(statement.Condition, s)
+		l.statements(statement.Then, newScope(s))
+		l.statements(statement.Else, newScope(s))
+	case *ast.WhileStatement:
+		l.expression(statement.Condition, s)
+		l.statements(statement.Body, newScope(s))
+	}
+}
+
+func (l *linter) let(statement *ast.LetStatement, s *scope) {
+	l.expression(statement.Value, s)
+	kind := "variable"
+	if statement.IsConst() {
+		kind = "constant"
+	}
+	if ident, ok := statement.Name.(*ast.Identifier); ok {
+		b := l.declare(ident, kind, s)
+		b.fn, _ = statement.Value.(*ast.Function)
+		b.constant = statement.IsConst()
+		return
+	}
+	l.declarePattern(statement.Name, kind, s)
+	if statement.IsConst() {
+		for _, name := range ast.PatternNames(statement.Name) {
+			s.names[name].constant = true
+		}
+	}
+}
+
+func (l *linter) function(fn *ast.Function, s *scope) {
+	fnScope := newScope(s)
+	for _, param := range fn.Params {
+		if param.Default != nil {
+			l.expression(param.Default, fnScope)
+		}
+		l.declarePattern(param.Pattern, "parameter", fnScope)
+	}
+	l.statements(fn.Body, fnScope)
+}
+
+func (l *linter) expression(expr ast.Expression, s *scope) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if b, ok := s.lookup(expr.TokenLiteral()); ok {
+			b.used = true
+			l.resolve(expr, b)
+		} else {
+			l.report(expr.Token, CheckUndefined, fmt.Sprintf("undefined: %s", expr.TokenLiteral()))
+		}
+	case *ast.InfixExpression:
+		if token.IsAssignment(expr.Token.Type) {
+			l.assign(expr.Left, expr.Token.Type != token.TOKEN_ASSIGNMENT, s)
+		} else {
+			l.expression(expr.Left, s)
+		}
+		l.expression(expr.Right, s)
+	case *ast.PrefixExpression:
+		l.expression(expr.Right, s)
+	case *ast.PostfixExpression:
+		l.assign(expr.Left, true, s)
+	case *ast.Function:
+		l.functions = append(l.functions, deferredFunction{fn: expr, scope: s})
+	case *ast.FunctionCall:
+		l.expression(expr.FunctionExpr, s)
+		for _, arg := range expr.Arguments {
+			l.expression(arg, s)
+		}
+		switch fn := expr.FunctionExpr.(type) {
+		case *ast.Identifier:
+			if b, ok := s.lookup(fn.TokenLiteral()); ok && b.fn != nil {
+				l.calls = append(l.calls, call{binding: b, call: expr})
+			}
+		case *ast.Function:
+			l.arity(fn, expr, "function literal")
+		}
+	case *ast.Spread:
+		l.expression(expr.Value, s)
+	case *ast.KeywordArgument:
+		l.expression(expr.Value, s)
+	case *ast.Array:
+		for _, element := range expr.Elements {
+			l.expression(element, s)
+		}
+	case *ast.Template:
+		for _, value := range expr.Values {
+			l.expression(value, s)
+		}
+	case *ast.Map:
+		for _, pair := range expr.Pairs {
+			l.expression(pair[0], s)
+			l.expression(pair[1], s)
+		}
+	case *ast.Index:
+		l.expression(expr.Left, s)
+		l.expression(expr.Index, s)
+	case *ast.Slice:
+		l.expression(expr.Left, s)
+		for _, bound := range []ast.Expression{expr.Start, expr.End, expr.Step} {
+			if bound != nil {
+				l.expression(bound, s)
+			}
+		}
+	case *ast.Dot:
+		l.expression(expr.Left, s)
+	case *ast.MatchExpression:
+		l.expression(expr.Value, s)
+		for _, arm := range expr.Arms {
+			armScope := newScope(s)
+			l.declarePattern(arm.Pattern, "variable", armScope)
+			if arm.Guard != nil {
+				l.expression(arm.Guard, armScope)
+			}
+			l.expression(arm.Body, armScope)
+		}
+	}
+}
+
+// assign checks the target of an assignment. Assigning to a name does not
+// count as using it, unless the old value is read as with += and ++.
+func (l *linter) assign(target ast.Expression, reads bool, s *scope) {
+	ident, ok := target.(*ast.Identifier)
+	if !ok {
+		l.expression(target, s)
+		return
+	}
+	b, ok := s.lookup(ident.TokenLiteral())
+	if !ok {
+		l.report(ident.Token, CheckUndeclaredAssignment, fmt.Sprintf("assignment to undeclared variable %s", ident.TokenLiteral()))
+		return
+	}
+	l.resolve(ident, b)
+	if b.constant {
+		l.report(ident.Token, CheckConstAssignment, fmt.Sprintf("assignment to constant %s", ident.TokenLiteral()))
+	}
+	b.reassigned = true
+	if reads {
+		b.used = true
+	}
+}
+
+// arity checks the arguments of c against the parameters of fn, following
+// the same rules as the evaluator binding them. Calls that spread an array
+// cannot be checked.
+func (l *linter) arity(fn *ast.Function, c *ast.FunctionCall, name string) {
+	positional := 0
+	kwargs := make(map[string]bool)
+	for _, arg := range c.Arguments {
+		switch arg := arg.(type) {
+		case *ast.Spread:
+			return
+		case *ast.KeywordArgument:
+			kwargs[arg.Name.TokenLiteral()] = true
+		default:
+			positional++
+		}
+	}
+	pos := ast.Start(c)
+	used, max := 0, 0
+	for _, param := range fn.Params {
+		paramName := ""
+		if ident, ok := param.Pattern.(*ast.Identifier); ok {
+			paramName = ident.TokenLiteral()
+		}
+		hasKwarg := kwargs[paramName]
+		delete(kwargs, paramName)
+		switch {
+		case param.Variadic:
+			if hasKwarg {
+				l.report(pos, CheckArity, fmt.Sprintf("variadic parameter %s passed as keyword argument in call to %s", paramName, name))
+			}
+			max = -1
+			used = positional
+		case used < positional:
+			if hasKwarg {
+				l.report(pos, CheckArity, fmt.Sprintf("multiple values for argument %s in call to %s", paramName, name))
+			}
+			used++
+			max++
+		case hasKwarg || param.Default != nil:
+			max++
+		default:
+			l.report(pos, CheckArity, fmt.Sprintf("missing argument %s in call to %s", paramName, name))
+			max++
+		}
+	}
+	if used < positional {
+		l.report(pos, CheckArity, fmt.Sprintf("too many arguments in call to %s: got %d, want at most %d", name, positional, max))
+	}
+	var unknown []string
+	for kwarg := range kwargs {
+		unknown = append(unknown, kwarg)
+	}
+	sort.Strings(unknown)
+	for _, kwarg := range unknown {
+		l.report(pos, CheckArity, fmt.Sprintf("unknown keyword argument %s in call to %s", kwarg, name))
+	}
+}
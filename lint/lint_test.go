@@ -0,0 +1,127 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func lint(str string, globals ...string) []string {
+	lex := token.NewLexer(str)
+	p := parser.NewParser(&lex)
+	var diagnostics []string
+	for _, d := range Lint(p.ParseProgram(), globals) {
+		diagnostics = append(diagnostics, d.String())
+	}
+	return diagnostics
+}
+
+func TestLint_Clean(t *testing.T) {
+	assert.Empty(t, lint(`
+let fib = fn(n) {
+	if (n < 2) { return n; }
+	return fib(n - 1) + fib(n - 2);
+};
+let isEven = fn(n) { if (n == 0) { return true; } return isOdd(n - 1); };
+let isOdd = fn(n) { if (n == 0) { return false; } return isEven(n - 1); };
+let count = 0;
+let inc = fn(_step) { count += 1; };
+inc(1);
+let [a, ...rest] = [fib(10), isEven(4)];
+export let total = match (a) { 0 => len(rest), n if n > 1 => n, _ => 0 };
+`))
+	assert.Empty(t, lint(`let xs = map([1], fn(x) { x }); xs;`, "map"))
+}
+
+func TestLint_Undefined(t *testing.T) {
+	assert.Equal(t, []string{"1:9: undefined: y (undefined)"}, lint(`let x = y; x;`))
+	assert.Equal(t, []string{"1:29: undefined: z (undefined)"}, lint(`if (true) { let z = 1; z; } z;`))
+	assert.Equal(t, []string{"1:39: undefined: n (undefined)"}, lint(`let f = fn(x) { match (x) { n => n }; n; }; f(1);`))
+	assert.Equal(t, []string{"1:9: undefined: map (undefined)"}, lint(`let m = map; m;`))
+}
+
+func TestLint_Unused(t *testing.T) {
+	assert.Equal(t, []string{"1:5: unused variable x (unused)"}, lint(`let x = 1;`))
+	assert.Equal(t, []string{"1:15: unused parameter b (unused)", "1:27: unused variable c (unused)"},
+		lint(`let f = fn(a, b) { a; let c = 2; }; f(1, 2);`))
+	assert.Equal(t, []string{"1:18: unused import m (unused)"}, lint(`import "m.mk" as m;`))
+	assert.Equal(t, []string{"1:5: unused variable x (unused)"}, lint(`let x = 1; x = 2;`))
+	assert.Empty(t, lint(`let x = 1; x++; export let [_y, z] = [1, 2];`))
+}
+
+func TestLint_Shadow(t *testing.T) {
+	assert.Equal(t, []string{"1:25: declaration of x shadows declaration at 1:5 (shadow)"},
+		lint(`let x = 1; if (x) { let x = 2; x; }`))
+	assert.Equal(t, []string{"1:23: declaration of x shadows declaration at 1:5 (shadow)"},
+		lint(`let x = 1; let f = fn(x) { x; }; f(x);`))
+	assert.Empty(t, lint(`let x = 1; let x = x + 1; x; let len = 2; len;`))
+}
+
+func TestLint_Unreachable(t *testing.T) {
+	assert.Equal(t, []string{"1:26: unreachable code (unreachable)"},
+		lint(`let f = fn() { return 1; f; }; f();`))
+	assert.Equal(t, []string{"1:57: unreachable code (unreachable)"},
+		lint(`let f = fn(x) { if (x) { return 1; } else { return 2; } x; }; f(1);`))
+	assert.Empty(t, lint(`let f = fn(x) { if (x) { return 1; } return 2; }; f(1);`))
+}
+
+func TestLint_UndeclaredAssignment(t *testing.T) {
+	assert.Equal(t, []string{"1:1: assignment to undeclared variable x (undeclared-assignment)"}, lint(`x = 1;`))
+	assert.Equal(t, []string{"1:16: assignment to undeclared variable y (undeclared-assignment)"},
+		lint(`let f = fn() { y++; }; f();`))
+}
+
+func TestLint_ConstAssignment(t *testing.T) {
+	assert.Equal(t, []string{"1:14: assignment to constant x (const-assignment)"}, lint(`const x = 1; x = 2; x;`))
+	assert.Equal(t, []string{"1:39: assignment to constant b (const-assignment)"},
+		lint(`const [a, b] = [1, 2]; let f = fn() { b++; }; f(); a;`))
+	assert.Equal(t, []string{"1:7: unused constant x (unused)"}, lint(`const x = 1;`))
+	assert.Equal(t, []string{"1:21: redeclaration of constant c declared at 1:7 (const-assignment)"},
+		lint(`const c = 1; c; let c = 2; c;`))
+	assert.Equal(t, []string{"1:34: redeclaration of constant p declared at 1:8 (const-assignment)"},
+		lint(`const [p, q] = [1, 2]; p; q; let p = 9; p;`))
+	assert.Empty(t, lint(`const x = [1]; x[0] = 2; let f = fn() { let y = x; y = 2; return y; }; f();`))
+}
+
+func TestLint_Arity(t *testing.T) {
+	assert.Equal(t, []string{"1:30: too many arguments in call to f: got 3, want at most 2 (arity)"},
+		lint(`let f = fn(a, b) { a + b; }; f(1, 2, 3);`))
+	assert.Equal(t, []string{"1:30: missing argument b in call to f (arity)"},
+		lint(`let f = fn(a, b) { a + b; }; f(1);`))
+	assert.Equal(t, []string{
+		"1:34: multiple values for argument a in call to f (arity)",
+		"1:34: unknown keyword argument c in call to f (arity)",
+	}, lint(`let f = fn(a, b = 1) { a + b; }; f(1, a: 2, c: 3);`))
+	assert.Equal(t, []string{"1:28: variadic parameter xs passed as keyword argument in call to f (arity)"},
+		lint(`let f = fn(...xs) { xs; }; f(xs: 1);`))
+	assert.Equal(t, []string{"1:1: missing argument x in call to function literal (arity)"}, lint(`fn(x) { x; }();`))
+	assert.Empty(t, lint(`let f = fn(a, ...xs) { a + len(xs); }; f(1, 2, 3); f(...[1]); f(a: 1);`))
+	assert.Empty(t, lint(`let f = fn(a) { a; }; f = fn() { 1; }; f();`))
+}
+
+func TestGlobals(t *testing.T) {
+	lex := token.NewLexer(`import "std/list.mk" as list; let map = list.map; export let [a, {"k": b}] = [1, {}];`)
+	p := parser.NewParser(&lex)
+	assert.Equal(t, []string{"list", "map", "a", "b"}, Globals(p.ParseProgram()))
+}
+
+func TestResolve(t *testing.T) {
+	lex := token.NewLexer(`let x = 1; let f = fn(x) { x + y; }; x = f(x); let g = fn() { h; }; let h = 2;`)
+	p := parser.NewParser(&lex)
+	resolved := Resolve(p.ParseProgram(), nil)
+	var refs []string
+	for ref, decl := range resolved {
+		refs = append(refs, fmt.Sprintf("%d->%d", ref.Token.Column, decl.Token.Column))
+	}
+	sort.Strings(refs)
+	assert.Equal(t, []string{"16->16", "23->23", "28->23", "38->5", "42->16", "44->5", "5->5", "52->52", "63->73", "73->73"}, refs)
+}
+
+func TestPreludeGlobals(t *testing.T) {
+	globals, err := PreludeGlobals()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map", "filter", "reduce", "range"}, globals)
+}
@@ -0,0 +1,43 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"github.com/carsonip/monkey-interpreter/token"
+	"io/fs"
+	"strings"
+)
+
+// PreludeGlobals lists the names the prelude binds for every script, for use
+// as the globals of Lint and Resolve. Names starting with _ are the
+// prelude's own and left out.
+func PreludeGlobals() ([]string, error) {
+	source, err := fs.ReadFile(stdlib.FS, "prelude.mk")
+	if err != nil {
+		return nil, err
+	}
+	program, err := parsePrelude(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("prelude: %s", err)
+	}
+	var globals []string
+	for _, name := range Globals(program) {
+		if !strings.HasPrefix(name, "_") {
+			globals = append(globals, name)
+		}
+	}
+	return globals, nil
+}
+
+func parsePrelude(source string) (program *ast.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	lex := token.NewLexer(source)
+	p := parser.NewParser(&lex)
+	return p.ParseProgram(), nil
+}
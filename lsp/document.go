@@ -0,0 +1,393 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/lint"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/printer"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/carsonip/monkey-interpreter/typecheck"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+var keywords = []string{"fn", "let", "const", "true", "false", "if", "else", "return", "match", "while", "import", "export", "as"}
+
+// document is an open text document and what the server knows about it.
+type document struct {
+	text string
+	// program is nil if the text does not parse.
+	program  *ast.Program
+	resolved map[*ast.Identifier]*ast.Identifier
+	// lastProgram is the most recent program that parsed, which keeps
+	// completion working while the text is being edited.
+	lastProgram *ast.Program
+	diagnostics []Diagnostic
+	// utf16 is set if the client counts the characters of positions in
+	// UTF-16 code units, the protocol's default, rather than in the bytes
+	// the lexer and the document's own positions count.
+	utf16 bool
+}
+
+func analyze(text string, globals []string) *document {
+	doc := &document{text: text, diagnostics: []Diagnostic{}}
+	program, errToken, err := parse(text)
+	if err != nil {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    tokenRange(errToken),
+			Severity: SeverityError,
+			Source:   "parser",
+			Message:  err.Error(),
+		})
+		return doc
+	}
+	doc.program = program
+	doc.lastProgram = program
+	doc.resolved = lint.Resolve(program, globals)
+	for _, d := range lint.Lint(program, globals) {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    doc.wordRange(d.Line, d.Column),
+			Severity: SeverityWarning,
+			Code:     d.Check,
+			Source:   "lint",
+			Message:  d.Message,
+		})
+	}
+	for _, e := range typecheck.Check(program) {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    doc.wordRange(e.Line, e.Column),
+			Severity: SeverityError,
+			Source:   "typecheck",
+			Message:  e.Message,
+		})
+	}
+	return doc
+}
+
+func parse(text string) (program *ast.Program, errToken token.Token, err error) {
+	lex := token.NewLexer(text)
+	p := parser.NewParser(&lex)
+	defer func() {
+		if r := recover(); r != nil {
+			errToken = p.CurrentToken()
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return p.ParseProgram(), token.Token{}, nil
+}
+
+// tokenRange converts the one-based position of a token to a range.
+func tokenRange(tok token.Token) Range {
+	start := Position{Line: tok.Line - 1, Character: tok.Column - 1}
+	if start.Line < 0 || start.Character < 0 {
+		start = Position{}
+	}
+	length := len(tok.Literal)
+	if tok.Type == token.TOKEN_STRING {
+		length += 2
+	} else if tok.Type == token.TOKEN_EOF || length == 0 {
+		length = 1
+	}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + length}}
+}
+
+// wordRange returns the range of the identifier or other run of characters
+// starting at a one-based line and column, or a single character.
+func (d *document) wordRange(line int, column int) Range {
+	start := Position{Line: line - 1, Character: column - 1}
+	lines := strings.Split(d.text, "\n")
+	length := 1
+	if start.Line >= 0 && start.Line < len(lines) && start.Character >= 0 && start.Character < len(lines[start.Line]) {
+		rest := lines[start.Line][start.Character:]
+		if n := strings.IndexFunc(rest, func(r rune) bool { return !isIdentifierChar(r) }); n > 0 {
+			length = n
+		} else if n < 0 {
+			length = len(rest)
+		}
+	}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + length}}
+}
+
+func isIdentifierChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+}
+
+// identifierAt returns the identifier that covers pos.
+func (d *document) identifierAt(pos Position) *ast.Identifier {
+	if d.program == nil {
+		return nil
+	}
+	var found *ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			r := tokenRange(ident.Token)
+			if r.Start.Line == pos.Line && r.Start.Character <= pos.Character && pos.Character < r.End.Character {
+				found = ident
+			}
+		}
+		return found == nil
+	})
+	return found
+}
+
+func (d *document) definition(pos Position) (Range, bool) {
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return Range{}, false
+	}
+	decl, ok := d.resolved[ident]
+	if !ok {
+		return Range{}, false
+	}
+	return tokenRange(decl.Token), true
+}
+
+func (d *document) hover(pos Position, globals []string) *Hover {
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return nil
+	}
+	var text string
+	if decl, ok := d.resolved[ident]; ok {
+		text = d.describe(decl)
+	} else if t, ok := typecheck.Builtins[ident.TokenLiteral()]; ok && !d.declares(ident.TokenLiteral()) {
+		text = fmt.Sprintf("builtin %s: %s", ident.TokenLiteral(), t)
+	} else {
+		for _, global := range globals {
+			if global == ident.TokenLiteral() {
+				text = "prelude " + global
+			}
+		}
+	}
+	if text == "" {
+		return nil
+	}
+	r := tokenRange(ident.Token)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + text + "\n```"}, Range: &r}
+}
+
+// declares reports whether the document declares name anywhere.
+func (d *document) declares(name string) bool {
+	for _, decl := range d.resolved {
+		if decl.TokenLiteral() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describe summarizes the declaration of decl: the let statement with the
+// value it binds, with functions shortened to their signature, or the
+// parameter or import it comes from.
+func (d *document) describe(decl *ast.Identifier) string {
+	var text string
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if text != "" {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			if !contains(node.Name, decl) {
+				return true
+			}
+			if node.Name != ast.Pattern(decl) {
+				text = fmt.Sprintf("%s %s (destructured from %s)", node.TokenLiteral(), decl.TokenLiteral(), summary(node.Value))
+				return false
+			}
+			text = node.TokenLiteral() + " " + decl.TokenLiteral()
+			if node.Type != nil {
+				text += ": " + node.Type.TokenLiteral()
+			}
+			text += " = " + summary(node.Value)
+		case *ast.Parameter:
+			if contains(node.Pattern, decl) {
+				param := printer.Print(&ast.Function{Params: []*ast.Parameter{node}})
+				text = "parameter " + strings.TrimSuffix(strings.TrimPrefix(param, "fn("), ") {}")
+			}
+		case *ast.ImportStatement:
+			if node.Alias == decl {
+				text = printer.Print(node)
+			}
+		case *ast.MatchArm:
+			if contains(node.Pattern, decl) {
+				text = "match arm binding " + decl.TokenLiteral()
+			}
+		}
+		return true
+	})
+	return strings.TrimSuffix(text, ";")
+}
+
+func contains(pattern ast.Node, ident *ast.Identifier) bool {
+	found := false
+	ast.Inspect(pattern, func(node ast.Node) bool {
+		found = found || node == ident
+		return !found
+	})
+	return found
+}
+
+// summary prints an expression, showing only the signature of a function.
+func summary(expr ast.Expression) string {
+	if fn, ok := expr.(*ast.Function); ok {
+		signature := &ast.Function{Token: fn.Token, Params: fn.Params, ReturnType: fn.ReturnType}
+		return strings.TrimSuffix(printer.Print(signature), " {}") + " { ... }"
+	}
+	return printer.Print(expr)
+}
+
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	if d.program == nil {
+		return symbols
+	}
+	add := func(ident *ast.Identifier, kind int) {
+		r := tokenRange(ident.Token)
+		symbols = append(symbols, DocumentSymbol{Name: ident.TokenLiteral(), Kind: kind, Range: r, SelectionRange: r})
+	}
+	var let func(statement *ast.LetStatement)
+	let = func(statement *ast.LetStatement) {
+		if ident, ok := statement.Name.(*ast.Identifier); ok {
+			if _, ok := statement.Value.(*ast.Function); ok {
+				add(ident, SymbolKindFunction)
+				return
+			}
+		}
+		kind := SymbolKindVariable
+		if statement.IsConst() {
+			kind = SymbolKindConstant
+		}
+		ast.Inspect(statement.Name, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Identifier); ok && ident.TokenLiteral() != "_" {
+				add(ident, kind)
+			}
+			return true
+		})
+	}
+	for _, node := range d.program.Statements {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			let(node)
+		case *ast.ExportStatement:
+			let(node.Statement)
+		case *ast.ImportStatement:
+			add(node.Alias, SymbolKindModule)
+		}
+	}
+	return symbols
+}
+
+// completion offers the names declared in the document, the builtins, the
+// prelude and the keywords.
+func (s *Server) completion(doc *document) []CompletionItem {
+	items := make(map[string]CompletionItem)
+	for _, keyword := range keywords {
+		items[keyword] = CompletionItem{Label: keyword, Kind: CompletionKindKeyword}
+	}
+	for _, global := range s.globals {
+		items[global] = CompletionItem{Label: global, Kind: CompletionKindVariable, Detail: "prelude"}
+	}
+	for name := range eval.BUILTINS {
+		item := CompletionItem{Label: name, Kind: CompletionKindFunction, Detail: "builtin"}
+		if t, ok := typecheck.Builtins[name]; ok {
+			item.Detail = t.String()
+		}
+		items[name] = item
+	}
+	if doc != nil && doc.lastProgram != nil {
+		ast.Inspect(doc.lastProgram, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.LetStatement:
+				kind := CompletionKindVariable
+				if _, ok := node.Value.(*ast.Function); ok {
+					kind = CompletionKindFunction
+				}
+				for _, name := range lint.Globals(&ast.Program{Statements: []ast.Node{node}}) {
+					items[name] = CompletionItem{Label: name, Kind: kind}
+				}
+			case *ast.Parameter:
+				ast.Inspect(node.Pattern, func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Identifier); ok && ident.TokenLiteral() != "_" {
+						items[ident.TokenLiteral()] = CompletionItem{Label: ident.TokenLiteral(), Kind: CompletionKindVariable}
+					}
+					return true
+				})
+			case *ast.ImportStatement:
+				items[node.Alias.TokenLiteral()] = CompletionItem{Label: node.Alias.TokenLiteral(), Kind: CompletionKindModule}
+			}
+			return true
+		})
+	}
+	result := make([]CompletionItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// format returns an edit replacing the whole document with its formatted
+// text, or no edits if it is already formatted.
+func (d *document) format() ([]TextEdit, error) {
+	formatted, err := printer.Format(d.text)
+	if err != nil {
+		return nil, err
+	}
+	if formatted == d.text {
+		return []TextEdit{}, nil
+	}
+	lines := strings.Split(d.text, "\n")
+	end := Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}
+	return []TextEdit{{Range: Range{End: end}, NewText: formatted}}, nil
+}
+
+// encode converts pos to the client's encoding.
+func (d *document) encode(pos Position) Position {
+	if !d.utf16 {
+		return pos
+	}
+	line := d.line(pos.Line)
+	n := pos.Character
+	if n > len(line) {
+		n = len(line)
+	}
+	units := len(utf16.Encode([]rune(line[:n])))
+	return Position{Line: pos.Line, Character: units + pos.Character - n}
+}
+
+func (d *document) encodeRange(r Range) Range {
+	return Range{Start: d.encode(r.Start), End: d.encode(r.End)}
+}
+
+// decode converts pos from the client's encoding.
+func (d *document) decode(pos Position) Position {
+	if !d.utf16 {
+		return pos
+	}
+	line := d.line(pos.Line)
+	units := 0
+	for i, r := range line {
+		if units >= pos.Character {
+			return Position{Line: pos.Line, Character: i}
+		}
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+	}
+	return Position{Line: pos.Line, Character: len(line) + pos.Character - units}
+}
+
+// line returns the text of the zero-based line n, empty if there is none.
+func (d *document) line(n int) string {
+	lines := strings.Split(d.text, "\n")
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return lines[n]
+}
@@ -0,0 +1,218 @@
+// Package lsp implements a Language Server Protocol server for Monkey,
+// offering diagnostics, hover, go to definition, document symbols, completion
+// and formatting.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/carsonip/monkey-interpreter/framing"
+	"github.com/carsonip/monkey-interpreter/lint"
+	"io"
+)
+
+// Server holds the open documents of one client connection.
+type Server struct {
+	globals []string
+	docs    map[string]*document
+	w       io.Writer
+	// utf8 is set if the client accepted positions counted in bytes.
+	utf8 bool
+}
+
+func NewServer() (*Server, error) {
+	globals, err := lint.PreludeGlobals()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{globals: globals, docs: make(map[string]*document)}, nil
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends exit or closes r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	reader := bufio.NewReader(r)
+	for {
+		data, err := framing.Read(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			if err := s.write(response{JSONRPC: "2.0", Error: &responseError{Code: codeParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, rpcErr := s.handle(req)
+		if req.ID == nil {
+			// Notifications get no response.
+			continue
+		}
+		if err := s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) write(msg interface{}) error {
+	return framing.Write(s.w, msg)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		var params InitializeParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, invalidParams(err)
+			}
+		}
+		encoding := "utf-16"
+		for _, e := range params.Capabilities.General.PositionEncodings {
+			if e == "utf-8" {
+				encoding = e
+				s.utf8 = true
+			}
+		}
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"positionEncoding":           encoding,
+				"textDocumentSync":           1,
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentSymbolProvider":     true,
+				"completionProvider":         map[string]interface{}{},
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "monkey"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			// The server asks for full syncs, so the last change holds the
+			// whole document.
+			s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		return nil, nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if doc, ok := s.docs[params.TextDocument.URI]; ok {
+			if hover := doc.hover(doc.decode(params.Position), s.globals); hover != nil {
+				if hover.Range != nil {
+					r := doc.encodeRange(*hover.Range)
+					hover.Range = &r
+				}
+				return hover, nil
+			}
+		}
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if doc, ok := s.docs[params.TextDocument.URI]; ok {
+			if r, ok := doc.definition(doc.decode(params.Position)); ok {
+				return Location{URI: params.TextDocument.URI, Range: doc.encodeRange(r)}, nil
+			}
+		}
+		return nil, nil
+	case "textDocument/documentSymbol":
+		var params DocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if doc, ok := s.docs[params.TextDocument.URI]; ok {
+			symbols := doc.symbols()
+			for i := range symbols {
+				symbols[i].Range = doc.encodeRange(symbols[i].Range)
+				symbols[i].SelectionRange = doc.encodeRange(symbols[i].SelectionRange)
+			}
+			return symbols, nil
+		}
+		return []DocumentSymbol{}, nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.completion(s.docs[params.TextDocument.URI]), nil
+	case "textDocument/formatting":
+		var params DocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		edits, err := doc.format()
+		if err != nil {
+			return nil, &responseError{Code: codeRequestFailed, Message: err.Error()}
+		}
+		for i := range edits {
+			edits[i].Range = doc.encodeRange(edits[i].Range)
+		}
+		return edits, nil
+	}
+	if req.ID == nil {
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// update reanalyzes a document after it changed and publishes its
+// diagnostics.
+func (s *Server) update(uri string, text string) {
+	doc := analyze(text, s.globals)
+	doc.utf16 = !s.utf8
+	if old, ok := s.docs[uri]; ok && doc.program == nil {
+		doc.lastProgram = old.lastProgram
+	}
+	s.docs[uri] = doc
+	diagnostics := make([]Diagnostic, len(doc.diagnostics))
+	for i, d := range doc.diagnostics {
+		d.Range = doc.encodeRange(d.Range)
+		diagnostics[i] = d
+	}
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
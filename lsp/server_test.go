@@ -0,0 +1,301 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/framing"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+const uri = "file:///test.mk"
+
+// client talks to a server running in another goroutine, as an editor would.
+type client struct {
+	t        *testing.T
+	w        io.WriteCloser
+	messages chan map[string]json.RawMessage
+	done     chan error
+	nextID   int
+}
+
+func newClient(t *testing.T) *client {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, w: inW, messages: make(chan map[string]json.RawMessage, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- server.Serve(inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		reader := bufio.NewReader(outR)
+		for {
+			data, err := framing.Read(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(data, &msg); err == nil {
+				c.messages <- msg
+			}
+		}
+	}()
+	return c
+}
+
+func (c *client) send(msg interface{}) {
+	assert.NoError(c.t, framing.Write(c.w, msg))
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// call sends a request and decodes the result of its response into result.
+func (c *client) call(method string, params interface{}, result interface{}) {
+	c.nextID++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	msg := c.next()
+	assert.Equal(c.t, fmt.Sprint(c.nextID), string(msg["id"]))
+	assert.Nil(c.t, msg["error"], string(msg["error"]))
+	assert.NoError(c.t, json.Unmarshal(msg["result"], result))
+}
+
+func (c *client) next() map[string]json.RawMessage {
+	msg, ok := <-c.messages
+	assert.True(c.t, ok, "server closed the connection")
+	return msg
+}
+
+// open opens a document and returns the diagnostics published for it.
+func (c *client) open(text string) []Diagnostic {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Text: text}})
+	return c.diagnostics()
+}
+
+func (c *client) diagnostics() []Diagnostic {
+	msg := c.next()
+	assert.Equal(c.t, `"textDocument/publishDiagnostics"`, string(msg["method"]))
+	var params PublishDiagnosticsParams
+	assert.NoError(c.t, json.Unmarshal(msg["params"], &params))
+	assert.Equal(c.t, uri, params.URI)
+	return params.Diagnostics
+}
+
+func (c *client) close() {
+	var result interface{}
+	c.call("shutdown", nil, &result)
+	c.notify("exit", nil)
+	assert.NoError(c.t, <-c.done)
+}
+
+func at(line int, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: character}}
+}
+
+func span(line int, start int, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func TestServer_Initialize(t *testing.T) {
+	c := newClient(t)
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{}, &result)
+	assert.Equal(t, true, result.Capabilities["hoverProvider"])
+	assert.Equal(t, true, result.Capabilities["definitionProvider"])
+	assert.Equal(t, float64(1), result.Capabilities["textDocumentSync"])
+	c.close()
+}
+
+func TestServer_PositionEncoding(t *testing.T) {
+	text := "let s = \"😀é\"; let u = s + t;\n"
+	ranges := func(diagnostics []Diagnostic) map[string]Range {
+		ranges := make(map[string]Range)
+		for _, d := range diagnostics {
+			ranges[d.Message] = d.Range
+		}
+		return ranges
+	}
+	hover := func(c *client, character int) string {
+		var result *Hover
+		c.call("textDocument/hover", at(0, character), &result)
+		if result == nil || result.Range == nil {
+			return ""
+		}
+		return fmt.Sprint(*result.Range)
+	}
+
+	// UTF-16 by default: the emoji takes two code units and é one.
+	c := newClient(t)
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{}, &result)
+	assert.Equal(t, "utf-16", result.Capabilities["positionEncoding"])
+	assert.Equal(t, map[string]Range{"unused variable u": span(0, 19, 20), "undefined: t": span(0, 27, 28)}, ranges(c.open(text)))
+	assert.Equal(t, fmt.Sprint(span(0, 23, 24)), hover(c, 23))
+	c.close()
+
+	c = newClient(t)
+	c.call("initialize", map[string]interface{}{
+		"capabilities": map[string]interface{}{"general": map[string]interface{}{"positionEncodings": []string{"utf-8", "utf-16"}}},
+	}, &result)
+	assert.Equal(t, "utf-8", result.Capabilities["positionEncoding"])
+	assert.Equal(t, map[string]Range{"unused variable u": span(0, 22, 23), "undefined: t": span(0, 30, 31)}, ranges(c.open(text)))
+	assert.Equal(t, fmt.Sprint(span(0, 26, 27)), hover(c, 26))
+	c.close()
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	c := newClient(t)
+	assert.Empty(t, c.open("let x = 1;\nx;\n"))
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   TextDocumentIdentifier{URI: uri},
+		"contentChanges": []map[string]string{{"text": "let x = 1;\nlet y: int = \"a\";\ny + z;\n"}},
+	})
+	assert.Equal(t, []Diagnostic{
+		{Range: span(0, 4, 5), Severity: SeverityWarning, Code: "unused", Source: "lint", Message: "unused variable x"},
+		{Range: span(2, 4, 5), Severity: SeverityWarning, Code: "undefined", Source: "lint", Message: "undefined: z"},
+		{Range: span(1, 13, 14), Severity: SeverityError, Source: "typecheck", Message: "cannot use string as int in declaration of y"},
+	}, c.diagnostics())
+
+	diagnostics := c.open("let x = ;\n")
+	if assert.Len(t, diagnostics, 1) {
+		assert.Equal(t, "parser", diagnostics[0].Source)
+		assert.Equal(t, SeverityError, diagnostics[0].Severity)
+		assert.Equal(t, span(0, 8, 9), diagnostics[0].Range)
+	}
+
+	c.notify("textDocument/didClose", DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	assert.Empty(t, c.diagnostics())
+	c.close()
+}
+
+func TestServer_Hover(t *testing.T) {
+	c := newClient(t)
+	c.open(`import "std/math.mk" as m;
+let add = fn(a: int, b): int { return a + b; };
+let total = add(1, len([m]));
+let [first, ..._rest] = [total];
+map([first], fn(x) { x; });
+`)
+	hover := func(line int, character int) string {
+		var result *Hover
+		c.call("textDocument/hover", at(line, character), &result)
+		if result == nil {
+			return ""
+		}
+		return result.Contents.Value
+	}
+	block := func(text string) string {
+		return "```monkey\n" + text + "\n```"
+	}
+	assert.Equal(t, block("let add = fn(a: int, b): int { ... }"), hover(2, 13))
+	assert.Equal(t, block("parameter a: int"), hover(1, 38))
+	assert.Equal(t, block(`import "std/math.mk" as m`), hover(2, 24))
+	assert.Equal(t, block("builtin len: fn(any): int"), hover(2, 20))
+	assert.Equal(t, block("let total = add(1, len([m]))"), hover(3, 26))
+	assert.Equal(t, block("let first (destructured from [total])"), hover(4, 5))
+	assert.Equal(t, block("prelude map"), hover(4, 0))
+	assert.Equal(t, "", hover(1, 0))
+	c.close()
+}
+
+func TestServer_Definition(t *testing.T) {
+	c := newClient(t)
+	c.open("let x = 1;\nlet f = fn(x) {\n\treturn x;\n};\nf(x);\n")
+	definition := func(line int, character int) *Location {
+		var result *Location
+		c.call("textDocument/definition", at(line, character), &result)
+		return result
+	}
+	assert.Equal(t, &Location{URI: uri, Range: span(1, 11, 12)}, definition(2, 8))
+	assert.Equal(t, &Location{URI: uri, Range: span(0, 4, 5)}, definition(4, 2))
+	assert.Equal(t, &Location{URI: uri, Range: span(1, 4, 5)}, definition(4, 0))
+	assert.Nil(t, definition(3, 0))
+	c.close()
+}
+
+func TestServer_Symbols(t *testing.T) {
+	c := newClient(t)
+	c.open("import \"m.mk\" as m;\nlet f = fn() { let inner = 1; inner; };\nexport let [a, b] = [1, 2];\n")
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols)
+	var names []string
+	var kinds []int
+	for _, symbol := range symbols {
+		names = append(names, symbol.Name)
+		kinds = append(kinds, symbol.Kind)
+	}
+	assert.Equal(t, []string{"m", "f", "a", "b"}, names)
+	assert.Equal(t, []int{SymbolKindModule, SymbolKindFunction, SymbolKindVariable, SymbolKindVariable}, kinds)
+	if assert.Len(t, symbols, 4) {
+		assert.Equal(t, span(1, 4, 5), symbols[1].Range)
+	}
+	c.close()
+}
+
+func TestServer_Completion(t *testing.T) {
+	c := newClient(t)
+	c.open("let total = 1;\nlet f = fn(count) { count; };\n")
+	complete := func() map[string]CompletionItem {
+		var items []CompletionItem
+		c.call("textDocument/completion", at(1, 0), &items)
+		byLabel := make(map[string]CompletionItem)
+		for _, item := range items {
+			byLabel[item.Label] = item
+		}
+		return byLabel
+	}
+	items := complete()
+	assert.Equal(t, CompletionItem{Label: "total", Kind: CompletionKindVariable}, items["total"])
+	assert.Equal(t, CompletionItem{Label: "f", Kind: CompletionKindFunction}, items["f"])
+	assert.Equal(t, CompletionItem{Label: "count", Kind: CompletionKindVariable}, items["count"])
+	assert.Equal(t, CompletionItem{Label: "len", Kind: CompletionKindFunction, Detail: "fn(any): int"}, items["len"])
+	assert.Equal(t, CompletionItem{Label: "map", Kind: CompletionKindVariable, Detail: "prelude"}, items["map"])
+	assert.Equal(t, CompletionItem{Label: "while", Kind: CompletionKindKeyword}, items["while"])
+
+	// Names from the last version that parsed are offered while the
+	// document is broken.
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   TextDocumentIdentifier{URI: uri},
+		"contentChanges": []map[string]string{{"text": "let total = 1;\nlet f = fn(count) { count; };\nto"}},
+	})
+	c.diagnostics()
+	assert.Contains(t, complete(), "total")
+	c.close()
+}
+
+func TestServer_Formatting(t *testing.T) {
+	c := newClient(t)
+	c.open("let   x=1;\nx;")
+	params := DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+	var edits []TextEdit
+	c.call("textDocument/formatting", params, &edits)
+	assert.Equal(t, []TextEdit{{Range: Range{End: Position{Line: 1, Character: 2}}, NewText: "let x = 1;\nx;\n"}}, edits)
+
+	c.open("let x = 1;\nx;\n")
+	c.call("textDocument/formatting", params, &edits)
+	assert.Empty(t, edits)
+	c.close()
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	c := newClient(t)
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "workspace/symbol", "params": map[string]interface{}{}})
+	msg := c.next()
+	var err responseError
+	assert.NoError(t, json.Unmarshal(msg["error"], &err))
+	assert.Equal(t, codeMethodNotFound, err.Code)
+	c.close()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/coverage"
+	"io"
+	"os"
+)
+
+// runCover reports the coverage profiles written by monkey run -cover,
+// merged into one.
+func runCover(args []string) int {
+	flags := flag.NewFlagSet("cover", flag.ContinueOnError)
+	htmlFile := flags.String("html", "", "write an HTML report with the highlighted source to `file`")
+	lcovFile := flags.String("lcov", "", "write an LCOV tracefile to `file`")
+	outFile := flags.String("o", "", "write the merged profile to `file`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey cover [-html file] [-lcov file] [-o file] profile ...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	profile := &coverage.Profile{}
+	for _, file := range flags.Args() {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		p, err := coverage.ReadProfile(f)
+		f.Close()
+		if err == nil {
+			err = profile.Merge(p)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return 1
+		}
+	}
+
+	outputs := []struct {
+		file  string
+		write func(w io.Writer) error
+	}{
+		{*htmlFile, profile.WriteHTML},
+		{*lcovFile, profile.WriteLCOV},
+		{*outFile, profile.Write},
+	}
+	for _, output := range outputs {
+		if output.file == "" {
+			continue
+		}
+		if err := writeFile(output.file, output.write); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if err := profile.WriteText(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func writeFile(file string, write func(w io.Writer) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/dap"
+	"os"
+)
+
+// runDAP serves the Debug Adapter Protocol over standard input and output.
+func runDAP(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey dap")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := dap.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/debug"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const debugHelp = `commands:
+  break LINE (b)     set a breakpoint
+  clear LINE         remove a breakpoint
+  continue (c)       run to the next breakpoint
+  step (s)           run to the next statement, entering calls
+  next (n)           run to the next statement in this function
+  out (o)            run until the current function returns
+  stack (bt)         show the call stack
+  locals [FRAME]     show the variables of a frame, 0 being the innermost
+  globals            show the top-level variables
+  print EXPR (p)     evaluate an expression in the innermost frame
+  quit (q)           end the program`
+
+// runDebug runs a Monkey program under an interactive debugger, stopped
+// before its first statement.
+func runDebug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey debug file")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	globals, loader, err := stdlib.NewGlobals(eval.FSResolver{FS: os.DirFS(".")})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading prelude: %s\n", err)
+		return 1
+	}
+	session, err := debug.NewSession(string(source), file, object.NewNestedEnv(globals), loader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return 1
+	}
+	session.SetStdio(os.Stdin, os.Stdout, os.Stderr)
+	return debugLoop(session, file, string(source), os.Stdin, os.Stdout)
+}
+
+// debugLoop starts session and reads commands from in while it is stopped.
+func debugLoop(session *debug.Session, file string, source string, in io.Reader, out io.Writer) int {
+	lines := strings.Split(source, "\n")
+	breakpoints := make(map[int]bool)
+	scanner := bufio.NewScanner(in)
+	session.Start(true)
+	for {
+		event := session.Wait()
+		if event.Reason == debug.StopExited {
+			if event.Err != nil {
+				fmt.Fprintf(out, "program failed at %s:%d: %s\n", file, event.Line, event.Err)
+				return 1
+			}
+			fmt.Fprintf(out, "program exited: %s\n", event.Result)
+			return 0
+		}
+		frame := session.Stack()[0]
+		fmt.Fprintf(out, "stopped at %s:%d in %s (%s)\n", file, event.Line, frame.Name, event.Reason)
+		if event.Line <= len(lines) {
+			fmt.Fprintf(out, "%d\t%s\n", event.Line, lines[event.Line-1])
+		}
+
+	commands:
+		for {
+			fmt.Fprint(out, "(debug) ")
+			if !scanner.Scan() {
+				session.Terminate()
+				session.Wait()
+				fmt.Fprintln(out)
+				return 0
+			}
+			command, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+			arg = strings.TrimSpace(arg)
+			switch command {
+			case "":
+			case "b", "break", "clear":
+				line, err := strconv.Atoi(arg)
+				if err != nil {
+					fmt.Fprintf(out, "bad line %q\n", arg)
+					continue
+				}
+				if command == "clear" {
+					delete(breakpoints, line)
+				} else {
+					breakpoints[line] = true
+				}
+				var requested []int
+				for line := range breakpoints {
+					requested = append(requested, line)
+				}
+				sort.Ints(requested)
+				actual := session.SetBreakpoints(requested)
+				if command != "clear" {
+					i := sort.SearchInts(requested, line)
+					if actual[i] == 0 {
+						fmt.Fprintf(out, "no statement at or after line %d\n", line)
+						delete(breakpoints, line)
+					} else {
+						fmt.Fprintf(out, "breakpoint at %s:%d\n", file, actual[i])
+					}
+				}
+			case "c", "continue":
+				session.Continue()
+				break commands
+			case "s", "step":
+				session.StepIn()
+				break commands
+			case "n", "next":
+				session.StepOver()
+				break commands
+			case "o", "out":
+				session.StepOut()
+				break commands
+			case "bt", "stack":
+				for i, frame := range session.Stack() {
+					fmt.Fprintf(out, "#%d %s at %s:%d\n", i, frame.Name, file, frame.Line)
+				}
+			case "locals":
+				stack := session.Stack()
+				i, err := strconv.Atoi(arg)
+				if arg == "" {
+					i, err = 0, nil
+				}
+				if err != nil || i < 0 || i >= len(stack) {
+					fmt.Fprintf(out, "no frame %s\n", arg)
+					continue
+				}
+				printVariables(out, debug.Locals(stack[i]))
+			case "globals":
+				printVariables(out, session.Globals())
+			case "p", "print":
+				val, err := session.Evaluate(arg, 0)
+				if err != nil {
+					fmt.Fprintf(out, "error: %s\n", err)
+				} else {
+					fmt.Fprintln(out, val)
+				}
+			case "q", "quit":
+				session.Terminate()
+				session.Wait()
+				return 0
+			case "h", "help":
+				fmt.Fprintln(out, debugHelp)
+			default:
+				fmt.Fprintf(out, "unknown command %s; try help\n", command)
+			}
+		}
+	}
+}
+
+func printVariables(out io.Writer, vars []debug.Variable) {
+	for _, v := range vars {
+		fmt.Fprintf(out, "%s = %s\n", v.Name, v.Value)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"github.com/carsonip/monkey-interpreter/debug"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const debugSource = `let add = fn(a, b) {
+	let sum = a + b;
+	return sum;
+};
+let total = add(1, 2);
+total * 2;
+`
+
+func runDebugLoop(t *testing.T, source string, commands string) (string, int) {
+	globals, loader, err := stdlib.NewGlobals(eval.MapResolver{})
+	assert.NoError(t, err)
+	session, err := debug.NewSession(source, "main.mk", object.NewNestedEnv(globals), loader)
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	status := debugLoop(session, "main.mk", source, strings.NewReader(commands), &out)
+	return out.String(), status
+}
+
+func TestDebugLoop(t *testing.T) {
+	out, status := runDebugLoop(t, debugSource, "b 3\nc\nbt\nlocals\nlocals 1\np sum + 1\nglobals\nc\n")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, `stopped at main.mk:1 in <main> (entry)
+1	let add = fn(a, b) {
+(debug) breakpoint at main.mk:3
+(debug) stopped at main.mk:3 in add (breakpoint)
+3		return sum;
+(debug) #0 add at main.mk:3
+#1 <main> at main.mk:5
+(debug) a = 1
+b = 2
+sum = 3
+(debug) add = fn
+(debug) 4
+(debug) add = fn
+(debug) program exited: 6
+`, out)
+}
+
+func TestDebugLoop_Stepping(t *testing.T) {
+	out, _ := runDebugLoop(t, debugSource, "n\nn\ns\nn\no\nq\n")
+	assert.Equal(t, []string{
+		"stopped at main.mk:1 in <main> (entry)",
+		"(debug) stopped at main.mk:5 in <main> (step)",
+		"(debug) stopped at main.mk:6 in <main> (step)",
+	}, stops(out)[:3])
+
+	out, _ = runDebugLoop(t, debugSource, "n\ns\nn\no\nq\n")
+	assert.Equal(t, []string{
+		"stopped at main.mk:1 in <main> (entry)",
+		"(debug) stopped at main.mk:5 in <main> (step)",
+		"(debug) stopped at main.mk:2 in add (step)",
+		"(debug) stopped at main.mk:3 in add (step)",
+		"(debug) stopped at main.mk:6 in <main> (step)",
+	}, stops(out))
+}
+
+func TestDebugLoop_Error(t *testing.T) {
+	out, status := runDebugLoop(t, "let f = fn() {\n\treturn 1 / 0;\n};\nf();\n", "c\n")
+	assert.Equal(t, 1, status)
+	assert.Contains(t, out, "program failed at main.mk:2: division by zero\n")
+}
+
+func stops(out string) []string {
+	var stops []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "stopped at") {
+			stops = append(stops, line)
+		}
+	}
+	return stops
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// unifiedDiff returns the line differences between a and b in unified diff
+// format, or an empty string if they are equal.
+func unifiedDiff(name string, a string, b string) string {
+	return diff(name+".orig", name, a, b)
+}
+
+// diff is unifiedDiff with the names of a and b given separately.
+func diff(aName string, bName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// aLines[i:] and bLines[j:].
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+		a, b int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(aLines) || j < len(bLines) {
+		switch {
+		case i < len(aLines) && j < len(bLines) && aLines[i] == bLines[j]:
+			edits = append(edits, edit{' ', aLines[i], i, j})
+			i++
+			j++
+		case i < len(aLines) && (j == len(bLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', aLines[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', bLines[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// Grow the hunk until the changes are separated by more than
+		// twice the context.
+		hunkStart := start - diffContext
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		end := start
+		for k := start; k < len(edits) && k <= end+2*diffContext; k++ {
+			if edits[k].op != ' ' {
+				end = k
+			}
+		}
+		hunkEnd := end + diffContext + 1
+		if hunkEnd > len(edits) {
+			hunkEnd = len(edits)
+		}
+		aCount, bCount := 0, 0
+		for _, e := range edits[hunkStart:hunkEnd] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(edits[hunkStart].a, aCount), hunkRange(edits[hunkStart].b, bCount))
+		for _, e := range edits[hunkStart:hunkEnd] {
+			fmt.Fprintf(&sb, "%c%s\n", e.op, e.line)
+		}
+		start = hunkEnd
+	}
+	return sb.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Equal(t, "", unifiedDiff("a.mk", "x;\n", "x;\n"))
+	assert.Equal(t, "--- a.mk.orig\n+++ a.mk\n@@ -1,3 +1,3 @@\n a;\n-b ;\n+b;\n c;\n",
+		unifiedDiff("a.mk", "a;\nb ;\nc;\n", "a;\nb;\nc;\n"))
+	assert.Equal(t, "--- a.mk.orig\n+++ a.mk\n@@ -0,0 +1 @@\n+a;\n",
+		unifiedDiff("a.mk", "", "a;\n"))
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+	assert.Equal(t, "--- a.mk.orig\n+++ a.mk\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		unifiedDiff("a.mk", a, b))
+}
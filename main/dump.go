@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/token"
+	"io"
+	"os"
+)
+
+// runDump prints the tokens or the syntax tree of each file, or of standard
+// input if no files are given, as indented JSON for tools to consume.
+func runDump(files []string, tokens bool, astFormat string) int {
+	if astFormat != "" && astFormat != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported AST format %s\n", astFormat)
+		return 2
+	}
+	dump := dumpAST
+	if tokens {
+		dump = dumpTokens
+	}
+	if len(files) == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := dump(os.Stdout, string(source)); err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+	status := 0
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err == nil {
+			err = dump(os.Stdout, string(source))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// dumpTokens writes the tokens of source up to and including EOF.
+func dumpTokens(w io.Writer, source string) error {
+	lex := token.NewLexer(source)
+	var tokens []token.Token
+	for {
+		tok := lex.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.TOKEN_EOF {
+			break
+		}
+	}
+	return writeJSON(w, tokens)
+}
+
+func dumpAST(w io.Writer, source string) error {
+	program, err := parse(source)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, program)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDumpTokens(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, dumpTokens(&buf, "x;"))
+	assert.Equal(t, `[
+  {
+    "type": "IDENTIFIER",
+    "literal": "x",
+    "line": 1,
+    "column": 1
+  },
+  {
+    "type": "SEMICOLON",
+    "literal": ";",
+    "line": 1,
+    "column": 2
+  },
+  {
+    "type": "EOF",
+    "literal": "\u0000",
+    "line": 1,
+    "column": 3
+  }
+]
+`, buf.String())
+}
+
+func TestDumpAST(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, dumpAST(&buf, "x;"))
+	assert.Equal(t, `{
+  "kind": "Program",
+  "statements": [
+    {
+      "kind": "Identifier",
+      "token": {
+        "type": "IDENTIFIER",
+        "literal": "x",
+        "line": 1,
+        "column": 1
+      }
+    }
+  ]
+}
+`, buf.String())
+	assert.Error(t, dumpAST(&buf, "let = 1;"))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/printer"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runFmt formats Monkey source files, or standard input if no paths are
+// given. Directories are searched for .mk files.
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write result to source files instead of standard output")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey fmt [-w] [-d] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		result, err := printer.Format(string(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %s\n", err)
+			return 1
+		}
+		if *diff {
+			fmt.Print(unifiedDiff("<stdin>", string(source), result))
+		} else {
+			fmt.Print(result)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || file != path && !strings.HasSuffix(file, ".mk") {
+				return nil
+			}
+			return fmtFile(file, *write, *diff)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func fmtFile(file string, write bool, diff bool) error {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	result, err := printer.Format(string(source))
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	if diff {
+		fmt.Print(unifiedDiff(file, string(source), result))
+	}
+	if write {
+		if result != string(source) {
+			return os.WriteFile(file, []byte(result), 0644)
+		}
+	} else if !diff {
+		fmt.Print(result)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/lint"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileDiagnostic struct {
+	File string `json:"file"`
+	lint.Diagnostic
+}
+
+// runLint checks Monkey source files, or standard input if no paths are
+// given, and exits with status 1 if anything is reported.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "report diagnostics as a JSON array")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey lint [-json] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	globals, err := lint.PreludeGlobals()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	status := 0
+	diagnostics := []fileDiagnostic{}
+	lintSource := func(file string, source string) {
+		program, err := parse(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			status = 1
+			return
+		}
+		for _, d := range lint.Lint(program, globals) {
+			diagnostics = append(diagnostics, fileDiagnostic{File: file, Diagnostic: d})
+		}
+	}
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		lintSource("<stdin>", string(source))
+	}
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || file != path && !strings.HasSuffix(file, ".mk") {
+				return nil
+			}
+			source, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			lintSource(file, string(source))
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+
+	if *jsonOutput {
+		if err := writeJSON(os.Stdout, diagnostics); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Printf("%s:%s\n", d.File, d.Diagnostic)
+		}
+	}
+	if len(diagnostics) > 0 {
+		status = 1
+	}
+	return status
+}
+
+func parse(source string) (program *ast.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	lex := token.NewLexer(source)
+	p := parser.NewParser(&lex)
+	return p.ParseProgram(), nil
+}
@@ -1,11 +1,50 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"github.com/carsonip/monkey-interpreter/repl"
+	"io"
+	"log"
 	"os"
 )
 
+var commands = map[string]func(args []string) int{
+	"cover":     runCover,
+	"dap":       runDAP,
+	"debug":     runDebug,
+	"fmt":       runFmt,
+	"lint":      runLint,
+	"lsp":       runLSP,
+	"run":       runRun,
+	"test":      runTest,
+	"typecheck": runTypecheck,
+}
+
 func main() {
+	dumpTokens := flag.Bool("dump-tokens", false, "print the tokens of the given files as JSON")
+	dumpAST := flag.String("dump-ast", "", "print the syntax tree of the given files in `format` (json)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: monkey [--dump-tokens | --dump-ast=json] [file ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       monkey command [arguments]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	// Subcommands and dumps report parse errors themselves rather than
+	// through the parser's log output.
+	if *dumpTokens || *dumpAST != "" {
+		log.SetOutput(io.Discard)
+		os.Exit(runDump(flag.Args(), *dumpTokens, *dumpAST))
+	}
+	if flag.NArg() > 0 {
+		log.SetOutput(io.Discard)
+		command, ok := commands[flag.Arg(0)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %s\n", flag.Arg(0))
+			os.Exit(2)
+		}
+		os.Exit(command(flag.Args()[1:]))
+	}
 	r := repl.Repl{}
 	r.Start(os.Stdin, os.Stdout)
 }
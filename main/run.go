@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/coverage"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/profile"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"os"
+)
+
+// runRun runs a Monkey program, optionally tracing its calls or profiling
+// it, and exits with status 1 if it fails.
+func runRun(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	trace := flags.Bool("trace", false, "log every call with its arguments and result to standard error")
+	profileFile := flags.String("profile", "", "write a pprof profile of the program's functions to `file`")
+	report := flags.Bool("profile-report", false, "print the calls and times of the program's functions to standard error")
+	coverFile := flags.String("cover", "", "add the program's statement and branch coverage to the coverage profile `file`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey run [-trace] [-profile file] [-profile-report] [-cover file] file")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file := flags.Arg(0)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var profiler *profile.Profiler
+	if *profileFile != "" || *report {
+		profiler = profile.NewProfiler()
+	}
+	var collector *coverage.Collector
+	if *coverFile != "" {
+		collector = coverage.NewCollector()
+	}
+	status := 0
+	stdio := object.NewStdio(os.Stdin, os.Stdout, os.Stderr)
+	err = runFile(file, string(source), stdio, func(ev *eval.Evaluator, program *ast.Program) {
+		if *trace {
+			ev.SetTrace(os.Stderr)
+		}
+		var hooks eval.MultiHooks
+		if profiler != nil {
+			hooks = append(hooks, profiler)
+		}
+		if collector != nil {
+			collector.Add(file, string(source), program)
+			hooks = append(hooks, collector)
+		}
+		if len(hooks) > 0 {
+			ev.SetHooks(hooks)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		status = 1
+	}
+	if collector != nil {
+		if err := addCoverage(*coverFile, collector.Profile()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if profiler == nil {
+		return status
+	}
+	profiler.Stop()
+	if *report {
+		profiler.WriteReport(os.Stderr)
+	}
+	if *profileFile != "" {
+		if err := writeFile(*profileFile, profiler.WritePprof); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return status
+}
+
+// addCoverage merges profile into the coverage profile saved in file,
+// creating it if it does not exist.
+func addCoverage(file string, profile *coverage.Profile) error {
+	if data, err := os.ReadFile(file); err == nil {
+		saved, err := coverage.ReadProfile(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if err := saved.Merge(profile); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		profile = saved
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := profile.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
+
+// runFile runs source, the program in file, in a scope nested in one holding
+// the prelude, with imports resolved relative to the working directory and
+// input and output going to stdio. configure is called with the evaluator
+// and the parsed program before the program starts. Errors are prefixed with
+// file and, if the program failed, the line of the top-level statement that
+// failed.
+func runFile(file string, source string, stdio *object.Stdio, configure func(ev *eval.Evaluator, program *ast.Program)) error {
+	program, err := parse(source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	globals, loader, err := stdlib.NewGlobals(eval.FSResolver{FS: os.DirFS(".")})
+	if err != nil {
+		return fmt.Errorf("%s: error loading prelude: %w", file, err)
+	}
+	env := object.NewNestedEnv(globals)
+	ev := eval.NewEvaluator(nil, env)
+	ev.SetModuleLoader(loader, file)
+	ev.SetStdio(stdio.In, stdio.Out, stdio.Err)
+	configure(&ev, program)
+	for _, node := range program.Statements {
+		if err, ok := ev.Eval(node, env).(object.Error); ok {
+			return fmt.Errorf("%s:%d: %w", file, ast.Start(node).Line, err)
+		}
+	}
+	return nil
+}
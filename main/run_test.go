@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/coverage"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFile(t *testing.T) {
+	var trace, out strings.Builder
+	stdio := object.NewStdio(strings.NewReader(""), &out, &out)
+	err := runFile("main.mk", "let double = fn(x) { return x * 2; };\nprintln(double(len(\"ab\")));\n", stdio, func(ev *eval.Evaluator, program *ast.Program) {
+		ev.SetTrace(&trace)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `-> len("ab") at main.mk:2:16
+<- len = 2
+-> double(2) at main.mk:2:9
+<- double = 4
+-> println(4) at main.mk:2:1
+<- println = null
+`, trace.String())
+	assert.Equal(t, "4\n", out.String())
+
+	err = runFile("main.mk", "let x = 1;\nx / 0;\n", stdio, func(ev *eval.Evaluator, program *ast.Program) {})
+	assert.EqualError(t, err, "main.mk:2: division by zero")
+	err = runFile("main.mk", "let = ;", stdio, func(ev *eval.Evaluator, program *ast.Program) {})
+	assert.Error(t, err)
+}
+
+func TestAddCoverage(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cover.json")
+	source := "let x = 1;\n"
+	program, err := parse(source)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		collector := coverage.NewCollector()
+		collector.Add("main.mk", source, program)
+		collector.Before(program.Statements[0], nil)
+		assert.NoError(t, addCoverage(file, collector.Profile()))
+	}
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	profile, err := coverage.ReadProfile(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, profile.Files[0].Statements[0].Count)
+}
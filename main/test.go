@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/stdlib"
+	"github.com/carsonip/monkey-interpreter/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// testResult is the outcome of one test function.
+type testResult struct {
+	File string
+	Name string
+	// Err is why the test failed, nil if it passed. Line and Column locate
+	// the statement of the test file that was running when it failed.
+	Err    *object.Error
+	Line   int
+	Column int
+}
+
+// runTest runs the test functions of *_test.mk files, those top-level
+// functions whose name starts with test, and exits with status 1 if any
+// fail.
+func runTest(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	run := flags.String("run", "", "only run tests whose name matches `regexp`")
+	verbose := flags.Bool("v", false, "list passing tests too")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey test [-run regexp] [-v] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	match, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -run pattern: %s\n", err)
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	stdio := object.NewStdio(os.Stdin, os.Stdout, os.Stderr)
+	status := 0
+	var results []testResult
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || file != path && !strings.HasSuffix(file, "_test.mk") {
+				return nil
+			}
+			source, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			fileResults, err := testFile(file, string(source), match, stdio)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+				status = 1
+				return nil
+			}
+			for _, result := range fileResults {
+				writeTestResult(os.Stdout, result, *verbose)
+			}
+			results = append(results, fileResults...)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	switch {
+	case failed > 0:
+		fmt.Printf("FAIL\t%d passed, %d failed\n", len(results)-failed, failed)
+		status = 1
+	case len(results) == 0:
+		fmt.Println("no tests to run")
+	default:
+		fmt.Printf("PASS\t%d passed\n", len(results))
+	}
+	return status
+}
+
+// testFile runs the tests in source, the contents of file, whose names match
+// match. Each runs in a fresh environment, in which the rest of the file's
+// top-level statements have run first, with input and output going to stdio.
+func testFile(file string, source string, match *regexp.Regexp, stdio *object.Stdio) ([]testResult, error) {
+	program, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	var results []testResult
+	for _, node := range program.Statements {
+		if export, ok := node.(*ast.ExportStatement); ok {
+			node = export.Statement
+		}
+		let, ok := node.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		name, ok := let.Name.(*ast.Identifier)
+		if _, isFunction := let.Value.(*ast.Function); !ok || !isFunction {
+			continue
+		}
+		if strings.HasPrefix(name.TokenLiteral(), "test") && match.MatchString(name.TokenLiteral()) {
+			results = append(results, runTestFunction(file, program, name, stdio))
+		}
+	}
+	return results, nil
+}
+
+func runTestFunction(file string, program *ast.Program, test *ast.Identifier, stdio *object.Stdio) testResult {
+	result := testResult{File: file, Name: test.TokenLiteral(), Line: test.Token.Line, Column: test.Token.Column}
+	globals, loader, err := stdlib.NewGlobals(eval.FSResolver{FS: os.DirFS(".")})
+	if err != nil {
+		failure := object.NewError("error loading prelude: " + err.Error())
+		result.Err = &failure
+		return result
+	}
+	env := object.NewNestedEnv(globals)
+	ev := eval.NewEvaluator(nil, env)
+	ev.SetModuleLoader(loader, file)
+	ev.SetStdio(stdio.In, stdio.Out, stdio.Err)
+	locator := newFailureLocator(program)
+	ev.SetHooks(locator)
+	nodes := append(append([]ast.Node{}, program.Statements...), &ast.FunctionCall{FunctionExpr: test})
+	for _, node := range nodes {
+		if err, ok := ev.Eval(node, env).(object.Error); ok {
+			result.Err = &err
+			if start, ok := locator.location(); ok {
+				result.Line, result.Column = start.Line, start.Column
+			}
+			return result
+		}
+	}
+	return testResult{File: file, Name: test.TokenLiteral()}
+}
+
+// writeTestResult reports a failed test with its error and, for a failed
+// assertEqual, a diff of the values compared. Passing tests are only listed
+// if verbose.
+func writeTestResult(w io.Writer, result testResult, verbose bool) {
+	if result.Err == nil {
+		if verbose {
+			fmt.Fprintf(w, "--- PASS: %s\n", result.Name)
+		}
+		return
+	}
+	fmt.Fprintf(w, "--- FAIL: %s (%s:%d:%d)\n", result.Name, result.File, result.Line, result.Column)
+	fmt.Fprintf(w, "    %s\n", result.Err.Message)
+	if result.Err.Expected != "" || result.Err.Actual != "" {
+		for _, line := range splitLines(diff("expected", "actual", result.Err.Expected+"\n", result.Err.Actual+"\n")) {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// failureLocator implements eval.Hooks, keeping track of the statement of a
+// program that is running in each call, so that when the program fails it
+// can tell the innermost statement of the program that was running.
+type failureLocator struct {
+	statements map[ast.Node]bool
+	// frames holds the statement running in each call, innermost last, nil
+	// for calls of functions from elsewhere, such as the prelude.
+	frames []ast.Node
+	// failed is the statement running in the innermost call that failed,
+	// until the program carries on.
+	failed ast.Node
+}
+
+func newFailureLocator(program *ast.Program) *failureLocator {
+	l := &failureLocator{statements: make(map[ast.Node]bool), frames: []ast.Node{nil}}
+	add := func(nodes []ast.Node) {
+		for _, node := range nodes {
+			l.statements[node] = true
+		}
+	}
+	add(program.Statements)
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStatement:
+			add(node.Then)
+			add(node.Else)
+		case *ast.WhileStatement:
+			add(node.Body)
+		case *ast.Function:
+			add(node.Body)
+		}
+		return true
+	})
+	return l
+}
+
+func (l *failureLocator) Before(node ast.Node, env *object.Env) {
+	l.failed = nil
+	if l.statements[node] {
+		l.frames[len(l.frames)-1] = node
+	}
+}
+
+func (l *failureLocator) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {
+	l.frames = append(l.frames, nil)
+}
+
+func (l *failureLocator) Return(call *ast.FunctionCall, result object.Object) {
+	statement := l.frames[len(l.frames)-1]
+	l.frames = l.frames[:len(l.frames)-1]
+	if result == nil && l.failed == nil {
+		l.failed = statement
+	}
+}
+
+// location returns the start of the statement that was running when the
+// program failed.
+func (l *failureLocator) location() (start token.Token, ok bool) {
+	if l.failed != nil {
+		return ast.Start(l.failed), true
+	}
+	for i := len(l.frames) - 1; i >= 0; i-- {
+		if l.frames[i] != nil {
+			return ast.Start(l.frames[i]), true
+		}
+	}
+	return token.Token{}, false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testSource = `let count = 0;
+let check = fn(x) {
+	count++;
+	assert(x > 0, "positive");
+};
+
+let testPass = fn() {
+	check(1);
+	println("pass", count);
+	assertEqual(count, 1);
+};
+
+let testIsolated = fn() {
+	assertEqual(count, 0);
+	check(1);
+	assertEqual(map([1, 2], fn(x) { return x * 2; }), [2, 5]);
+};
+
+let testHelper = fn() {
+	assertThrows(fn() { check(0); });
+	check(-1);
+};
+
+let helper = fn() {};
+`
+
+func TestTestFile(t *testing.T) {
+	var out bytes.Buffer
+	stdio := object.NewStdio(strings.NewReader(""), &out, &out)
+	results, err := testFile("a_test.mk", testSource, regexp.MustCompile(""), stdio)
+	assert.NoError(t, err)
+	equal := object.NewError("assertEqual failed: expected [2, 5], got [2, 4]")
+	equal.Expected, equal.Actual = "[2, 5]", "[2, 4]"
+	positive := object.NewError("assertion failed: positive")
+	assert.Equal(t, []testResult{
+		{File: "a_test.mk", Name: "testPass"},
+		{File: "a_test.mk", Name: "testIsolated", Err: &equal, Line: 16, Column: 2},
+		{File: "a_test.mk", Name: "testHelper", Err: &positive, Line: 4, Column: 2},
+	}, results)
+	assert.Equal(t, "pass 1\n", out.String())
+
+	results, err = testFile("a_test.mk", testSource, regexp.MustCompile("Pass|Helper$"), stdio)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "testHelper", results[1].Name)
+	}
+
+	_, err = testFile("a_test.mk", "let = ;", regexp.MustCompile(""), stdio)
+	assert.Error(t, err)
+}
+
+func TestWriteTestResult(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestResult(&buf, testResult{Name: "testPass"}, false)
+	assert.Equal(t, "", buf.String())
+	writeTestResult(&buf, testResult{Name: "testPass"}, true)
+	err := object.NewError("assertEqual failed: string values differ")
+	err.Expected, err.Actual = "a\nb\nc", "a\nB\nc"
+	writeTestResult(&buf, testResult{File: "a_test.mk", Name: "testFail", Err: &err, Line: 3, Column: 2}, true)
+	assert.Equal(t, `--- PASS: testPass
+--- FAIL: testFail (a_test.mk:3:2)
+    assertEqual failed: string values differ
+    --- expected
+    +++ actual
+    @@ -1,3 +1,3 @@
+     a
+    -b
+    +B
+     c
+`, buf.String())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/typecheck"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runTypecheck reports type errors in Monkey source files, or standard input
+// if no paths are given, and exits with status 1 if there are any.
+func runTypecheck(args []string) int {
+	flags := flag.NewFlagSet("typecheck", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey typecheck [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	status := 0
+	checkSource := func(file string, source string) {
+		program, err := parse(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			status = 1
+			return
+		}
+		for _, err := range typecheck.Check(program) {
+			fmt.Printf("%s:%s\n", file, err)
+			status = 1
+		}
+	}
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		checkSource("<stdin>", string(source))
+	}
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || file != path && !strings.HasSuffix(file, ".mk") {
+				return nil
+			}
+			source, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			checkSource(file, string(source))
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
@@ -1,8 +1,21 @@
 package object
 
+import "sort"
+
+// Env is a lexical scope. Every function call, if branch and loop iteration
+// runs in a fresh Env nested in the scope it appears in, and functions keep a
+// reference to the Env they were defined in rather than a copy of it, so
+// closures observe later assignments to captured variables.
+//
+// let always declares a name in the current scope, shadowing any binding of
+// the same name in enclosing scopes, unless it is a constant of the current
+// scope itself, which cannot be declared again. Assignment updates the nearest
+// existing binding and fails if there is none, or if it is a constant.
 type Env struct {
-	parentEnv   *Env
-	env         map[string]Object
+	parentEnv *Env
+	env       map[string]Object
+	// consts holds the names in env declared with const.
+	consts      map[string]bool
 	returnValue Object
 }
 
@@ -36,7 +49,7 @@ func (e *Env) Get(name string) (Object, bool) {
 func (e *Env) MustGet(name string) Object {
 	val, ok := e.Get(name)
 	if !ok {
-		panic("unknown identifier")
+		panic(NewError("unknown identifier"))
 	}
 	return val
 }
@@ -45,16 +58,33 @@ func (e *Env) SetNew(name string, value Object) {
 	e.env[name] = value
 }
 
-func (e *Env) Set(name string, value Object) {
+// SetConst declares a constant in the current scope, like SetNew, which Set
+// then refuses to change.
+func (e *Env) SetConst(name string, value Object) {
+	e.env[name] = value
+	if e.consts == nil {
+		e.consts = make(map[string]bool)
+	}
+	e.consts[name] = true
+}
+
+// IsConst reports whether name is a constant declared in this scope itself.
+func (e *Env) IsConst(name string) bool {
+	return e.consts[name]
+}
+
+func (e *Env) Set(name string, value Object) error {
 	if _, ok := e.env[name]; ok {
+		if e.consts[name] {
+			return NewError("cannot assign to constant")
+		}
 		e.env[name] = value
-		return
+		return nil
 	} else {
 		if e.parentEnv != nil {
-			e.parentEnv.Set(name, value)
-			return
+			return e.parentEnv.Set(name, value)
 		} else {
-			panic("unknown identifier")
+			return NewError("unknown identifier")
 		}
 	}
 }
@@ -76,3 +106,18 @@ func (e *Env) MustReturned() Object {
 	}
 	return nil
 }
+
+// Parent returns the enclosing scope, or nil for an outermost one.
+func (e *Env) Parent() *Env {
+	return e.parentEnv
+}
+
+// Names returns the names bound in this scope itself, sorted.
+func (e *Env) Names() []string {
+	names := make([]string, 0, len(e.env))
+	for name := range e.env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -44,3 +44,38 @@ func TestEnv_Nested(t *testing.T) {
 	assert.Equal(t, NewInteger(3), rootEnv.MustReturned())
 	assert.Equal(t, NewInteger(30), env.MustReturned())
 }
+
+func TestEnv_Set(t *testing.T) {
+	rootEnv := NewEnv()
+	rootEnv.SetNew("foo", NewInteger(1))
+	env := NewNestedEnv(rootEnv)
+	assert.NoError(t, env.Set("foo", NewInteger(2)))
+	assert.Equal(t, NewInteger(2), rootEnv.MustGet("foo"))
+	err := env.Set("bar", NewInteger(3))
+	assert.Equal(t, NewError("unknown identifier"), err)
+	_, ok := rootEnv.Get("bar")
+	assert.False(t, ok)
+}
+
+func TestEnv_SetConst(t *testing.T) {
+	rootEnv := NewEnv()
+	rootEnv.SetConst("foo", NewInteger(1))
+	env := NewNestedEnv(rootEnv)
+	assert.Equal(t, NewError("cannot assign to constant"), env.Set("foo", NewInteger(2)))
+	assert.Equal(t, NewInteger(1), rootEnv.MustGet("foo"))
+	env.SetNew("foo", NewInteger(3))
+	assert.NoError(t, env.Set("foo", NewInteger(4)))
+	assert.True(t, rootEnv.IsConst("foo"))
+	assert.False(t, env.IsConst("foo"))
+}
+
+func TestEnv_Names(t *testing.T) {
+	rootEnv := NewEnv()
+	rootEnv.SetNew("foo", NewInteger(1))
+	env := NewNestedEnv(rootEnv)
+	env.SetNew("bar", NewInteger(2))
+	env.SetNew("baz", NewInteger(3))
+	assert.Equal(t, []string{"bar", "baz"}, env.Names())
+	assert.Equal(t, []string{"foo"}, env.Parent().Names())
+	assert.Nil(t, rootEnv.Parent())
+}
@@ -0,0 +1,67 @@
+package object
+
+import "reflect"
+
+// Equal reports whether a and b are the same value: scalars of the same type
+// and value, arrays and maps with equal elements, and the same function,
+// builtin or module. Values of different types are never equal. Arrays and
+// maps that contain themselves are compared without looping forever.
+func Equal(a Object, b Object) bool {
+	return equal(a, b, make(map[[2]uintptr]bool))
+}
+
+// equal compares a and b, assuming the pairs of arrays and maps in seen,
+// those being compared further up, are equal.
+func equal(a Object, b Object, seen map[[2]uintptr]bool) bool {
+	switch a := a.(type) {
+	case Array:
+		b, ok := b.(Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		pair := [2]uintptr{reflect.ValueOf(a.Elements).Pointer(), reflect.ValueOf(b.Elements).Pointer()}
+		if pair[0] == pair[1] || seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case Map:
+		b, ok := b.(Map)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		pair := [2]uintptr{reflect.ValueOf(a.Elements).Pointer(), reflect.ValueOf(b.Elements).Pointer()}
+		if pair[0] == pair[1] || seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for _, pairs := range a.Elements {
+			for _, kv := range pairs {
+				if value, ok := b.Get(kv.Key); !ok || !equal(kv.Value, value, seen) {
+					return false
+				}
+			}
+		}
+		return true
+	case Function:
+		b, ok := b.(Function)
+		return ok && a.Definition == b.Definition && a.Env == b.Env && a.Definition != nil
+	case BuiltinFunction:
+		b, ok := b.(BuiltinFunction)
+		return ok && reflect.ValueOf(a.Fn).Pointer() == reflect.ValueOf(b.Fn).Pointer() &&
+			reflect.ValueOf(a.CallFn).Pointer() == reflect.ValueOf(b.CallFn).Pointer() &&
+			reflect.ValueOf(a.StdioFn).Pointer() == reflect.ValueOf(b.StdioFn).Pointer()
+	case Module:
+		b, ok := b.(Module)
+		return ok && a.Path == b.Path
+	case BigInt:
+		b, ok := b.(BigInt)
+		return ok && a.Value.Cmp(b.Value) == 0
+	}
+	return a == b
+}
@@ -0,0 +1,39 @@
+package object
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(NewInteger(1), NewInteger(1)))
+	assert.False(t, Equal(NewInteger(1), NewString("1")))
+	assert.True(t, Equal(NULL, NULL))
+	assert.True(t, Equal(
+		NewArray([]Object{NewInteger(1), NewMap([][2]Object{{NewString("k"), NewArray(nil)}})}),
+		NewArray([]Object{NewInteger(1), NewMap([][2]Object{{NewString("k"), NewArray([]Object{})}})}),
+	))
+	assert.False(t, Equal(NewArray([]Object{NewInteger(1)}), NewArray([]Object{NewBoolean(true)})))
+	assert.False(t, Equal(NewMap(nil), NewArray(nil)))
+
+	a := NewArray([]Object{NewInteger(1), NULL})
+	a.Elements[1] = a
+	b := NewArray([]Object{NewInteger(1), NULL})
+	b.Elements[1] = b
+	assert.True(t, Equal(a, b))
+	c := NewArray([]Object{NewInteger(2), NULL})
+	c.Elements[1] = c
+	assert.False(t, Equal(a, c))
+}
+
+func TestNewInt(t *testing.T) {
+	assert.Equal(t, NewInteger(-5), NewInt(big.NewInt(-5)))
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	assert.Equal(t, NewBigInt(huge), NewInt(huge))
+
+	same, _ := new(big.Int).SetString("100000000000000000000", 10)
+	assert.True(t, Equal(NewBigInt(huge), NewBigInt(same)))
+	assert.Equal(t, NewBigInt(huge).Hash(), NewBigInt(same).Hash())
+	assert.False(t, Equal(NewBigInt(huge), NewInteger(1)))
+}
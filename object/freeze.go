@@ -0,0 +1,50 @@
+package object
+
+import "reflect"
+
+// Freeze returns a deep copy of obj in which every array and map is frozen,
+// so that neither it nor anything it contains can be modified. Other values
+// are returned as they are. Arrays and maps that contain themselves are
+// copied without looping forever, and so are those already frozen.
+func Freeze(obj Object) Object {
+	return freeze(obj, make(map[uintptr]Object))
+}
+
+// freeze copies obj, reusing the copies in frozen of the arrays and maps
+// already copied, keyed by their elements.
+func freeze(obj Object, frozen map[uintptr]Object) Object {
+	switch obj := obj.(type) {
+	case Array:
+		if obj.Frozen {
+			return obj
+		}
+		key := reflect.ValueOf(obj.Elements).Pointer()
+		if copied, ok := frozen[key]; ok && len(obj.Elements) > 0 {
+			return copied
+		}
+		copied := Array{Elements: make([]Object, len(obj.Elements)), Frozen: true}
+		frozen[key] = copied
+		for i, element := range obj.Elements {
+			copied.Elements[i] = freeze(element, frozen)
+		}
+		return copied
+	case Map:
+		if obj.Frozen {
+			return obj
+		}
+		key := reflect.ValueOf(obj.Elements).Pointer()
+		if copied, ok := frozen[key]; ok {
+			return copied
+		}
+		copied := NewMap(nil)
+		frozen[key] = Map{Elements: copied.Elements, Frozen: true}
+		for _, pairs := range obj.Elements {
+			for _, kv := range pairs {
+				copied.Set(kv.Key, freeze(kv.Value, frozen))
+			}
+		}
+		copied.Frozen = true
+		return copied
+	}
+	return obj
+}
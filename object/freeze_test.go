@@ -0,0 +1,34 @@
+package object
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	inner := NewArray([]Object{NewInteger(1)})
+	m := NewMap([][2]Object{{NewString("inner"), inner}})
+	frozen := Freeze(m).(Map)
+	assert.True(t, frozen.Frozen)
+	assert.True(t, frozen.MustGet(NewString("inner")).(Array).Frozen)
+	assert.True(t, Equal(m, frozen))
+	inner.Set(NewInteger(0), NewInteger(2))
+	assert.Equal(t, NewInteger(1), frozen.MustGet(NewString("inner")).(Array).Elements[0])
+	assert.Equal(t, NewInteger(1), Freeze(NewInteger(1)))
+
+	cyclic := NewArray([]Object{NewInteger(1), NULL})
+	cyclic.Elements[1] = cyclic
+	frozenCyclic := Freeze(cyclic).(Array)
+	assert.True(t, frozenCyclic.Elements[1].(Array).Frozen)
+	assert.Equal(t, frozenCyclic.Hash(), Freeze(cyclic).(Hashable).Hash())
+}
+
+func TestMap_FrozenArrayKey(t *testing.T) {
+	m := NewMap(nil)
+	key := Freeze(NewArray([]Object{NewInteger(1), NewString("a")}))
+	m.Set(key, NewInteger(2))
+	assert.Equal(t, NewInteger(2), m.MustGet(Freeze(NewArray([]Object{NewInteger(1), NewString("a")}))))
+	_, ok := m.Get(Freeze(NewArray([]Object{NewInteger(1)})))
+	assert.False(t, ok)
+	assert.Panics(t, func() { m.Get(NewArray([]Object{NewInteger(1), NewString("a")})) })
+}
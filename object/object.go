@@ -1,14 +1,26 @@
 package object
 
 import (
+	"encoding/binary"
 	"fmt"
 	"github.com/carsonip/monkey-interpreter/ast"
 	"hash/fnv"
+	"math/big"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+// Object is a Monkey value. String is how the value displays, as it is
+// printed or interpolated in a template string, while Inspect shows it as it
+// would be written in source where it can, as the REPL echoes it. The two
+// only differ for strings, which Inspect quotes, and functions, which Inspect
+// shows the source of. Type is the name of the value's type, as written in
+// annotations.
 type Object interface {
 	String() string
+	Inspect() string
+	Type() string
 }
 
 type Hashable interface {
@@ -18,7 +30,15 @@ type Hashable interface {
 type Null struct {}
 
 func (n Null) String() string {
-	return ""
+	return "null"
+}
+
+func (n Null) Inspect() string {
+	return n.String()
+}
+
+func (n Null) Type() string {
+	return "null"
 }
 
 func (n Null) Hash() uint64 {
@@ -35,6 +55,14 @@ func (i Integer) String() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+func (i Integer) Inspect() string {
+	return i.String()
+}
+
+func (i Integer) Type() string {
+	return "int"
+}
+
 func (i Integer) Hash() uint64 {
 	return uint64(i.Value)
 }
@@ -43,10 +71,55 @@ func NewInteger(value int) Integer {
 	return Integer{Value: value}
 }
 
+// BigInt is an integer too large for an Integer. Arithmetic only makes one
+// when its result does not fit in an int, so an Integer and a BigInt never
+// hold the same value.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (i BigInt) String() string {
+	return i.Value.String()
+}
+
+func (i BigInt) Inspect() string {
+	return i.String()
+}
+
+func (i BigInt) Type() string {
+	return "int"
+}
+
+func (i BigInt) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(i.Value.String()))
+	return h.Sum64()
+}
+
+func NewBigInt(value *big.Int) BigInt {
+	return BigInt{Value: value}
+}
+
+// NewInt returns value as an Integer if it fits in an int, or as a BigInt.
+func NewInt(value *big.Int) Object {
+	if value.IsInt64() && int64(int(value.Int64())) == value.Int64() {
+		return NewInteger(int(value.Int64()))
+	}
+	return NewBigInt(value)
+}
+
 type Boolean struct {
 	Value bool
 }
 
+func (b Boolean) Inspect() string {
+	return b.String()
+}
+
+func (b Boolean) Type() string {
+	return "bool"
+}
+
 func (b Boolean) String() string {
 	if b.Value {
 		return "true"
@@ -68,33 +141,91 @@ func NewBoolean(value bool) Boolean {
 }
 
 type Function struct {
-	Params []string
+	Params []*ast.Parameter
 	Body []ast.Node
 	Env	*Env
+	// Definition is the literal the function was created from, and Path the
+	// module it appears in, empty for code that does not come from a file.
+	Definition *ast.Function
+	// Printer formats Definition for Inspect. The evaluator sets it, so that
+	// values do not depend on the printer.
+	Printer func(node ast.Node) string
+	Path string
+	// Name is the name a let statement declared the function with, if any.
+	Name string
 }
 
 func (f Function) String() string {
 	return "fn"
 }
 
-func NewFunction(params []string, body []ast.Node, env *Env) Function {
+// Inspect shows the source of the function literal f was created from.
+func (f Function) Inspect() string {
+	if f.Definition == nil || f.Printer == nil {
+		return f.String()
+	}
+	return f.Printer(f.Definition)
+}
+
+func (f Function) Type() string {
+	return "fn"
+}
+
+// Arity returns the number of arguments a call needs at least and accepts at
+// most, with max set to -1 for functions with a variadic parameter.
+func (f Function) Arity() (min int, max int) {
+	for _, param := range f.Params {
+		if param.Variadic {
+			return min, -1
+		}
+		if param.Default == nil {
+			min++
+		}
+		max++
+	}
+	return min, max
+}
+
+func NewFunction(params []*ast.Parameter, body []ast.Node, env *Env) Function {
 	return Function{Params: params, Body: body, Env: env}
 }
 
 type BuiltinFunction struct {
 	Fn func(args ...Object) Object
+	// CallFn is used instead of Fn by builtins that call the functions they
+	// are passed, which they do with call.
+	CallFn func(call func(fn Object, args ...Object) Object, args ...Object) Object
+	// StdioFn is used instead of Fn by builtins that read or write the
+	// streams of the evaluator calling them.
+	StdioFn func(stdio *Stdio, args ...Object) Object
 }
 
 func (f BuiltinFunction) String() string {
 	return "builtin"
 }
 
+func (f BuiltinFunction) Inspect() string {
+	return f.String()
+}
+
+func (f BuiltinFunction) Type() string {
+	return "fn"
+}
+
 type String struct {
 	Value string
 }
 
 func (s String) String() string {
-	return fmt.Sprintf("\"%s\"", s.Value)
+	return s.Value
+}
+
+func (s String) Inspect() string {
+	return strconv.Quote(s.Value)
+}
+
+func (s String) Type() string {
+	return "string"
 }
 
 func (s String) Hash() uint64 {
@@ -103,43 +234,107 @@ func (s String) Hash() uint64 {
 	return h.Sum64()
 }
 
+// Len returns the number of characters in s. Characters are runes, Unicode
+// code points, for len, indexing and slicing alike, so that none of them
+// splits a character encoded in several bytes.
+func (s String) Len() int {
+	return utf8.RuneCountInString(s.Value)
+}
+
+// Index returns the character at ind as a string, counting from the end if
+// ind is negative.
+func (s String) Index(ind Object) String {
+	runes := []rune(s.Value)
+	i := index(ind, len(runes), "string")
+	return NewString(string(runes[i]))
+}
+
+// Slice returns the characters of s that start, end and step select, as
+// described by sliceIndices.
+func (s String) Slice(start Object, end Object, step Object) String {
+	runes := []rune(s.Value)
+	indices := sliceIndices(start, end, step, len(runes), "string")
+	selected := make([]rune, len(indices))
+	for i, j := range indices {
+		selected[i] = runes[j]
+	}
+	return NewString(string(selected))
+}
+
 func NewString(value string) String {
 	return String{Value: value}
 }
 
 type Array struct {
 	Elements []Object
+	// Frozen arrays, made by Freeze, cannot be modified and can be map keys.
+	Frozen bool
 }
 
+// String shows the elements of a as Inspect does, so that strings in it are
+// quoted.
 func (a Array) String() string {
+	return a.Inspect()
+}
+
+func (a Array) Inspect() string {
 	var strs []string
 	for _, element := range a.Elements {
-		strs = append(strs, element.String())
+		strs = append(strs, element.Inspect())
 	}
 
 	return fmt.Sprintf(`[%s]`, strings.Join(strs, ", "))
 }
 
+func (a Array) Type() string {
+	return "array"
+}
+
+// Get returns the element at ind, counting from the end if it is negative.
 func (a Array) Get(ind Object) Object {
-	indNum, ok := ind.(Integer)
-	if !ok {
-		panic(NewError("array index not an integer"))
-	}
-	if indNum.Value < 0 || indNum.Value >= len(a.Elements) {
-		panic(NewError("array index out of bounds"))
-	}
-	return a.Elements[indNum.Value]
+	return a.Elements[index(ind, len(a.Elements), "array")]
 }
 
 func (a Array) Set(ind Object, value Object) {
-	indNum, ok := ind.(Integer)
-	if !ok {
-		panic(NewError("array index not an integer"))
+	i := index(ind, len(a.Elements), "array")
+	if a.Frozen {
+		panic(NewError("cannot modify frozen array"))
 	}
-	if indNum.Value < 0 || indNum.Value >= len(a.Elements) {
-		panic(NewError("array index out of bounds"))
+	a.Elements[i] = value
+}
+
+// Slice returns a new array of the elements of a that start, end and step
+// select, as described by sliceIndices. The slice of a frozen array is
+// frozen too.
+func (a Array) Slice(start Object, end Object, step Object) Array {
+	indices := sliceIndices(start, end, step, len(a.Elements), "array")
+	elements := make([]Object, len(indices))
+	for i, j := range indices {
+		elements[i] = a.Elements[j]
 	}
-	a.Elements[indNum.Value] = value
+	return Array{Elements: elements, Frozen: a.Frozen}
+}
+
+// Hash combines the hashes of the elements of a, for frozen arrays used as
+// map keys. Nested arrays and maps only contribute their length, which keeps
+// hashing arrays that contain themselves from looping forever.
+func (a Array) Hash() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, element := range a.Elements {
+		var eh uint64
+		switch element := element.(type) {
+		case Array:
+			eh = uint64(len(element.Elements))
+		case Map:
+			eh = uint64(element.Len())
+		case Hashable:
+			eh = element.Hash()
+		}
+		binary.LittleEndian.PutUint64(buf[:], eh)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
 }
 
 func NewArray(elements []Object) Array {
@@ -153,9 +348,21 @@ type KV struct {
 
 type Map struct {
 	Elements map[uint64][]KV
+	// Frozen maps, made by Freeze, cannot be modified.
+	Frozen bool
 }
 
+// String shows the pairs of m as Inspect does, so that strings in it are
+// quoted.
 func (m Map) String() string {
+	return m.Inspect()
+}
+
+func (m Map) Type() string {
+	return "map"
+}
+
+func (m Map) Inspect() string {
 	var sb strings.Builder
 	sb.WriteString("{")
 	first := true
@@ -166,7 +373,7 @@ func (m Map) String() string {
 			} else {
 				sb.WriteString(", ")
 			}
-			sb.WriteString(fmt.Sprintf("%s: %s", kv.Key.String(), kv.Value.String()))
+			sb.WriteString(fmt.Sprintf("%s: %s", kv.Key.Inspect(), kv.Value.Inspect()))
 		}
 	}
 	sb.WriteString("}")
@@ -174,14 +381,25 @@ func (m Map) String() string {
 	return sb.String()
 }
 
-func (m Map) Get(key Object) (Object, bool) {
-	if hashable, ok := key.(Hashable); !ok {
+// hashKey returns the hash of a map key. Arrays are only hashable once
+// frozen, so that they cannot change while they are keys.
+func hashKey(key Object) uint64 {
+	if arr, ok := key.(Array); ok && !arr.Frozen {
+		panic(NewError("key not hashable"))
+	}
+	hashable, ok := key.(Hashable)
+	if !ok {
 		panic(NewError("key not hashable"))
-	} else if pairs, ok := m.Elements[hashable.Hash()]; !ok {
+	}
+	return hashable.Hash()
+}
+
+func (m Map) Get(key Object) (Object, bool) {
+	if pairs, ok := m.Elements[hashKey(key)]; !ok {
 		return nil, false
 	} else {
 		for _, kv := range pairs {
-			if kv.Key == key {
+			if Equal(kv.Key, key) {
 				return kv.Value, true
 			}
 		}
@@ -197,16 +415,23 @@ func (m Map) MustGet(key Object) Object {
 	}
 }
 
-func (m Map) Set(key Object, value Object) {
-	hashable, ok := key.(Hashable)
-	if !ok {
-		panic(NewError("key not hashable"))
+// Len returns the number of pairs in m.
+func (m Map) Len() int {
+	n := 0
+	for _, pairs := range m.Elements {
+		n += len(pairs)
 	}
+	return n
+}
 
-	h := hashable.Hash()
+func (m Map) Set(key Object, value Object) {
+	h := hashKey(key)
+	if m.Frozen {
+		panic(NewError("cannot modify frozen map"))
+	}
 	if pairs, ok := m.Elements[h]; ok {
 		for i, kv := range pairs {
-			if kv.Key == key {
+			if Equal(kv.Key, key) {
 				m.Elements[h][i].Value = value
 				return
 			}
@@ -225,14 +450,63 @@ func NewMap(pairs [][2]Object) Map {
 	return m
 }
 
+type Module struct {
+	Path string
+	Env *Env
+	Exports map[string]bool
+}
+
+func (m Module) String() string {
+	return "module"
+}
+
+func (m Module) Inspect() string {
+	if m.Path == "" {
+		return m.String()
+	}
+	return fmt.Sprintf("module %q", m.Path)
+}
+
+func (m Module) Type() string {
+	return "module"
+}
+
+// Member returns an exported top-level binding of the module.
+func (m Module) Member(name string) (Object, bool) {
+	if !m.Exports[name] {
+		return nil, false
+	}
+	return m.Env.Get(name)
+}
+
+func NewModule(path string, env *Env, exports map[string]bool) Module {
+	return Module{Path: path, Env: env, Exports: exports}
+}
+
 type Error struct {
 	Message string
+	// Expected and Actual are the Inspect() of the values a failed
+	// assertEqual compared.
+	Expected string
+	Actual string
 }
 
 func (e Error) String() string {
 	return fmt.Sprintf("error: %s", e.Message)
 }
 
+func (e Error) Inspect() string {
+	return e.String()
+}
+
+func (e Error) Type() string {
+	return "error"
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
 func NewError(message string) Error {
 	return Error{Message: message}
 }
@@ -0,0 +1,34 @@
+package object
+
+import (
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	values := NewArray([]Object{NewString("a"), NULL, NewInteger(1), NewMap([][2]Object{{NewString("k"), NewBoolean(true)}})})
+	assert.Equal(t, `["a", null, 1, {"k": true}]`, values.Inspect())
+	assert.Equal(t, values.Inspect(), values.String())
+	assert.Equal(t, "a", NewString("a").String())
+	assert.Equal(t, `"a"`, NewString("a").Inspect())
+	assert.Equal(t, `"say \"hi\"\n\\"`, NewString("say \"hi\"\n\\").Inspect())
+	assert.Equal(t, `["\t"]`, NewArray([]Object{NewString("\t")}).String())
+	assert.Equal(t, "null", NULL.String())
+	assert.Equal(t, "fn", Function{}.Inspect())
+	printer := func(node ast.Node) string { return "fn() {}" }
+	assert.Equal(t, "fn", Function{Printer: printer}.Inspect())
+	assert.Equal(t, "fn() {}", Function{Definition: &ast.Function{}, Printer: printer}.Inspect())
+	assert.Equal(t, `module "lib.mk"`, Module{Path: "lib.mk"}.Inspect())
+}
+
+func TestType(t *testing.T) {
+	assert.Equal(t, "int", NewInteger(1).Type())
+	assert.Equal(t, "int", NewBigInt(new(big.Int).Lsh(big.NewInt(1), 100)).Type())
+	assert.Equal(t, "string", NewString("").Type())
+	assert.Equal(t, "fn", BuiltinFunction{}.Type())
+	assert.Equal(t, "array", NewArray(nil).Type())
+	assert.Equal(t, "map", NewMap(nil).Type())
+	assert.Equal(t, "null", NULL.Type())
+}
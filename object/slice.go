@@ -0,0 +1,99 @@
+package object
+
+import "math"
+
+// index returns ind as an index of a sequence of length elements, counting
+// from the end if it is negative. kind names the sequence in errors.
+func index(ind Object, length int, kind string) int {
+	i := bound(ind, length, kind)
+	if i == length {
+		panic(NewError(kind + " index out of bounds"))
+	}
+	return i
+}
+
+// bound returns ind as a position between two elements of a sequence of
+// length elements, from 0 before the first to length after the last,
+// counting from the end if it is negative.
+func bound(ind Object, length int, kind string) int {
+	var i int
+	switch ind := ind.(type) {
+	case Integer:
+		i = ind.Value
+	case BigInt:
+		panic(NewError(kind + " index out of bounds"))
+	default:
+		panic(NewError(kind + " index not an integer"))
+	}
+	if i < 0 {
+		i += length
+	}
+	if i < 0 || i > length {
+		panic(NewError(kind + " index out of bounds"))
+	}
+	return i
+}
+
+// sliceIndices returns the indices of the elements of a sequence of length
+// elements selected by start:end:step, any of which may be nil if left out.
+// With a positive step, the default, they run from start up to but not
+// including end, which default to the start and end of the sequence. With a
+// negative step they run backwards, from the last element if start is left
+// out, down to but not including end, or the first element if it is left
+// out. Negative bounds count from the end, and bounds outside the sequence
+// are an error, as they are for an index.
+func sliceIndices(start Object, end Object, step Object, length int, kind string) []int {
+	by := 1
+	switch s := step.(type) {
+	case nil:
+	case Integer:
+		by = s.Value
+	case BigInt:
+		by = s.Value.Sign() * math.MaxInt
+	default:
+		panic(NewError("slice step not an integer"))
+	}
+	if by == 0 {
+		panic(NewError("slice step cannot be zero"))
+	}
+	// Steps longer than the sequence select the same as one as long, and
+	// keep the arithmetic below from overflowing.
+	if by > length && length > 0 {
+		by = length
+	} else if by < -length && length > 0 {
+		by = -length
+	}
+
+	var from, to, n int
+	if by > 0 {
+		from, to = 0, length
+		if start != nil {
+			from = bound(start, length, kind)
+		}
+		if end != nil {
+			to = bound(end, length, kind)
+		}
+		if to > from {
+			n = (to-from-1)/by + 1
+		}
+	} else if by < 0 {
+		from, to = length-1, -1
+		if start != nil {
+			from = bound(start, length, kind)
+			if from == length {
+				from = length - 1
+			}
+		}
+		if end != nil {
+			to = bound(end, length, kind)
+		}
+		if from > to {
+			n = (from-to-1)/-by + 1
+		}
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = from + i*by
+	}
+	return indices
+}
@@ -0,0 +1,21 @@
+package object
+
+import (
+	"bufio"
+	"io"
+)
+
+// Stdio holds the streams the input and output builtins read from and write
+// to. In is buffered, so that input can read a line at a time, and should be
+// shared by everything reading from the same stream.
+type Stdio struct {
+	In  *bufio.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// NewStdio returns the streams in, out and err, buffering in unless it
+// already is.
+func NewStdio(in io.Reader, out io.Writer, err io.Writer) *Stdio {
+	return &Stdio{In: bufio.NewReader(in), Out: out, Err: err}
+}
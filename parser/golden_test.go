@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestParser_Golden parses each testdata/*.mk file and compares its AST, as
+// JSON, with the .json file next to it.
+func TestParser_Golden(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.mk")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		lex := token.NewLexer(string(source))
+		p := NewParser(&lex)
+		got, err := json.MarshalIndent(p.ParseProgram(), "", "  ")
+		assert.NoError(t, err)
+		got = append(got, '\n')
+
+		golden := strings.TrimSuffix(file, ".mk") + ".json"
+		if *update {
+			assert.NoError(t, os.WriteFile(golden, got, 0644))
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(got), file)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"github.com/carsonip/monkey-interpreter/ast"
 	"github.com/carsonip/monkey-interpreter/token"
 	"log"
+	"math/big"
 	"strconv"
 )
 
 type Parser struct {
 	lexer *token.Lexer
 	curToken token.Token
+	// peeked holds the tokens read ahead of curToken.
+	peeked []token.Token
 }
 
 func NewParser(l *token.Lexer) Parser {
@@ -19,20 +22,73 @@ func NewParser(l *token.Lexer) Parser {
 }
 
 func (p *Parser) next() {
+	if len(p.peeked) > 0 {
+		p.curToken = p.peeked[0]
+		p.peeked = p.peeked[1:]
+		return
+	}
 	p.curToken = p.lexer.NextToken()
 }
 
+// peek returns the token after curToken.
+func (p *Parser) peek() token.Token {
+	if len(p.peeked) == 0 {
+		p.peeked = append(p.peeked, p.lexer.NextToken())
+	}
+	return p.peeked[0]
+}
+
+// splitToken turns the ++ or -- the parser is looking at into the two + or
+// - operators it is made of.
+func (p *Parser) splitToken() {
+	first := p.curToken
+	first.Type = token.TOKEN_PLUS
+	if p.curToken.Type == token.TOKEN_DECREMENT {
+		first.Type = token.TOKEN_MINUS
+	}
+	first.Literal = first.Literal[:1]
+	second := first
+	second.Column++
+	p.curToken = first
+	p.peeked = append([]token.Token{second}, p.peeked...)
+}
+
+// isPostfix reports whether the ++ or -- the parser is looking at, after
+// expr, increments or decrements it. That needs expr to be a name, an index
+// or a member, and no operand to follow. Otherwise it is two operators, as in
+// 5--3 or a -- b, which subtract a negated operand.
+func (p *Parser) isPostfix(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.Identifier, *ast.Index, *ast.Dot:
+	default:
+		return false
+	}
+	switch p.peek().Type {
+	case token.TOKEN_NUMBER, token.TOKEN_IDENTIFIER, token.TOKEN_STRING, token.TOKEN_BACKTICK,
+		token.TOKEN_TRUE, token.TOKEN_FALSE, token.TOKEN_FUNCTION, token.TOKEN_MATCH,
+		token.TOKEN_NOT, token.TOKEN_LPAREN, token.TOKEN_LBRACKET, token.TOKEN_LBRACE:
+		return false
+	}
+	return true
+}
+
 func (p *Parser) NextNode() ast.Node {
 	var node ast.Node
 	switch p.curToken.Type {
 	case token.TOKEN_EOF:
 		node = nil
-	case token.TOKEN_LET:
+	case token.TOKEN_LET, token.TOKEN_CONST:
 		node = p.parseLetStatement()
 	case token.TOKEN_RETURN:
 		node = p.parseReturnStatement()
+	case token.TOKEN_IMPORT:
+		node = p.parseImportStatement()
+	case token.TOKEN_EXPORT:
+		node = p.parseExportStatement()
 	case token.TOKEN_IF:
 		node = p.parseIfStatement()
+	case token.TOKEN_WHILE:
+		node = p.parseWhileStatement()
 	default:
 		node = p.parseExpression()
 	}
@@ -42,17 +98,59 @@ func (p *Parser) NextNode() ast.Node {
 	return node
 }
 
+// CurrentToken returns the token the parser is looking at, which is where
+// parsing stopped if it failed.
+func (p *Parser) CurrentToken() token.Token {
+	return p.curToken
+}
+
+// ParseProgram parses all remaining input.
+func (p *Parser) ParseProgram() *ast.Program {
+	program := &ast.Program{}
+	for node := p.NextNode(); node != nil; node = p.NextNode() {
+		program.Statements = append(program.Statements, node)
+	}
+	return program
+}
+
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	l := &ast.LetStatement{
 		Token: p.curToken,
 	}
-	p.expectAndNext(token.TOKEN_LET)
-	l.Name = p.parseIdentifier()
+	if !p.curTokenIs(token.TOKEN_LET, token.TOKEN_CONST) {
+		log.Panicf("expected let or const, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+	}
+	p.next()
+	l.Name = p.parseBindingPattern()
+	l.Type = p.parseOptionalType()
 	p.expectAndNext(token.TOKEN_ASSIGNMENT)
 	l.Value = p.parseExpression()
 	return l
 }
 
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	s := &ast.ImportStatement{
+		Token: p.curToken,
+	}
+	p.expectAndNext(token.TOKEN_IMPORT)
+	s.Path = p.parseString()
+	p.expectAndNext(token.TOKEN_AS)
+	s.Alias = p.parseIdentifier()
+	return s
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	s := &ast.ExportStatement{
+		Token: p.curToken,
+	}
+	p.expectAndNext(token.TOKEN_EXPORT)
+	if !p.curTokenIs(token.TOKEN_LET, token.TOKEN_CONST) {
+		log.Panicf("expected let or const after export, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+	}
+	s.Statement = p.parseLetStatement()
+	return s
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	s := &ast.ReturnStatement{
 		Token: p.curToken,
@@ -76,6 +174,7 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 	for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
 		s.Then = append(s.Then, p.NextNode())
 	}
+	s.ThenRBrace = p.curToken
 	p.expectAndNext(token.TOKEN_RBRACE)
 	if p.curTokenIs(token.TOKEN_ELSE) {
 		p.expectAndNext(token.TOKEN_ELSE)
@@ -83,11 +182,29 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 		for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
 			s.Else = append(s.Else, p.NextNode())
 		}
+		s.ElseRBrace = p.curToken
 		p.expectAndNext(token.TOKEN_RBRACE)
 	}
 	return s
 }
 
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	s := &ast.WhileStatement{
+		Token: p.curToken,
+	}
+	p.expectAndNext(token.TOKEN_WHILE)
+	p.expectAndNext(token.TOKEN_LPAREN)
+	s.Condition = p.parseExpression()
+	p.expectAndNext(token.TOKEN_RPAREN)
+	p.expectAndNext(token.TOKEN_LBRACE)
+	for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
+		s.Body = append(s.Body, p.NextNode())
+	}
+	s.RBrace = p.curToken
+	p.expectAndNext(token.TOKEN_RBRACE)
+	return s
+}
+
 func (p *Parser) parseExpression() ast.Expression {
 	return p.parseExpressionWithPrecedence(0)
 }
@@ -108,6 +225,9 @@ func (p *Parser) parseExpressionWithPrecedence(curPrecedence Precedence) ast.Exp
 		expr = p.parseIdentifier()
 	case token.TOKEN_PLUS, token.TOKEN_MINUS, token.TOKEN_NOT:
 		expr = p.parsePrefixExpression()
+	case token.TOKEN_INCREMENT, token.TOKEN_DECREMENT:
+		p.splitToken()
+		expr = p.parsePrefixExpression()
 	case token.TOKEN_FUNCTION:
 		expr = p.parseFunction()
 	case token.TOKEN_TRUE, token.TOKEN_FALSE:
@@ -116,10 +236,14 @@ func (p *Parser) parseExpressionWithPrecedence(curPrecedence Precedence) ast.Exp
 		expr = p.parseGroupedExpression()
 	case token.TOKEN_STRING:
 		expr = p.parseString()
+	case token.TOKEN_BACKTICK:
+		expr = p.parseTemplate()
 	case token.TOKEN_LBRACKET:
 		expr = p.parseArray()
 	case token.TOKEN_LBRACE:
 		expr = p.parseMap()
+	case token.TOKEN_MATCH:
+		expr = p.parseMatch()
 	default:
 		log.Panicf("expected expression, got %d %s instead", p.curToken.Type, p.curToken.Literal)
 		p.next()
@@ -127,7 +251,7 @@ func (p *Parser) parseExpressionWithPrecedence(curPrecedence Precedence) ast.Exp
 	}
 
 	for {
-		if p.curTokenIs(token.TOKEN_COLON, token.TOKEN_RBRACE, token.TOKEN_RPAREN, token.TOKEN_RBRACKET, token.TOKEN_COMMA, token.TOKEN_SEMICOLON, token.TOKEN_EOF) {
+		if p.curTokenIs(token.TOKEN_COLON, token.TOKEN_RBRACE, token.TOKEN_RPAREN, token.TOKEN_RBRACKET, token.TOKEN_COMMA, token.TOKEN_SEMICOLON, token.TOKEN_ARROW, token.TOKEN_EOF) {
 			return expr
 		}
 
@@ -135,6 +259,13 @@ func (p *Parser) parseExpressionWithPrecedence(curPrecedence Precedence) ast.Exp
 			expr = p.parseFunctionCall(expr)
 		} else if p.curTokenIs(token.TOKEN_LBRACKET) {
 			expr = p.parseIndex(expr)
+		} else if p.curTokenIs(token.TOKEN_DOT) {
+			expr = p.parseDot(expr)
+		} else if p.curTokenIs(token.TOKEN_INCREMENT, token.TOKEN_DECREMENT) && p.isPostfix(expr) {
+			expr = &ast.PostfixExpression{Token: p.curToken, Left: expr}
+			p.next()
+		} else if p.curTokenIs(token.TOKEN_INCREMENT, token.TOKEN_DECREMENT) {
+			p.splitToken()
 		} else if precedence, ok := operatorToPrecedence[p.curToken.Type]; ok {
 			if precedence <= curPrecedence {
 				return expr
@@ -152,17 +283,24 @@ func (p *Parser) parseNumber() *ast.NumberLiteral {
 	if !p.curTokenIs(token.TOKEN_NUMBER) {
 		log.Panicf("expected number, got %d %s instead", p.curToken.Type, p.curToken.Literal)
 	}
-	if val, err := strconv.Atoi(p.curToken.Literal); err != nil {
-		log.Panicf("bad number %s", p.curToken.Literal)
-		p.next()
-		return nil
-	} else {
+	if val, err := strconv.Atoi(p.curToken.Literal); err == nil {
 		lit := &ast.NumberLiteral{
 			Token: p.curToken,
 			Value: val,
 		}
 		p.next()
 		return lit
+	} else if val, ok := new(big.Int).SetString(p.curToken.Literal, 10); ok {
+		lit := &ast.NumberLiteral{
+			Token: p.curToken,
+			Big:   val,
+		}
+		p.next()
+		return lit
+	} else {
+		log.Panicf("bad number %s", p.curToken.Literal)
+		p.next()
+		return nil
 	}
 }
 
@@ -183,19 +321,52 @@ func(p *Parser) parseFunction() *ast.Function {
 		} else {
 			p.expectAndNext(token.TOKEN_COMMA)
 		}
-		ident := p.parseIdentifier()
-		fn.Params = append(fn.Params, ident)
+		param := p.parseParameter()
+		fn.Params = append(fn.Params, param)
+		if param.Variadic && !p.curTokenIs(token.TOKEN_RPAREN) {
+			log.Panicf("variadic parameter must be last")
+		}
 	}
 	p.expectAndNext(token.TOKEN_RPAREN)
+	fn.ReturnType = p.parseOptionalType()
 	p.expectAndNext(token.TOKEN_LBRACE)
 	for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
 		node := p.NextNode()
 		fn.Body = append(fn.Body, node)
 	}
+	fn.RBrace = p.curToken
 	p.expectAndNext(token.TOKEN_RBRACE)
 	return fn
 }
 
+func (p *Parser) parseParameter() *ast.Parameter {
+	if p.curTokenIs(token.TOKEN_ELLIPSIS) {
+		p.next()
+		return &ast.Parameter{Pattern: p.parseIdentifier(), Type: p.parseOptionalType(), Variadic: true}
+	}
+	param := &ast.Parameter{Pattern: p.parseBindingPattern(), Type: p.parseOptionalType()}
+	if p.curTokenIs(token.TOKEN_ASSIGNMENT) {
+		p.next()
+		param.Default = p.parseExpression()
+	}
+	return param
+}
+
+// parseOptionalType parses a ": type" annotation if there is one. Type names
+// are identifiers, or fn for functions.
+func (p *Parser) parseOptionalType() *ast.TypeAnnotation {
+	if !p.curTokenIs(token.TOKEN_COLON) {
+		return nil
+	}
+	p.next()
+	if !p.curTokenIs(token.TOKEN_IDENTIFIER, token.TOKEN_FUNCTION) {
+		log.Panicf("expected type, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+	}
+	t := &ast.TypeAnnotation{Token: p.curToken}
+	p.next()
+	return t
+}
+
 func (p *Parser) expectAndNext(token token.TokenType) {
 	if !p.curTokenIs(token) {
 		log.Panicf("expected %d, got %d %s instead", token, p.curToken.Type, p.curToken.Literal)
@@ -231,12 +402,26 @@ var operatorToPrecedence = map[token.TokenType]Precedence{
 	token.TOKEN_MINUS: PRECEDENCE_PLUS_MINUS,
 	token.TOKEN_ASTERISK: PRECEDENCE_MULTIPLY_DIVIDE,
 	token.TOKEN_SLASH: PRECEDENCE_MULTIPLY_DIVIDE,
+	token.TOKEN_PERCENT: PRECEDENCE_MULTIPLY_DIVIDE,
 	token.TOKEN_LPAREN: PRECEDENCE_CALL,
 	token.TOKEN_LT: PRECEDENCE_COMPARISON,
 	token.TOKEN_GT: PRECEDENCE_COMPARISON,
+	token.TOKEN_LTE: PRECEDENCE_COMPARISON,
+	token.TOKEN_GTE: PRECEDENCE_COMPARISON,
 	token.TOKEN_EQUAL: PRECEDENCE_COMPARISON,
 	token.TOKEN_NOTEQUAL: PRECEDENCE_COMPARISON,
 	token.TOKEN_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+	token.TOKEN_PLUS_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+	token.TOKEN_MINUS_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+	token.TOKEN_ASTERISK_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+	token.TOKEN_SLASH_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+	token.TOKEN_PERCENT_ASSIGNMENT: PRECEDENCE_ASSIGNMENT,
+}
+
+// OperatorPrecedence returns the binding power of an infix operator.
+func OperatorPrecedence(tokenType token.TokenType) (Precedence, bool) {
+	precedence, ok := operatorToPrecedence[tokenType]
+	return precedence, ok
 }
 
 func (p *Parser) parseInfixExpression(left ast.Expression, curPrecedence Precedence) ast.Expression {
@@ -281,19 +466,66 @@ func (p *Parser) parseFunctionCall(expr ast.Expression) *ast.FunctionCall {
 		} else {
 			p.expectAndNext(token.TOKEN_COMMA)
 		}
-		expr := p.parseExpression()
-		fnCall.Arguments = append(fnCall.Arguments, expr)
+		fnCall.Arguments = append(fnCall.Arguments, p.parseArgument())
 	}
+	fnCall.RParen = p.curToken
 	p.expectAndNext(token.TOKEN_RPAREN)
 	return fnCall
 }
 
+func (p *Parser) parseArgument() ast.Expression {
+	if p.curTokenIs(token.TOKEN_ELLIPSIS) {
+		spread := &ast.Spread{Token: p.curToken}
+		p.next()
+		spread.Value = p.parseExpression()
+		return spread
+	}
+	expr := p.parseExpression()
+	if p.curTokenIs(token.TOKEN_COLON) {
+		name, ok := expr.(*ast.Identifier)
+		if !ok {
+			log.Panicf("expected keyword argument name, got %s instead", expr.TokenLiteral())
+		}
+		kwarg := &ast.KeywordArgument{Token: p.curToken, Name: name}
+		p.next()
+		kwarg.Value = p.parseExpression()
+		return kwarg
+	}
+	return expr
+}
+
 func (p *Parser) parseString() *ast.String {
 	str := &ast.String{Token: p.curToken, Value: p.curToken.Literal}
 	p.expectAndNext(token.TOKEN_STRING)
 	return str
 }
 
+func (p *Parser) parseTemplate() *ast.Template {
+	t := &ast.Template{Token: p.curToken}
+	p.expectAndNext(token.TOKEN_BACKTICK)
+	t.Text = append(t.Text, p.parseTemplateText())
+	for p.curTokenIs(token.TOKEN_INTERPOLATION) {
+		p.next()
+		t.Values = append(t.Values, p.parseExpression())
+		p.expectAndNext(token.TOKEN_RBRACE)
+		t.Text = append(t.Text, p.parseTemplateText())
+	}
+	t.End = p.curToken
+	p.expectAndNext(token.TOKEN_BACKTICK)
+	return t
+}
+
+// parseTemplateText parses the text of a template string up to the next
+// interpolation or its end, which may be empty.
+func (p *Parser) parseTemplateText() string {
+	if !p.curTokenIs(token.TOKEN_TEMPLATE_TEXT) {
+		return ""
+	}
+	text := p.curToken.Literal
+	p.next()
+	return text
+}
+
 func (p *Parser) parseArray() *ast.Array {
 	arr := &ast.Array{Token: p.curToken}
 	p.expectAndNext(token.TOKEN_LBRACKET)
@@ -307,16 +539,44 @@ func (p *Parser) parseArray() *ast.Array {
 		expr := p.parseExpression()
 		arr.Elements = append(arr.Elements, expr)
 	}
+	arr.RBracket = p.curToken
 	p.expectAndNext(token.TOKEN_RBRACKET)
 	return arr
 }
 
-func (p *Parser) parseIndex(left ast.Expression) *ast.Index {
-	ind := &ast.Index{Token: p.curToken, Left: left}
+// parseIndex parses an index, left[index], or a slice, left[start:end] or
+// left[start:end:step], in which any part may be left out.
+func (p *Parser) parseIndex(left ast.Expression) ast.Expression {
+	tok := p.curToken
 	p.expectAndNext(token.TOKEN_LBRACKET)
-	ind.Index = p.parseExpression()
+	var start ast.Expression
+	if !p.curTokenIs(token.TOKEN_COLON) {
+		start = p.parseExpression()
+	}
+	if !p.curTokenIs(token.TOKEN_COLON) {
+		p.expectAndNext(token.TOKEN_RBRACKET)
+		return &ast.Index{Token: tok, Left: left, Index: start}
+	}
+	slice := &ast.Slice{Token: tok, Left: left, Start: start}
+	p.next()
+	if !p.curTokenIs(token.TOKEN_COLON, token.TOKEN_RBRACKET) {
+		slice.End = p.parseExpression()
+	}
+	if p.curTokenIs(token.TOKEN_COLON) {
+		p.next()
+		if !p.curTokenIs(token.TOKEN_RBRACKET) {
+			slice.Step = p.parseExpression()
+		}
+	}
 	p.expectAndNext(token.TOKEN_RBRACKET)
-	return ind
+	return slice
+}
+
+func (p *Parser) parseDot(left ast.Expression) *ast.Dot {
+	dot := &ast.Dot{Token: p.curToken, Left: left}
+	p.expectAndNext(token.TOKEN_DOT)
+	dot.Name = p.parseIdentifier()
+	return dot
 }
 
 func (p *Parser) parseMap() *ast.Map {
@@ -334,6 +594,140 @@ func (p *Parser) parseMap() *ast.Map {
 		val := p.parseExpression()
 		m.Pairs = append(m.Pairs, [2]ast.Expression{key, val})
 	}
+	m.RBrace = p.curToken
+	p.expectAndNext(token.TOKEN_RBRACE)
+	return m
+}
+
+func (p *Parser) parseMatch() *ast.MatchExpression {
+	m := &ast.MatchExpression{Token: p.curToken}
+	p.expectAndNext(token.TOKEN_MATCH)
+	p.expectAndNext(token.TOKEN_LPAREN)
+	m.Value = p.parseExpression()
+	p.expectAndNext(token.TOKEN_RPAREN)
+	p.expectAndNext(token.TOKEN_LBRACE)
+	for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
+		arm := &ast.MatchArm{Pattern: p.parsePattern()}
+		if p.curTokenIs(token.TOKEN_IF) {
+			p.next()
+			arm.Guard = p.parseExpression()
+		}
+		p.expectAndNext(token.TOKEN_ARROW)
+		arm.Body = p.parseExpression()
+		m.Arms = append(m.Arms, arm)
+		if !p.curTokenIs(token.TOKEN_RBRACE) {
+			p.expectAndNext(token.TOKEN_COMMA)
+		}
+	}
+	m.RBrace = p.curToken
+	p.expectAndNext(token.TOKEN_RBRACE)
+	return m
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.TOKEN_IDENTIFIER:
+		return p.parseIdentifier()
+	case token.TOKEN_NUMBER:
+		return p.parseNumber()
+	case token.TOKEN_MINUS:
+		p.next()
+		num := p.parseNumber()
+		num.Token.Literal = "-" + num.Token.Literal
+		num.Value = -num.Value
+		if num.Big != nil {
+			// -9223372036854775808 fits in an int although its magnitude
+			// does not.
+			num.Big = new(big.Int).Neg(num.Big)
+			if num.Big.IsInt64() && int64(int(num.Big.Int64())) == num.Big.Int64() {
+				num.Value, num.Big = int(num.Big.Int64()), nil
+			}
+		}
+		return num
+	case token.TOKEN_STRING:
+		return p.parseString()
+	case token.TOKEN_TRUE, token.TOKEN_FALSE:
+		return p.parseBoolean().(*ast.Boolean)
+	case token.TOKEN_LBRACKET:
+		return p.parseArrayPattern(p.parsePattern)
+	case token.TOKEN_LBRACE:
+		return p.parseMapPattern(p.parsePattern)
+	}
+	log.Panicf("expected pattern, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+	return nil
+}
+
+// parseBindingPattern parses the target of a let statement or a function
+// parameter, which must bind names rather than compare against a literal,
+// at any depth.
+func (p *Parser) parseBindingPattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.TOKEN_IDENTIFIER:
+		return p.parseIdentifier()
+	case token.TOKEN_LBRACKET:
+		return p.parseArrayPattern(p.parseBindingPattern)
+	case token.TOKEN_LBRACE:
+		return p.parseMapPattern(p.parseBindingPattern)
+	}
+	log.Panicf("expected identifier or destructuring pattern, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+	return nil
+}
+
+// parseArrayPattern parses an array pattern whose elements are parsed by
+// element.
+func (p *Parser) parseArrayPattern(element func() ast.Pattern) *ast.ArrayPattern {
+	arr := &ast.ArrayPattern{Token: p.curToken}
+	p.expectAndNext(token.TOKEN_LBRACKET)
+	first := true
+	for !p.curTokenIsEOFOr(token.TOKEN_RBRACKET) {
+		if first {
+			first = false
+		} else {
+			p.expectAndNext(token.TOKEN_COMMA)
+		}
+		if p.curTokenIs(token.TOKEN_ELLIPSIS) {
+			p.next()
+			arr.Rest = p.parseIdentifier()
+			break
+		}
+		arr.Elements = append(arr.Elements, element())
+	}
+	p.expectAndNext(token.TOKEN_RBRACKET)
+	return arr
+}
+
+// parseMapPattern parses a map pattern whose values are parsed by value.
+// Keys are always literals.
+func (p *Parser) parseMapPattern(value func() ast.Pattern) *ast.MapPattern {
+	m := &ast.MapPattern{Token: p.curToken}
+	p.expectAndNext(token.TOKEN_LBRACE)
+	first := true
+	for !p.curTokenIsEOFOr(token.TOKEN_RBRACE) {
+		if first {
+			first = false
+		} else {
+			p.expectAndNext(token.TOKEN_COMMA)
+		}
+		var pair ast.MapPatternPair
+		if p.curTokenIs(token.TOKEN_IDENTIFIER) {
+			// {name} is shorthand for {"name": name}
+			ident := p.parseIdentifier()
+			pair.Key = &ast.String{
+				Token: token.Token{Type: token.TOKEN_STRING, Literal: ident.Token.Literal},
+				Value: ident.Token.Literal,
+			}
+			pair.Value = ident
+		} else {
+			key, ok := p.parsePattern().(ast.Expression)
+			if !ok {
+				log.Panicf("expected literal map pattern key, got %d %s instead", p.curToken.Type, p.curToken.Literal)
+			}
+			pair.Key = key
+			p.expectAndNext(token.TOKEN_COLON)
+			pair.Value = value()
+		}
+		m.Pairs = append(m.Pairs, pair)
+	}
 	p.expectAndNext(token.TOKEN_RBRACE)
 	return m
 }
@@ -211,6 +211,28 @@ func TestParser_String(t *testing.T) {
 	assert.Equal(t, "hello world", s.Value)
 }
 
+func TestParser_Template(t *testing.T) {
+	lex := token.NewLexer("`a ${x} b ${`${y}`}`")
+	p := NewParser(&lex)
+	tmpl, ok := p.NextNode().(*ast.Template)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a ", " b ", ""}, tmpl.Text)
+	assert.Len(t, tmpl.Values, 2)
+	assert.Equal(t, "x", tmpl.Values[0].TokenLiteral())
+	inner, ok := tmpl.Values[1].(*ast.Template)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"", ""}, inner.Text)
+	assert.Equal(t, 1, tmpl.End.Column+1-len("`a ${x} b ${`${y}`}`"))
+	assert.Nil(t, p.NextNode())
+
+	lex = token.NewLexer("``")
+	p = NewParser(&lex)
+	tmpl, ok = p.NextNode().(*ast.Template)
+	assert.True(t, ok)
+	assert.Equal(t, []string{""}, tmpl.Text)
+	assert.Empty(t, tmpl.Values)
+}
+
 func TestParser_Array(t *testing.T) {
 	str := `[1, "foo", []]`
 	lex := token.NewLexer(str)
@@ -251,6 +273,39 @@ func TestParser_Index_Nested(t *testing.T) {
 	assert.Nil(t, p.NextNode())
 }
 
+func TestParser_Slice(t *testing.T) {
+	tests := []struct {
+		input            string
+		start, end, step string
+	}{
+		{`a[1:2]`, "1", "2", ""},
+		{`a[1:]`, "1", "", ""},
+		{`a[:x]`, "", "x", ""},
+		{`a[:]`, "", "", ""},
+		{`a[::-1]`, "", "", "(-1)"},
+		{`a[i:i + 2:2]`, "i", "(i + 2)", "2"},
+		{`a[1::3]`, "1", "", "3"},
+	}
+	literal := func(expr ast.Expression) string {
+		if expr == nil {
+			return ""
+		}
+		return expr.TokenLiteral()
+	}
+	for _, test := range tests {
+		lex := token.NewLexer(test.input)
+		p := NewParser(&lex)
+		slice, ok := p.NextNode().(*ast.Slice)
+		if !assert.True(t, ok, test.input) {
+			continue
+		}
+		assert.Equal(t, "a", slice.Left.TokenLiteral())
+		assert.Equal(t, []string{test.start, test.end, test.step},
+			[]string{literal(slice.Start), literal(slice.End), literal(slice.Step)}, test.input)
+		assert.Nil(t, p.NextNode())
+	}
+}
+
 func TestParser_Map(t *testing.T) {
 	str := `{"foo": 1, 2: "bar"}`
 	lex := token.NewLexer(str)
@@ -273,3 +328,193 @@ func TestParser_Terminate(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_Match(t *testing.T) {
+	str := `match (x) { 1 => "one", [a, ...rest] if a > 1 => rest, {"k": v, name} => v, _ => 0, }`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	node := p.NextNode()
+	m, ok := node.(*ast.MatchExpression)
+	assert.True(t, ok)
+	assert.Equal(t, "x", m.Value.TokenLiteral())
+	assert.Len(t, m.Arms, 4)
+	assert.IsType(t, &ast.NumberLiteral{}, m.Arms[0].Pattern)
+	arr, ok := m.Arms[1].Pattern.(*ast.ArrayPattern)
+	assert.True(t, ok)
+	assert.Len(t, arr.Elements, 1)
+	assert.Equal(t, "rest", arr.Rest.TokenLiteral())
+	assert.Equal(t, "(a > 1)", m.Arms[1].Guard.TokenLiteral())
+	mp, ok := m.Arms[2].Pattern.(*ast.MapPattern)
+	assert.True(t, ok)
+	assert.Len(t, mp.Pairs, 2)
+	assert.Equal(t, `"name"`, mp.Pairs[1].Key.TokenLiteral())
+	assert.Nil(t, m.Arms[3].Guard)
+	assert.Nil(t, p.NextNode())
+}
+
+func TestParser_Match_NegativeBig(t *testing.T) {
+	lex := token.NewLexer(`match (x) { -9223372036854775808 => 1, -9223372036854775809 => 2 }`)
+	p := NewParser(&lex)
+	m := p.NextNode().(*ast.MatchExpression)
+	min := m.Arms[0].Pattern.(*ast.NumberLiteral)
+	assert.Nil(t, min.Big)
+	assert.Equal(t, -9223372036854775808, min.Value)
+	assert.Equal(t, "-9223372036854775809", m.Arms[1].Pattern.(*ast.NumberLiteral).Big.String())
+}
+
+func TestParser_LetStatement_Destructuring(t *testing.T) {
+	str := `let [a, ...rest] = arr; let {name, "age": age} = person;`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	l, ok := p.NextNode().(*ast.LetStatement)
+	assert.True(t, ok)
+	arr, ok := l.Name.(*ast.ArrayPattern)
+	assert.True(t, ok)
+	assert.Len(t, arr.Elements, 1)
+	assert.Equal(t, "rest", arr.Rest.TokenLiteral())
+	l, ok = p.NextNode().(*ast.LetStatement)
+	assert.True(t, ok)
+	m, ok := l.Name.(*ast.MapPattern)
+	assert.True(t, ok)
+	assert.Len(t, m.Pairs, 2)
+	assert.Nil(t, p.NextNode())
+}
+
+func TestParser_Function_Destructuring(t *testing.T) {
+	str := `fn([a, b], {name}){}`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	fn, ok := p.NextNode().(*ast.Function)
+	assert.True(t, ok)
+	assert.Len(t, fn.Params, 2)
+	assert.IsType(t, &ast.ArrayPattern{}, fn.Params[0].Pattern)
+	assert.IsType(t, &ast.MapPattern{}, fn.Params[1].Pattern)
+}
+
+func TestParser_Destructuring_Literal(t *testing.T) {
+	inputs := []string{
+		`let [1, a] = x;`,
+		`let {"k": [true]} = x;`,
+		`fn([a, "b"]) {}`,
+	}
+	for _, input := range inputs {
+		assert.Panics(t, func() {
+			lex := token.NewLexer(input)
+			p := NewParser(&lex)
+			p.NextNode()
+		}, input)
+	}
+}
+
+func TestParser_Function_DefaultVariadic(t *testing.T) {
+	str := `fn(x, y = 1 + 1, ...rest){}`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	fn, ok := p.NextNode().(*ast.Function)
+	assert.True(t, ok)
+	assert.Len(t, fn.Params, 3)
+	assert.Nil(t, fn.Params[0].Default)
+	assert.Equal(t, "(1 + 1)", fn.Params[1].Default.TokenLiteral())
+	assert.False(t, fn.Params[1].Variadic)
+	assert.True(t, fn.Params[2].Variadic)
+	assert.Equal(t, "rest", fn.Params[2].TokenLiteral())
+}
+
+func TestParser_FunctionCall_SpreadKeyword(t *testing.T) {
+	str := `f(...xs, y: 2)`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	fnCall, ok := p.NextNode().(*ast.FunctionCall)
+	assert.True(t, ok)
+	assert.Len(t, fnCall.Arguments, 2)
+	assert.IsType(t, &ast.Spread{}, fnCall.Arguments[0])
+	kwarg, ok := fnCall.Arguments[1].(*ast.KeywordArgument)
+	assert.True(t, ok)
+	assert.Equal(t, "y", kwarg.Name.TokenLiteral())
+	assert.Equal(t, "2", kwarg.Value.TokenLiteral())
+}
+
+func TestParser_CompoundAssignment(t *testing.T) {
+	str := `a[i] += 1 * 2; m.k %= 3; x++`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	node := p.NextNode()
+	exp, ok := node.(*ast.InfixExpression)
+	assert.True(t, ok)
+	assert.Equal(t, token.TOKEN_PLUS_ASSIGNMENT, exp.Token.Type)
+	assert.IsType(t, &ast.Index{}, exp.Left)
+	assert.Equal(t, "(1 * 2)", exp.Right.TokenLiteral())
+	node = p.NextNode()
+	assert.Equal(t, "(m.k %= 3)", node.TokenLiteral())
+	node = p.NextNode()
+	assert.Equal(t, "(x++)", node.TokenLiteral())
+	assert.Nil(t, p.NextNode())
+}
+
+func TestParser_PostfixExpression(t *testing.T) {
+	tests := map[string]string{
+		"x++":       "(x++)",
+		"m.k++ + 1": "((m.k++) + 1)",
+		"a -- 2":    "(a - (-2))",
+		"a ++ 2":    "(a + (+2))",
+		"a--2":      "(a - (-2))",
+		"m.k -- 2":  "(m.k - (-2))",
+		"5--3":      "(5 - (-3))",
+		"a--b":      "(a - (-b))",
+		"a - -b":    "(a - (-b))",
+		"(a)--1":    "(a - (-1))",
+		"--x":       "(-(-x))",
+	}
+	for input, expected := range tests {
+		lex := token.NewLexer(input)
+		p := NewParser(&lex)
+		assert.Equal(t, expected, p.NextNode().TokenLiteral(), input)
+		assert.Nil(t, p.NextNode(), input)
+	}
+}
+
+func TestParser_WhileStatement(t *testing.T) {
+	str := `while (x < 3) { x = x + 1; y; }`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	node := p.NextNode()
+	s, ok := node.(*ast.WhileStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "(x < 3)", s.Condition.TokenLiteral())
+	assert.Len(t, s.Body, 2)
+	assert.Nil(t, p.NextNode())
+}
+
+func TestParser_ImportExport(t *testing.T) {
+	str := `import "lib/util.mk" as util; export let x = 1;`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	imp, ok := p.NextNode().(*ast.ImportStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "lib/util.mk", imp.Path.Value)
+	assert.Equal(t, "util", imp.Alias.TokenLiteral())
+	exp, ok := p.NextNode().(*ast.ExportStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "x", exp.Statement.Name.TokenLiteral())
+	assert.Nil(t, p.NextNode())
+}
+
+func TestParser_TypeAnnotations(t *testing.T) {
+	str := `let x: int = 1; fn(a: string, b, ...c: int): bool {}; let f: fn = fn() {};`
+	lex := token.NewLexer(str)
+	p := NewParser(&lex)
+	l := p.NextNode().(*ast.LetStatement)
+	assert.Equal(t, "int", l.Type.TokenLiteral())
+	fn := p.NextNode().(*ast.Function)
+	assert.Equal(t, "string", fn.Params[0].Type.TokenLiteral())
+	assert.Nil(t, fn.Params[1].Type)
+	assert.Equal(t, "int", fn.Params[2].Type.TokenLiteral())
+	assert.Equal(t, "bool", fn.ReturnType.TokenLiteral())
+	l = p.NextNode().(*ast.LetStatement)
+	assert.Equal(t, "fn", l.Type.TokenLiteral())
+	assert.Nil(t, l.Value.(*ast.Function).ReturnType)
+
+	lex = token.NewLexer(`let x: 1 = 1;`)
+	p = NewParser(&lex)
+	assert.Panics(t, func() { p.NextNode() })
+}
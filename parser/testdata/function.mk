@@ -0,0 +1,8 @@
+let f = fn(a, b = 2, ...more) {
+	if (a > b) {
+		return a;
+	}
+	a += b;
+	return match (a) { 0 => true, n if n < 3 => more[0], _ => false };
+};
+f(1, b: -2);
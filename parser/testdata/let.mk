@@ -0,0 +1,3 @@
+let x = 1 + 2 * 3;
+let [a, ...rest] = [x, "y"];
+let big = 123456789012345678901234567890;
@@ -0,0 +1,4 @@
+let greet = fn(name: string, times: int = 1): string {
+	return name;
+};
+let n: int = 2;
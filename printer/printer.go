@@ -0,0 +1,570 @@
+// Package printer turns ASTs back into canonically formatted Monkey source.
+package printer
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"strings"
+)
+
+type printer struct {
+	sb       strings.Builder
+	indent   int
+	comments []token.Comment
+	lines    []string
+}
+
+// Print returns the canonical source for node. A program is printed one
+// terminated statement per line, an expression without a terminator.
+func Print(node ast.Node) string {
+	p := &printer{}
+	switch node := node.(type) {
+	case *ast.Program:
+		p.statements(node.Statements, 0)
+	case ast.Expression:
+		p.expr(node, 0)
+	default:
+		p.statement(node)
+	}
+	return p.sb.String()
+}
+
+// Format parses source and prints it back canonically. Comments and single
+// blank lines between statements are kept.
+func Format(source string) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	lex := token.NewLexer(source)
+	par := parser.NewParser(&lex)
+	program := par.ParseProgram()
+	p := &printer{comments: lex.Comments(), lines: strings.Split(source, "\n")}
+	p.statements(program.Statements, 0)
+	p.pendingComments(0, len(program.Statements) == 0)
+	return p.sb.String(), nil
+}
+
+func (p *printer) write(s string) {
+	p.sb.WriteString(s)
+}
+
+func (p *printer) writeIndent() {
+	p.write(strings.Repeat("\t", p.indent))
+}
+
+// blankBefore reports whether the source line above line is empty.
+func (p *printer) blankBefore(line int) bool {
+	return line >= 2 && line-2 < len(p.lines) && strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// pendingComments prints, each on its own line, the comments that come
+// before line, or all remaining comments if line is 0. It reports whether
+// nothing was printed, so callers know if they are still at the top of a
+// block.
+func (p *printer) pendingComments(line int, first bool) bool {
+	for len(p.comments) > 0 && (line == 0 || p.comments[0].Line < line) {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+		if !first && p.blankBefore(comment.Line) {
+			p.write("\n")
+		}
+		p.writeIndent()
+		p.write(comment.Text)
+		p.write("\n")
+		first = false
+	}
+	return first
+}
+
+func (p *printer) hasCommentsBefore(line int) bool {
+	return len(p.comments) > 0 && p.comments[0].Line < line
+}
+
+func (p *printer) statements(nodes []ast.Node, endLine int) {
+	first := true
+	for i, node := range nodes {
+		line := ast.Start(node).Line
+		first = p.pendingComments(line, first)
+		if !first && p.blankBefore(line) {
+			p.write("\n")
+		}
+		p.writeIndent()
+		p.statement(node)
+
+		// Comments up to the end of the statement's last line belong to
+		// it, unless something else follows on that line.
+		last := lastLine(node)
+		next := endLine
+		if i+1 < len(nodes) {
+			next = ast.Start(nodes[i+1]).Line
+		}
+		p.trailingComment(last, next)
+		p.write("\n")
+		if next == 0 || next > last+1 {
+			p.pendingComments(last+1, false)
+		} else {
+			p.pendingComments(next, false)
+		}
+		first = false
+	}
+	if endLine > 0 {
+		p.pendingComments(endLine, first)
+	}
+}
+
+// trailingComment prints the comment on line last, the last line of
+// something just printed, unless the next thing, starting on line next,
+// follows on that line too. next is 0 if nothing follows.
+func (p *printer) trailingComment(last int, next int) {
+	if (next == 0 || next > last) && len(p.comments) > 0 && p.comments[0].Line == last {
+		p.write(" ")
+		p.write(p.comments[0].Text)
+		p.comments = p.comments[1:]
+	}
+}
+
+// list prints the n items of a list between open and close, separated by
+// commas. A list with comments between or beside its items is printed one
+// item per line, so that each comment stays next to the item it was written
+// by. lines returns the first and last line of an item.
+func (p *printer) list(open token.Token, close token.Token, n int, lines func(i int) (int, int), item func(i int)) {
+	if !p.listHasComments(open, close, n, lines) {
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				p.write(", ")
+			}
+			item(i)
+		}
+		return
+	}
+	p.write("\n")
+	p.indent++
+	first := true
+	for i := 0; i < n; i++ {
+		start, last := lines(i)
+		first = p.pendingComments(start, first)
+		p.writeIndent()
+		item(i)
+		next := close.Line
+		if i+1 < n {
+			p.write(",")
+			next, _ = lines(i + 1)
+		}
+		p.trailingComment(last, next)
+		p.write("\n")
+		first = false
+	}
+	p.pendingComments(close.Line, first)
+	p.indent--
+	p.writeIndent()
+}
+
+// listHasComments reports whether there are comments in a list that are
+// not inside one of its items. Comments from the first line of an item up
+// to its last are left for the item to print.
+func (p *printer) listHasComments(open token.Token, close token.Token, n int, lines func(i int) (int, int)) bool {
+	for _, comment := range p.comments {
+		if comment.Line >= close.Line {
+			break
+		}
+		if comment.Line < open.Line {
+			continue
+		}
+		inside := false
+		for i := 0; i < n && !inside; i++ {
+			start, last := lines(i)
+			inside = start <= comment.Line && comment.Line < last
+		}
+		if !inside {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) block(nodes []ast.Node, end token.Token) {
+	if len(nodes) == 0 && !p.hasCommentsBefore(end.Line) {
+		p.write("{}")
+		return
+	}
+	p.write("{\n")
+	p.indent++
+	p.statements(nodes, end.Line)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+func (p *printer) statement(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		p.let(node)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expr(node.Value, 0)
+		p.write(";")
+	case *ast.ImportStatement:
+		p.write(fmt.Sprintf("import \"%s\" as %s;", node.Path.Value, node.Alias.TokenLiteral()))
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.let(node.Statement)
+		p.write(";")
+	case *ast.IfStatement:
+		p.write("if (")
+		p.expr(node.Condition, 0)
+		p.write(") ")
+		p.block(node.Then, node.ThenRBrace)
+		if len(node.Else) > 0 || node.ElseRBrace.Type == token.TOKEN_RBRACE {
+			p.write(" else ")
+			p.block(node.Else, node.ElseRBrace)
+		}
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expr(node.Condition, 0)
+		p.write(") ")
+		p.block(node.Body, node.RBrace)
+	case ast.Expression:
+		p.expr(node, 0)
+		p.write(";")
+	default:
+		panic(fmt.Sprintf("cannot print %T", node))
+	}
+}
+
+func (p *printer) let(node *ast.LetStatement) {
+	if node.IsConst() {
+		p.write("const ")
+	} else {
+		p.write("let ")
+	}
+	p.pattern(node.Name)
+	p.typeAnnotation(node.Type)
+	p.write(" = ")
+	p.expr(node.Value, 0)
+}
+
+// atomic binds tighter than any operator, so it never needs parentheses.
+const atomic = parser.PRECEDENCE_CALL + 1
+
+func precedence(expr ast.Expression) parser.Precedence {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		if precedence, ok := parser.OperatorPrecedence(expr.Token.Type); ok {
+			return precedence
+		}
+	case *ast.PrefixExpression:
+		return parser.PRECEDENCE_PREFIX
+	}
+	return atomic
+}
+
+// expr prints expr, wrapped in parentheses if it binds looser than min.
+func (p *printer) expr(expr ast.Expression, min parser.Precedence) {
+	if precedence(expr) < min {
+		p.write("(")
+		defer p.write(")")
+	}
+	switch expr := expr.(type) {
+	case *ast.Identifier, *ast.NumberLiteral:
+		p.write(expr.TokenLiteral())
+	case *ast.Boolean:
+		p.write(fmt.Sprintf("%t", expr.Value))
+	case *ast.String:
+		p.write(fmt.Sprintf("\"%s\"", expr.Value))
+	case *ast.Template:
+		p.write("`")
+		for i, text := range expr.Text {
+			p.write(escapeTemplateText(text))
+			if i < len(expr.Values) {
+				p.write("${")
+				p.expr(expr.Values[i], 0)
+				p.write("}")
+			}
+		}
+		p.write("`")
+	case *ast.InfixExpression:
+		precedence := precedence(expr)
+		p.expr(expr.Left, precedence)
+		p.write(fmt.Sprintf(" %s ", expr.Token.Literal))
+		p.expr(expr.Right, precedence+1)
+	case *ast.PrefixExpression:
+		p.write(expr.Token.Literal)
+		if right, ok := expr.Right.(*ast.PrefixExpression); ok && right.Token.Literal == expr.Token.Literal {
+			// keep "- -x" from being read back as "--x"
+			p.write("(")
+			p.expr(expr.Right, 0)
+			p.write(")")
+		} else {
+			p.expr(expr.Right, parser.PRECEDENCE_PREFIX)
+		}
+	case *ast.PostfixExpression:
+		p.expr(expr.Left, atomic)
+		p.write(expr.Token.Literal)
+	case *ast.FunctionCall:
+		p.expr(expr.FunctionExpr, atomic)
+		p.write("(")
+		p.exprs(expr.Token, expr.RParen, expr.Arguments)
+		p.write(")")
+	case *ast.Index:
+		p.expr(expr.Left, atomic)
+		p.write("[")
+		p.expr(expr.Index, 0)
+		p.write("]")
+	case *ast.Slice:
+		p.expr(expr.Left, atomic)
+		p.write("[")
+		if expr.Start != nil {
+			p.expr(expr.Start, 0)
+		}
+		p.write(":")
+		if expr.End != nil {
+			p.expr(expr.End, 0)
+		}
+		if expr.Step != nil {
+			p.write(":")
+			p.expr(expr.Step, 0)
+		}
+		p.write("]")
+	case *ast.Dot:
+		p.expr(expr.Left, atomic)
+		p.write(".")
+		p.write(expr.Name.TokenLiteral())
+	case *ast.Spread:
+		p.write("...")
+		p.expr(expr.Value, 0)
+	case *ast.KeywordArgument:
+		p.write(expr.Name.TokenLiteral())
+		p.write(": ")
+		p.expr(expr.Value, 0)
+	case *ast.Array:
+		p.write("[")
+		p.exprs(expr.Token, expr.RBracket, expr.Elements)
+		p.write("]")
+	case *ast.Map:
+		p.write("{")
+		p.list(expr.Token, expr.RBrace, len(expr.Pairs), func(i int) (int, int) {
+			return ast.Start(expr.Pairs[i][0]).Line, lastLine(expr.Pairs[i][1])
+		}, func(i int) {
+			p.expr(expr.Pairs[i][0], 0)
+			p.write(": ")
+			p.expr(expr.Pairs[i][1], 0)
+		})
+		p.write("}")
+	case *ast.Function:
+		p.write("fn(")
+		for i, param := range expr.Params {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.param(param)
+		}
+		p.write(")")
+		p.typeAnnotation(expr.ReturnType)
+		p.write(" ")
+		p.block(expr.Body, expr.RBrace)
+	case *ast.MatchExpression:
+		p.match(expr)
+	default:
+		panic(fmt.Sprintf("cannot print %T", expr))
+	}
+}
+
+func (p *printer) exprs(open token.Token, close token.Token, exprs []ast.Expression) {
+	p.list(open, close, len(exprs), func(i int) (int, int) {
+		return ast.Start(exprs[i]).Line, lastLine(exprs[i])
+	}, func(i int) {
+		p.expr(exprs[i], 0)
+	})
+}
+
+func (p *printer) typeAnnotation(t *ast.TypeAnnotation) {
+	if t != nil {
+		p.write(": " + t.TokenLiteral())
+	}
+}
+
+func (p *printer) param(param *ast.Parameter) {
+	if param.Variadic {
+		p.write("...")
+	}
+	p.pattern(param.Pattern)
+	p.typeAnnotation(param.Type)
+	if param.Default != nil {
+		p.write(" = ")
+		p.expr(param.Default, 0)
+	}
+}
+
+func (p *printer) match(m *ast.MatchExpression) {
+	p.write("match (")
+	p.expr(m.Value, 0)
+	p.write(") {\n")
+	p.indent++
+	first := true
+	for i, arm := range m.Arms {
+		line := ast.Start(arm.Pattern).Line
+		first = p.pendingComments(line, first)
+		p.writeIndent()
+		p.pattern(arm.Pattern)
+		if arm.Guard != nil {
+			p.write(" if ")
+			p.expr(arm.Guard, 0)
+		}
+		p.write(" => ")
+		p.expr(arm.Body, 0)
+		p.write(",")
+		next := m.RBrace.Line
+		if i+1 < len(m.Arms) {
+			next = ast.Start(m.Arms[i+1].Pattern).Line
+		}
+		p.trailingComment(lastLine(arm.Body), next)
+		p.write("\n")
+		first = false
+	}
+	p.pendingComments(m.RBrace.Line, first)
+	p.indent--
+	p.writeIndent()
+	p.write("}")
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, element := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(element)
+		}
+		if pattern.Rest != nil {
+			if len(pattern.Elements) > 0 {
+				p.write(", ")
+			}
+			p.write("...")
+			p.write(pattern.Rest.TokenLiteral())
+		}
+		p.write("]")
+	case *ast.MapPattern:
+		p.write("{")
+		for i, pair := range pattern.Pairs {
+			if i > 0 {
+				p.write(", ")
+			}
+			key, isString := pair.Key.(*ast.String)
+			value, isIdent := pair.Value.(*ast.Identifier)
+			if isString && isIdent && key.Value == value.TokenLiteral() {
+				p.write(value.TokenLiteral())
+				continue
+			}
+			p.expr(pair.Key, 0)
+			p.write(": ")
+			p.pattern(pair.Value)
+		}
+		p.write("}")
+	case ast.Expression:
+		p.expr(pattern, 0)
+	default:
+		panic(fmt.Sprintf("cannot print %T", pattern))
+	}
+}
+
+// escapeTemplateText escapes the characters of the text of a template string
+// that the lexer would otherwise take for the end of the text: backticks, $
+// before {, and backslashes before those or at the end.
+func escapeTemplateText(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		var next byte
+		if i+1 < len(text) {
+			next = text[i+1]
+		}
+		switch {
+		case ch == '`', ch == '$' && next == '{', ch == '\\' && (next == '`' || next == '$' || next == '\\' || next == 0):
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(ch)
+	}
+	return sb.String()
+}
+
+// lastLine returns the line of the last token of node, as far as the AST
+// records it.
+func lastLine(node ast.Node) int {
+	line := ast.Start(node).Line
+	var last ast.Node
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		last = node.Value
+	case *ast.ReturnStatement:
+		last = node.Value
+	case *ast.ExportStatement:
+		last = node.Statement
+	case *ast.ImportStatement:
+		last = node.Alias
+	case *ast.IfStatement:
+		if node.ElseRBrace.Line > 0 {
+			return node.ElseRBrace.Line
+		}
+		return node.ThenRBrace.Line
+	case *ast.WhileStatement:
+		return node.RBrace.Line
+	case *ast.Function:
+		return node.RBrace.Line
+	case *ast.Template:
+		return node.End.Line
+	case *ast.MatchExpression:
+		return node.RBrace.Line
+	case *ast.InfixExpression:
+		last = node.Right
+	case *ast.PrefixExpression:
+		last = node.Right
+	case *ast.Index:
+		last = node.Index
+	case *ast.Slice:
+		for _, expr := range []ast.Expression{node.Start, node.End, node.Step} {
+			if expr != nil {
+				last = expr
+			}
+		}
+	case *ast.Dot:
+		last = node.Name
+	case *ast.Spread:
+		last = node.Value
+	case *ast.KeywordArgument:
+		last = node.Value
+	case *ast.FunctionCall:
+		if node.RParen.Line > 0 {
+			return node.RParen.Line
+		}
+		if len(node.Arguments) > 0 {
+			last = node.Arguments[len(node.Arguments)-1]
+		}
+	case *ast.Array:
+		if node.RBracket.Line > 0 {
+			return node.RBracket.Line
+		}
+		if len(node.Elements) > 0 {
+			last = node.Elements[len(node.Elements)-1]
+		}
+	case *ast.Map:
+		if node.RBrace.Line > 0 {
+			return node.RBrace.Line
+		}
+		if len(node.Pairs) > 0 {
+			last = node.Pairs[len(node.Pairs)-1][1]
+		}
+	}
+	if last != nil {
+		if lastLine := lastLine(last); lastLine > line {
+			return lastLine
+		}
+	}
+	return line
+}
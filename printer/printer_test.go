@@ -0,0 +1,119 @@
+package printer
+
+import (
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func parse(input string) *ast.Program {
+	lex := token.NewLexer(input)
+	p := parser.NewParser(&lex)
+	return p.ParseProgram()
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// clearPositions zeroes every token position reachable from v, so that ASTs
+// parsed from differently laid out source compare equal.
+func clearPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			clearPositions(v.Elem())
+		}
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			v.FieldByName("Line").SetInt(0)
+			v.FieldByName("Column").SetInt(0)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			clearPositions(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			clearPositions(v.Index(i))
+		}
+	}
+}
+
+func TestPrint_RoundTrip(t *testing.T) {
+	inputs := []string{
+		`let x = 1 + 2 * 3 - 4 / 5 % 6;`,
+		`(1 + 2) * 3; 1 - (2 - 3); (1 - 2) - 3; 1 < 2 == (3 > 4);`,
+		`-1 + -(2 + 3); !true; - -1; -(-x); !(!x); -f(x).y[0]; a[0]++;`,
+		`x = y = 1; a[i] += 2; m.k %= 3; x++; y--;`,
+		`let f = fn(x, [a, b], {name, "k": v}, y = 1 + 1, ...rest) { return x; };`,
+		`f(1, ...xs, y: 2)(3); (fn() {})(); fn() { return 1; }(); (a + b)(1);`,
+		`[1, "two", [true, false], {}]; {"a": 1, 2: [3]}; m.a.b; a[0][1];`,
+		`if (x) { 1; } else { 2; } if (y) {} while (i < 10) { i++; }`,
+		`if (x) { if (y) { return 1; } } else {}`,
+		`match (x) { 1 => "one", -1 => "neg", [a, ...r] if a > 0 => r, {"k": [_, z]} => z, {name} => name, _ => 0 }`,
+		`import "lib/a.mk" as a; export let [p, q] = a.pair();`,
+		`let [...all] = xs; let {} = m;`,
+		`let x: int = 1; let f = fn(a: string, [b]: array = [], ...c: int): bool { return true; }; let g: fn = fn(): any {};`,
+		"`a ${x + 1} b`; ``; `${`${y}`}${z}`; `\\` \\${ $x $ \\\\`; f(`line\n${m[\"k\"]}`);",
+		`a[1:2]; a[:]; a[::-1]; s[i + 1:][0]; a[:n:2]; a[-1] = 1;`,
+		`const x = 1; const [a, ...b]: array = xs; export const f = fn() {};`,
+	}
+	for _, input := range inputs {
+		expected := parse(input)
+		printed := Print(expected)
+		actual := parse(printed)
+		clearPositions(reflect.ValueOf(expected))
+		clearPositions(reflect.ValueOf(actual))
+		assert.Equal(t, expected, actual, printed)
+		assert.Equal(t, printed, Print(actual))
+	}
+}
+
+func TestPrint(t *testing.T) {
+	tests := [][]string{
+		{`let   x=1+2*3`, "let x = 1 + 2 * 3;\n"},
+		{`(1+2)*3;1-(2-3)`, "(1 + 2) * 3;\n1 - (2 - 3);\n"},
+		{`let f=fn(x,y=2,...r){if(x){return y}else{return r}}`, "let f = fn(x, y = 2, ...r) {\n\tif (x) {\n\t\treturn y;\n\t} else {\n\t\treturn r;\n\t}\n};\n"},
+		{`fn(){}`, "fn() {};\n"},
+		{`match(x){1=>2,_=>3}`, "match (x) {\n\t1 => 2,\n\t_ => 3,\n};\n"},
+		{`while(true){}`, "while (true) {}\n"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], Print(parse(test[0])))
+	}
+	assert.Equal(t, "x + 1", Print(parse("x+1").Statements[0]))
+	assert.Equal(t, "let x = 1;", Print(parse("let x=1").Statements[0]))
+}
+
+func TestFormat_Comments(t *testing.T) {
+	tests := [][]string{
+		{"// header\n\nlet x = 1; // one\n// about y\nlet y = 2;\n", "// header\n\nlet x = 1; // one\n// about y\nlet y = 2;\n"},
+		{"let f = fn() {\n  // inside\n  x;\n  // at end\n};\n// after\nf();", "let f = fn() {\n\t// inside\n\tx;\n\t// at end\n};\n// after\nf();\n"},
+		{"if (x) {\n// only\n}", "if (x) {\n\t// only\n}\n"},
+		{"a; b; // c\n", "a;\nb; // c\n"},
+		{"let m = match (x) {\n  // first\n  1 => 2, // one\n  _ => 3 // rest\n};", "let m = match (x) {\n\t// first\n\t1 => 2, // one\n\t_ => 3, // rest\n};\n"},
+		{"let a = [\n  1, // one\n  // about two\n  2,\n  3 // three\n];", "let a = [\n\t1, // one\n\t// about two\n\t2,\n\t3 // three\n];\n"},
+		{"let m = {\n  \"a\": 1, // ma\n  \"b\": [2,\n    3]\n};", "let m = {\n\t\"a\": 1, // ma\n\t\"b\": [2, 3]\n};\n"},
+		{"f(\n  a, // first\n  b\n  // done\n);", "f(\n\ta, // first\n\tb\n\t// done\n);\n"},
+		{"let a = [[\n  1 // one\n], 2];\nlet b = [\n  // none yet\n];", "let a = [[\n\t1 // one\n], 2];\nlet b = [\n\t// none yet\n];\n"},
+		{"map(xs, fn(x) {\n  // double\n  x * 2;\n}); // c\nlet a = [1,\n  2]; // d", "map(xs, fn(x) {\n\t// double\n\tx * 2;\n}); // c\nlet a = [1, 2]; // d\n"},
+		{"a;\n\n\n\nb;", "a;\n\nb;\n"},
+		{"// only a comment", "// only a comment\n"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		result, err := Format(test[0])
+		assert.NoError(t, err)
+		assert.Equal(t, test[1], result)
+		again, err := Format(result)
+		assert.NoError(t, err)
+		assert.Equal(t, result, again)
+	}
+}
+
+func TestFormat_Error(t *testing.T) {
+	_, err := Format("let = 1;")
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// WritePprof writes the profile as a gzipped profile.proto message, the
+// format read by go tool pprof. Each function is a location of its own, and
+// each sample is the calls and self time of one call stack, from which pprof
+// works out cumulative times.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	strings := &stringTable{index: map[string]int64{"": 0}, strings: []string{""}}
+	var b protoBuffer
+
+	valueType := func(typ string, unit string) []byte {
+		var vt protoBuffer
+		vt.int64Field(1, strings.add(typ))
+		vt.int64Field(2, strings.add(unit))
+		return vt.bytes
+	}
+	b.bytesField(1, valueType("calls", "count"))
+	b.bytesField(1, valueType("time", "nanoseconds"))
+
+	functions := p.sorted()
+	ids := make(map[*Function]uint64)
+	for i, f := range functions {
+		ids[f] = uint64(i + 1)
+	}
+
+	var samples []*sample
+	for _, s := range p.samples {
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i], samples[j]
+		for k := 0; k < len(a.stack) && k < len(b.stack); k++ {
+			if a.stack[k] != b.stack[k] {
+				return ids[a.stack[k]] < ids[b.stack[k]]
+			}
+		}
+		return len(a.stack) < len(b.stack)
+	})
+	for _, s := range samples {
+		var sb protoBuffer
+		var locations []uint64
+		for _, f := range s.stack {
+			locations = append(locations, ids[f])
+		}
+		sb.packedUint64Field(1, locations)
+		sb.packedInt64Field(2, []int64{int64(s.calls), int64(s.self)})
+		b.bytesField(2, sb.bytes)
+	}
+
+	for i, f := range functions {
+		id := uint64(i + 1)
+		var line protoBuffer
+		line.uint64Field(1, id)
+		line.int64Field(2, int64(f.Line))
+		var location protoBuffer
+		location.uint64Field(1, id)
+		location.bytesField(4, line.bytes)
+		b.bytesField(4, location.bytes)
+	}
+	for i, f := range functions {
+		var function protoBuffer
+		function.uint64Field(1, uint64(i+1))
+		function.int64Field(2, strings.add(f.Name))
+		function.int64Field(3, strings.add(f.Name))
+		function.int64Field(4, strings.add(f.Path))
+		function.int64Field(5, int64(f.Line))
+		b.bytesField(5, function.bytes)
+	}
+
+	timeType := strings.add("time")
+	end := p.end
+	if end.IsZero() {
+		end = p.now()
+	}
+	b.int64Field(9, p.start.UnixNano())
+	b.int64Field(10, int64(end.Sub(p.start)))
+	b.bytesField(11, valueType("time", "nanoseconds"))
+	b.int64Field(12, 1)
+	b.int64Field(14, timeType)
+	// The string table goes last, once every string has been added.
+	for _, s := range strings.strings {
+		b.stringField(6, s)
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b.bytes); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type stringTable struct {
+	index   map[string]int64
+	strings []string
+}
+
+func (t *stringTable) add(s string) int64 {
+	if i, ok := t.index[s]; ok {
+		return i
+	}
+	i := int64(len(t.strings))
+	t.index[s] = i
+	t.strings = append(t.strings, s)
+	return i
+}
+
+// protoBuffer encodes the protocol buffer wire format, just enough of it for
+// profile.proto.
+type protoBuffer struct {
+	bytes []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.bytes = append(b.bytes, byte(x)|0x80)
+		x >>= 7
+	}
+	b.bytes = append(b.bytes, byte(x))
+}
+
+func (b *protoBuffer) key(field int, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (b *protoBuffer) uint64Field(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(field int, x int64) {
+	b.uint64Field(field, uint64(x))
+}
+
+func (b *protoBuffer) bytesField(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.bytes = append(b.bytes, data...)
+}
+
+// stringField is always written, even when empty, as the string table
+// depends on the position of each string.
+func (b *protoBuffer) stringField(field int, s string) {
+	b.bytesField(field, []byte(s))
+}
+
+func (b *protoBuffer) packedUint64Field(field int, xs []uint64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytesField(field, packed.bytes)
+}
+
+func (b *protoBuffer) packedInt64Field(field int, xs []int64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	b.bytesField(field, packed.bytes)
+}
@@ -0,0 +1,167 @@
+// Package profile measures how often Monkey functions are called and how
+// long they take, and reports it as a table or in pprof's format.
+package profile
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/object"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Function is what the profiler measured for one function, identified by
+// the literal that defines it.
+type Function struct {
+	// Name is the name the function was declared with, or "fn@line:column"
+	// for an anonymous one.
+	Name   string
+	Path   string
+	Line   int
+	Column int
+	Calls  int
+	// Cumulative is the time spent in calls of the function, including the
+	// functions it called; Self leaves those out.
+	Cumulative time.Duration
+	Self       time.Duration
+}
+
+// sample is the self time of the calls made through one call stack.
+type sample struct {
+	stack []*Function
+	calls int
+	self  time.Duration
+}
+
+type activation struct {
+	fn       *Function
+	start    time.Time
+	children time.Duration
+}
+
+// Profiler implements eval.Hooks, timing each call of a Monkey function.
+// Time spent in builtins counts towards the function calling them.
+type Profiler struct {
+	now       func() time.Time
+	start     time.Time
+	end       time.Time
+	functions map[*ast.Function]*Function
+	// active are the calls in progress, innermost last, and depth the
+	// number of them for each function, so recursive calls only add to
+	// cumulative time once.
+	active  []activation
+	depth   map[*Function]int
+	samples map[string]*sample
+}
+
+func NewProfiler() *Profiler {
+	return newProfiler(time.Now)
+}
+
+func newProfiler(now func() time.Time) *Profiler {
+	return &Profiler{
+		now:       now,
+		start:     now(),
+		functions: make(map[*ast.Function]*Function),
+		depth:     make(map[*Function]int),
+		samples:   make(map[string]*sample),
+	}
+}
+
+// Stop ends the profile, setting its duration.
+func (p *Profiler) Stop() {
+	p.end = p.now()
+}
+
+func (p *Profiler) Before(node ast.Node, env *object.Env) {}
+
+func (p *Profiler) Call(call *ast.FunctionCall, fn object.Function, env *object.Env) {
+	f, ok := p.functions[fn.Definition]
+	if !ok {
+		f = &Function{Name: fn.Name, Path: fn.Path}
+		if fn.Definition != nil {
+			f.Line, f.Column = fn.Definition.Token.Line, fn.Definition.Token.Column
+		}
+		if f.Name == "" {
+			f.Name = fmt.Sprintf("fn@%d:%d", f.Line, f.Column)
+		}
+		p.functions[fn.Definition] = f
+	}
+	f.Calls++
+	p.depth[f]++
+	p.active = append(p.active, activation{fn: f, start: p.now()})
+}
+
+func (p *Profiler) Return(call *ast.FunctionCall, result object.Object) {
+	a := p.active[len(p.active)-1]
+	p.active = p.active[:len(p.active)-1]
+	elapsed := p.now().Sub(a.start)
+	self := elapsed - a.children
+	a.fn.Self += self
+	p.depth[a.fn]--
+	if p.depth[a.fn] == 0 {
+		a.fn.Cumulative += elapsed
+	}
+	if len(p.active) > 0 {
+		p.active[len(p.active)-1].children += elapsed
+	}
+
+	stack := []*Function{a.fn}
+	for i := len(p.active) - 1; i >= 0; i-- {
+		stack = append(stack, p.active[i].fn)
+	}
+	key := fmt.Sprintf("%p", stack[0])
+	for _, f := range stack[1:] {
+		key += fmt.Sprintf(",%p", f)
+	}
+	s, ok := p.samples[key]
+	if !ok {
+		s = &sample{stack: stack}
+		p.samples[key] = s
+	}
+	s.calls++
+	s.self += self
+}
+
+// Functions returns the functions that were called, those with the most self
+// time first.
+func (p *Profiler) Functions() []Function {
+	var functions []Function
+	for _, f := range p.sorted() {
+		functions = append(functions, *f)
+	}
+	return functions
+}
+
+func (p *Profiler) sorted() []*Function {
+	var functions []*Function
+	for _, f := range p.functions {
+		functions = append(functions, f)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		a, b := functions[i], functions[j]
+		if a.Self != b.Self {
+			return a.Self > b.Self
+		} else if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Path < b.Path || a.Path == b.Path && (a.Line < b.Line || a.Line == b.Line && a.Column < b.Column)
+	})
+	return functions
+}
+
+// WriteReport writes a table of the functions that were called.
+func (p *Profiler) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "calls\tcumulative\tself\t function")
+	for _, f := range p.Functions() {
+		location := fmt.Sprintf("%d:%d", f.Line, f.Column)
+		if f.Path != "" {
+			location = f.Path + ":" + location
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t %s %s\n", f.Calls, f.Cumulative, f.Self, f.Name, location)
+	}
+	return tw.Flush()
+}
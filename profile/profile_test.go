@@ -0,0 +1,77 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+const source = `let f = fn(n) { if (n == 0) { return 0; } return f(n - 1); };
+f(2);
+let apply = fn(g) { return g(1); };
+apply(fn(x) { return x; });
+`
+
+// run profiles source with a clock that moves on a millisecond each time it
+// is read.
+func run(t *testing.T, source string) *Profiler {
+	var clock time.Time
+	p := newProfiler(func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	})
+	lex := token.NewLexer(source)
+	parse := parser.NewParser(&lex)
+	program := parse.ParseProgram()
+	env := object.NewEnv()
+	ev := eval.NewEvaluator(nil, env)
+	ev.SetHooks(p)
+	for _, node := range program.Statements {
+		_, isErr := ev.Eval(node, env).(object.Error)
+		assert.False(t, isErr)
+	}
+	p.Stop()
+	return p
+}
+
+func TestProfiler_Functions(t *testing.T) {
+	p := run(t, source)
+	assert.Equal(t, []Function{
+		{Name: "f", Line: 1, Column: 9, Calls: 3, Cumulative: 5 * time.Millisecond, Self: 5 * time.Millisecond},
+		{Name: "apply", Line: 3, Column: 13, Calls: 1, Cumulative: 3 * time.Millisecond, Self: 2 * time.Millisecond},
+		{Name: "fn@4:7", Line: 4, Column: 7, Calls: 1, Cumulative: time.Millisecond, Self: time.Millisecond},
+	}, p.Functions())
+}
+
+func TestProfiler_WriteReport(t *testing.T) {
+	p := run(t, source)
+	var buf bytes.Buffer
+	assert.NoError(t, p.WriteReport(&buf))
+	assert.Equal(t, `  calls  cumulative  self function
+      3         5ms   5ms f 1:9
+      1         3ms   2ms apply 3:13
+      1         1ms   1ms fn@4:7 4:7
+`, buf.String())
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	p := run(t, source)
+	var buf bytes.Buffer
+	assert.NoError(t, p.WritePprof(&buf))
+	gz, err := gzip.NewReader(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	for _, s := range []string{"calls", "count", "time", "nanoseconds", "f", "apply", "fn@4:7"} {
+		assert.Contains(t, string(data), "\x32"+string(rune(len(s)))+s)
+	}
+}
@@ -3,31 +3,58 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
 	"github.com/carsonip/monkey-interpreter/eval"
 	"github.com/carsonip/monkey-interpreter/object"
 	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/stdlib"
 	"github.com/carsonip/monkey-interpreter/token"
 	"io"
+	"os"
+	"strings"
 )
 
 type Repl struct {}
 
+func isStatement(node ast.Node) bool {
+	_, ok := node.(ast.Statement)
+	return ok
+}
+
 const PROMPT = ">> "
 
 func (r *Repl) Start(in io.Reader, out io.Writer) {
-	env := object.NewEnv()
-	scanner := bufio.NewScanner(in)
+	// As with monkey run, the REPL's own declarations go in a scope of their
+	// own, which imported modules cannot see.
+	globals, loader, err := stdlib.NewGlobals(eval.FSResolver{FS: os.DirFS(".")})
+	if err != nil {
+		fmt.Fprintf(out, "error loading prelude: %s\n", err)
+		return
+	}
+	env := object.NewNestedEnv(globals)
+	// Programs read their input from the same reader as the REPL, so that
+	// neither reads ahead into the other's lines.
+	reader := bufio.NewReader(in)
 	for {
 		fmt.Fprint(out, PROMPT)
-		if ok := scanner.Scan(); !ok {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
 			break
 		}
-		if line := scanner.Text(); line != "" {
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line != "" {
 			lex := token.NewLexer(line)
 			p := parser.NewParser(&lex)
 			ev := eval.NewEvaluator(&p, env)
-			for obj := ev.EvalNext(env); obj != nil; obj = ev.EvalNext(env) {
-				fmt.Fprintf(out, "%s\n", obj)
+			ev.SetModuleLoader(loader, "")
+			ev.SetStdio(reader, out, out)
+			// Expressions are echoed as they would be written in code;
+			// statements only if they fail.
+			for node := p.NextNode(); node != nil; node = p.NextNode() {
+				obj := ev.Eval(node, env)
+				if _, isError := obj.(object.Error); isError || !isStatement(node) {
+					fmt.Fprintf(out, "%s\n", obj.Inspect())
+				}
 			}
 		}
 	}
@@ -0,0 +1,9 @@
+// The prelude is evaluated into the global scope before any user code.
+// Names starting with _ are its own and not meant for programs.
+
+import "std/list.mk" as _list;
+
+let map = _list.map;
+let filter = _list.filter;
+let reduce = _list.reduce;
+let range = _list.range;
@@ -0,0 +1,52 @@
+// Combinators for building functions out of other functions.
+
+export let identity = fn(x) {
+	return x;
+};
+
+export let constant = fn(x) {
+	return fn(..._args) {
+		return x;
+	};
+};
+
+// compose(f, g)(x) is f(g(x)).
+export let compose = fn(f, g) {
+	return fn(...args) {
+		return f(g(...args));
+	};
+};
+
+// pipe(f, g, h)(x) is h(g(f(x))).
+export let pipe = fn(...fns) {
+	return fn(x) {
+		let i = 0;
+		while (i < len(fns)) {
+			x = fns[i](x);
+			i++;
+		}
+		return x;
+	};
+};
+
+export let partial = fn(f, ...bound) {
+	return fn(...args) {
+		let all = bound;
+		let i = 0;
+		while (i < len(args)) {
+			all = push(all, args[i]);
+			i++;
+		}
+		return f(...all);
+	};
+};
+
+export let flip = fn(f) {
+	return fn(a, b) {
+		return f(b, a);
+	};
+};
+
+export let apply = fn(f, args) {
+	return f(...args);
+};
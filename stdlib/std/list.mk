@@ -0,0 +1,110 @@
+// Functions over arrays. None of them modify their arguments.
+
+export let map = fn(arr, f) {
+	let result = [];
+	let i = 0;
+	while (i < len(arr)) {
+		result = push(result, f(arr[i]));
+		i++;
+	}
+	return result;
+};
+
+export let filter = fn(arr, pred) {
+	let result = [];
+	let i = 0;
+	while (i < len(arr)) {
+		if (pred(arr[i])) {
+			result = push(result, arr[i]);
+		}
+		i++;
+	}
+	return result;
+};
+
+export let reduce = fn(arr, f, initial) {
+	let acc = initial;
+	let i = 0;
+	while (i < len(arr)) {
+		acc = f(acc, arr[i]);
+		i++;
+	}
+	return acc;
+};
+
+export let each = fn(arr, f) {
+	let i = 0;
+	while (i < len(arr)) {
+		f(arr[i]);
+		i++;
+	}
+};
+
+// range returns the integers from start up to but not including end, or
+// down to it if step is negative.
+export let range = fn(start, end, step = 1) {
+	assert(step != 0, "range step cannot be zero");
+	let result = [];
+	let i = start;
+	if (step > 0) {
+		while (i < end) {
+			result = push(result, i);
+			i += step;
+		}
+	} else {
+		while (i > end) {
+			result = push(result, i);
+			i += step;
+		}
+	}
+	return result;
+};
+
+export let first = fn(arr) {
+	return arr[0];
+};
+
+export let last = fn(arr) {
+	return arr[len(arr) - 1];
+};
+
+export let rest = fn(arr) {
+	let result = [];
+	let i = 1;
+	while (i < len(arr)) {
+		result = push(result, arr[i]);
+		i++;
+	}
+	return result;
+};
+
+export let reverse = fn(arr) {
+	let result = [];
+	let i = len(arr);
+	while (i > 0) {
+		i--;
+		result = push(result, arr[i]);
+	}
+	return result;
+};
+
+export let concat = fn(a, b) {
+	return reduce(b, push, a);
+};
+
+export let contains = fn(arr, x) {
+	let i = 0;
+	while (i < len(arr)) {
+		if (arr[i] == x) {
+			return true;
+		}
+		i++;
+	}
+	return false;
+};
+
+export let sum = fn(arr) {
+	return reduce(arr, fn(a, b) {
+		return a + b;
+	}, 0);
+};
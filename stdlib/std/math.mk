@@ -0,0 +1,66 @@
+// Integer math.
+
+export let abs = fn(x) {
+	if (x < 0) {
+		return 0 - x;
+	}
+	return x;
+};
+
+export let sign = fn(x) {
+	if (x < 0) {
+		return 0 - 1;
+	}
+	if (x > 0) {
+		return 1;
+	}
+	return 0;
+};
+
+export let min = fn(first, ...rest) {
+	let result = first;
+	let i = 0;
+	while (i < len(rest)) {
+		if (rest[i] < result) {
+			result = rest[i];
+		}
+		i++;
+	}
+	return result;
+};
+
+export let max = fn(first, ...rest) {
+	let result = first;
+	let i = 0;
+	while (i < len(rest)) {
+		if (rest[i] > result) {
+			result = rest[i];
+		}
+		i++;
+	}
+	return result;
+};
+
+export let clamp = fn(x, lo, hi) {
+	return min(max(x, lo), hi);
+};
+
+export let pow = fn(base, exp) {
+	let result = 1;
+	while (exp > 0) {
+		result *= base;
+		exp--;
+	}
+	return result;
+};
+
+export let gcd = fn(a, b) {
+	a = abs(a);
+	b = abs(b);
+	while (b != 0) {
+		let t = b;
+		b = a % b;
+		a = t;
+	}
+	return a;
+};
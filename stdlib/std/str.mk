@@ -0,0 +1,46 @@
+// Functions over strings. Like len, indexing and slicing, they count
+// characters, which are Unicode code points however many bytes they take.
+
+export let join = fn(arr, sep = "") {
+	let result = "";
+	let i = 0;
+	while (i < len(arr)) {
+		if (i > 0) {
+			result += sep;
+		}
+		result += arr[i];
+		i++;
+	}
+	return result;
+};
+
+export let repeat = fn(s, n) {
+	let result = "";
+	while (n > 0) {
+		result += s;
+		n--;
+	}
+	return result;
+};
+
+export let reverse = fn(s) {
+	return s[::-1];
+};
+
+export let isEmpty = fn(s) {
+	return len(s) == 0;
+};
+
+export let padLeft = fn(s, width, pad = " ") {
+	while (len(s) < width) {
+		s = pad + s;
+	}
+	return s;
+};
+
+export let padRight = fn(s, width, pad = " ") {
+	while (len(s) < width) {
+		s += pad;
+	}
+	return s;
+};
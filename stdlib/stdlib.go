@@ -0,0 +1,69 @@
+// Package stdlib embeds the Monkey standard library: a prelude evaluated into
+// the global scope, and modules importable as "std/<name>.mk".
+package stdlib
+
+import (
+	"embed"
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"strings"
+)
+
+//go:embed prelude.mk std/*.mk
+var FS embed.FS
+
+const prefix = "std/"
+
+// Resolver serves "std/" imports, and relative imports between standard
+// library modules, from FS and passes everything else on to Fallback.
+type Resolver struct {
+	Fallback eval.ModuleResolver
+}
+
+func (r Resolver) Resolve(from string, importPath string) (string, string, error) {
+	if strings.HasPrefix(importPath, prefix) {
+		return eval.FSResolver{FS: FS}.Resolve("", importPath)
+	}
+	if strings.HasPrefix(from, prefix) {
+		return eval.FSResolver{FS: FS}.Resolve(from, importPath)
+	}
+	if r.Fallback == nil {
+		return "", "", fmt.Errorf("module %s not found", importPath)
+	}
+	return r.Fallback.Resolve(from, importPath)
+}
+
+// LoadPrelude evaluates the prelude into env. loader must be able to resolve
+// standard library imports.
+func LoadPrelude(env *object.Env, loader *eval.ModuleLoader) error {
+	source, err := FS.ReadFile("prelude.mk")
+	if err != nil {
+		return err
+	}
+	lex := token.NewLexer(string(source))
+	p := parser.NewParser(&lex)
+	ev := eval.NewEvaluator(&p, env)
+	ev.SetModuleLoader(loader, "prelude.mk")
+	for obj := ev.EvalNext(env); obj != nil; obj = ev.EvalNext(env) {
+		if err, ok := obj.(object.Error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewGlobals returns a scope holding the prelude and a loader for the
+// standard library and the modules fallback resolves. Programs run in a
+// scope nested in it, so their own declarations are kept apart.
+func NewGlobals(fallback eval.ModuleResolver) (*object.Env, *eval.ModuleLoader, error) {
+	env := object.NewEnv()
+	loader := eval.NewModuleLoader(Resolver{Fallback: fallback})
+	loader.SetGlobals(env)
+	if err := LoadPrelude(env, loader); err != nil {
+		return nil, nil, err
+	}
+	return env, loader, nil
+}
@@ -0,0 +1,112 @@
+package stdlib
+
+import (
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/eval"
+	"github.com/carsonip/monkey-interpreter/object"
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newEnv(t *testing.T) (*object.Env, *eval.ModuleLoader) {
+	env := object.NewEnv()
+	loader := eval.NewModuleLoader(Resolver{})
+	loader.SetGlobals(env)
+	assert.NoError(t, LoadPrelude(env, loader))
+	return env, loader
+}
+
+func evalString(env *object.Env, loader *eval.ModuleLoader, path string, input string) []ast.Node {
+	lex := token.NewLexer(input)
+	p := parser.NewParser(&lex)
+	ev := eval.NewEvaluator(&p, env)
+	ev.SetModuleLoader(loader, path)
+	var nodes []ast.Node
+	for node := p.NextNode(); node != nil; node = p.NextNode() {
+		nodes = append(nodes, node)
+		if err, ok := ev.Eval(node, env).(object.Error); ok {
+			panic(err)
+		}
+	}
+	return nodes
+}
+
+// TestMonkey runs every top-level function named test* in testdata/*_test.mk,
+// each of which checks its results with the assertEqual builtin.
+func TestMonkey(t *testing.T) {
+	files, err := filepath.Glob("testdata/*_test.mk")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		env, loader := newEnv(t)
+		for _, node := range evalString(env, loader, file, string(source)) {
+			let, ok := node.(*ast.LetStatement)
+			if !ok || !strings.HasPrefix(let.Name.TokenLiteral(), "test") {
+				continue
+			}
+			name := let.Name.TokenLiteral()
+			t.Run(filepath.Base(file)+"/"+name, func(t *testing.T) {
+				defer func() {
+					if err := recover(); err != nil {
+						t.Error(err)
+					}
+				}()
+				evalString(env, loader, file, name+"()")
+			})
+		}
+	}
+}
+
+func TestLoadPrelude(t *testing.T) {
+	env, _ := newEnv(t)
+	_, ok := env.Get("map")
+	assert.True(t, ok)
+	_, ok = env.Get("str")
+	assert.False(t, ok)
+	_, ok = env.Get("list")
+	assert.False(t, ok)
+}
+
+func TestResolver(t *testing.T) {
+	resolver := Resolver{Fallback: eval.MapResolver{"main.mk": "1"}}
+	resolved, source, err := resolver.Resolve("main.mk", "std/math.mk")
+	assert.NoError(t, err)
+	assert.Equal(t, "std/math.mk", resolved)
+	assert.Contains(t, source, "export let abs")
+	resolved, _, err = resolver.Resolve("std/list.mk", "math.mk")
+	assert.NoError(t, err)
+	assert.Equal(t, "std/math.mk", resolved)
+	_, source, err = resolver.Resolve("", "main.mk")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", source)
+	_, _, err = resolver.Resolve("", "std/missing.mk")
+	assert.Error(t, err)
+	_, _, err = Resolver{}.Resolve("", "main.mk")
+	assert.Error(t, err)
+}
+
+func TestNewGlobals(t *testing.T) {
+	env, loader, err := NewGlobals(eval.MapResolver{"lib.mk": "export let one = 1;"})
+	assert.NoError(t, err)
+	_, ok := env.Get("map")
+	assert.True(t, ok)
+	assert.Equal(t, "1", evalModuleMember(loader, "lib.mk", "one"))
+	assert.Equal(t, "1", evalModuleMember(loader, "std/math.mk", "abs(-1)"))
+}
+
+func evalModuleMember(loader *eval.ModuleLoader, path string, expr string) string {
+	env := object.NewEnv()
+	lex := token.NewLexer(`import "` + path + `" as m; m.` + expr + `;`)
+	p := parser.NewParser(&lex)
+	ev := eval.NewEvaluator(&p, env)
+	ev.SetModuleLoader(loader, "")
+	ev.EvalNext(env)
+	return ev.EvalNext(env).String()
+}
@@ -0,0 +1,39 @@
+import "std/functional.mk" as f;
+
+let inc = fn(x) {
+	return x + 1;
+};
+let double = fn(x) {
+	return x * 2;
+};
+
+let testIdentity = fn() {
+	assertEqual(f.identity(3), 3);
+	assertEqual(f.constant(3)(1, 2), 3);
+};
+
+let testCompose = fn() {
+	assertEqual(f.compose(inc, double)(5), 11);
+	assertEqual(f.pipe(inc, double)(5), 12);
+	assertEqual(f.pipe()(5), 5);
+};
+
+let testPartial = fn() {
+	let sub = fn(a, b, c) {
+		return a - b - c;
+	};
+	assertEqual(f.partial(sub, 10)(2, 3), 5);
+	assertEqual(f.partial(sub, 10, 2)(3), 5);
+};
+
+let testFlip = fn() {
+	assertEqual(f.flip(fn(a, b) {
+		return a - b;
+	})(1, 3), 2);
+};
+
+let testApply = fn() {
+	assertEqual(f.apply(fn(a, b) {
+		return a * b;
+	}, [3, 4]), 12);
+};
@@ -0,0 +1,68 @@
+import "std/list.mk" as list;
+
+let double = fn(x) {
+	return x * 2;
+};
+
+let testMap = fn() {
+	assertEqual(list.map([1, 2, 3], double), [2, 4, 6]);
+	assertEqual(list.map([], double), []);
+};
+
+let testFilter = fn() {
+	assertEqual(list.filter([1, 2, 3, 4], fn(x) {
+		return x % 2 == 0;
+	}), [2, 4]);
+};
+
+let testReduce = fn() {
+	assertEqual(list.reduce([1, 2, 3], fn(acc, x) {
+		return acc * x;
+	}, 1), 6);
+	assertEqual(list.reduce([], fn(acc, x) {
+		return acc + x;
+	}, 7), 7);
+};
+
+let testEach = fn() {
+	let total = 0;
+	list.each([1, 2, 3], fn(x) {
+		total += x;
+	});
+	assertEqual(total, 6);
+};
+
+let testRange = fn() {
+	assertEqual(list.range(0, 4), [0, 1, 2, 3]);
+	assertEqual(list.range(1, 8, 3), [1, 4, 7]);
+	assertEqual(list.range(3, 3), []);
+	assertEqual(list.range(5, 0, -2), [5, 3, 1]);
+	assertEqual(list.range(0, 5, -1), []);
+	assertEqual(assertThrows(fn() { list.range(0, 5, 0); }), "assertion failed: range step cannot be zero");
+};
+
+let testAccessors = fn() {
+	assertEqual(list.first([1, 2, 3]), 1);
+	assertEqual(list.last([1, 2, 3]), 3);
+	assertEqual(list.rest([1, 2, 3]), [2, 3]);
+};
+
+let testReverse = fn() {
+	assertEqual(list.reverse([1, 2, 3]), [3, 2, 1]);
+	assertEqual(list.reverse([]), []);
+};
+
+let testConcat = fn() {
+	let a = [1];
+	assertEqual(list.concat(a, [2, 3]), [1, 2, 3]);
+	assertEqual(a, [1]);
+};
+
+let testContains = fn() {
+	assertEqual(list.contains([1, 2], 2), true);
+	assertEqual(list.contains([1, 2], 3), false);
+};
+
+let testSum = fn() {
+	assertEqual(list.sum([1, 2, 3]), 6);
+};
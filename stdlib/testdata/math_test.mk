@@ -0,0 +1,34 @@
+import "std/math.mk" as math;
+
+let testAbs = fn() {
+	assertEqual(math.abs(0 - 3), 3);
+	assertEqual(math.abs(3), 3);
+};
+
+let testSign = fn() {
+	assertEqual(math.sign(0 - 5), 0 - 1);
+	assertEqual(math.sign(0), 0);
+	assertEqual(math.sign(5), 1);
+};
+
+let testMinMax = fn() {
+	assertEqual(math.min(3, 1, 2), 1);
+	assertEqual(math.max(3, 1, 2), 3);
+	assertEqual(math.min(4), 4);
+};
+
+let testClamp = fn() {
+	assertEqual(math.clamp(5, 0, 3), 3);
+	assertEqual(math.clamp(0 - 5, 0, 3), 0);
+	assertEqual(math.clamp(2, 0, 3), 2);
+};
+
+let testPow = fn() {
+	assertEqual(math.pow(2, 10), 1024);
+	assertEqual(math.pow(5, 0), 1);
+};
+
+let testGcd = fn() {
+	assertEqual(math.gcd(12, 18), 6);
+	assertEqual(math.gcd(0 - 4, 6), 2);
+};
@@ -0,0 +1,11 @@
+let testPrelude = fn() {
+	assertEqual(map([1, 2], fn(x) {
+		return x + 1;
+	}), [2, 3]);
+	assertEqual(filter(range(0, 5), fn(x) {
+		return x > 2;
+	}), [3, 4]);
+	assertEqual(reduce(range(1, 5), fn(a, b) {
+		return a * b;
+	}, 1), 24);
+};
@@ -0,0 +1,29 @@
+import "std/str.mk" as s;
+
+let testJoin = fn() {
+	assertEqual(s.join(["a", "b", "c"], ", "), "a, b, c");
+	assertEqual(s.join(["a", "b"]), "ab");
+	assertEqual(s.join([], ","), "");
+};
+
+let testRepeat = fn() {
+	assertEqual(s.repeat("ab", 3), "ababab");
+	assertEqual(s.repeat("ab", 0), "");
+};
+
+let testReverse = fn() {
+	assertEqual(s.reverse("abc"), "cba");
+	assertEqual(s.reverse("héllo"), "olléh");
+};
+
+let testIsEmpty = fn() {
+	assertEqual(s.isEmpty(""), true);
+	assertEqual(s.isEmpty("a"), false);
+};
+
+let testPad = fn() {
+	assertEqual(s.padLeft("7", 3, "0"), "007");
+	assertEqual(s.padRight("ab", 4), "ab  ");
+	assertEqual(s.padLeft("abcd", 2), "abcd");
+	assertEqual(s.padLeft("é", 3), "  é");
+};
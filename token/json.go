@@ -0,0 +1,96 @@
+package token
+
+import "fmt"
+
+var tokenNames = map[TokenType]string{
+	TOKEN_ILLEGAL:             "ILLEGAL",
+	TOKEN_EOF:                 "EOF",
+	TOKEN_LPAREN:              "LPAREN",
+	TOKEN_RPAREN:              "RPAREN",
+	TOKEN_COMMA:               "COMMA",
+	TOKEN_DOT:                 "DOT",
+	TOKEN_PLUS:                "PLUS",
+	TOKEN_MINUS:               "MINUS",
+	TOKEN_ASTERISK:            "ASTERISK",
+	TOKEN_SLASH:               "SLASH",
+	TOKEN_LBRACE:              "LBRACE",
+	TOKEN_RBRACE:              "RBRACE",
+	TOKEN_SQUOTE:              "SQUOTE",
+	TOKEN_DQOUTE:              "DQUOTE",
+	TOKEN_IDENTIFIER:          "IDENTIFIER",
+	TOKEN_NUMBER:              "NUMBER",
+	TOKEN_FUNCTION:            "FUNCTION",
+	TOKEN_LET:                 "LET",
+	TOKEN_TRUE:                "TRUE",
+	TOKEN_FALSE:               "FALSE",
+	TOKEN_IF:                  "IF",
+	TOKEN_ELSE:                "ELSE",
+	TOKEN_RETURN:              "RETURN",
+	TOKEN_EQUAL:               "EQUAL",
+	TOKEN_NOTEQUAL:            "NOTEQUAL",
+	TOKEN_ASSIGNMENT:          "ASSIGNMENT",
+	TOKEN_NOT:                 "NOT",
+	TOKEN_LT:                  "LT",
+	TOKEN_GT:                  "GT",
+	TOKEN_SEMICOLON:           "SEMICOLON",
+	TOKEN_COLON:               "COLON",
+	TOKEN_STRING:              "STRING",
+	TOKEN_LBRACKET:            "LBRACKET",
+	TOKEN_RBRACKET:            "RBRACKET",
+	TOKEN_MATCH:               "MATCH",
+	TOKEN_ARROW:               "ARROW",
+	TOKEN_ELLIPSIS:            "ELLIPSIS",
+	TOKEN_PERCENT:             "PERCENT",
+	TOKEN_PLUS_ASSIGNMENT:     "PLUS_ASSIGNMENT",
+	TOKEN_MINUS_ASSIGNMENT:    "MINUS_ASSIGNMENT",
+	TOKEN_ASTERISK_ASSIGNMENT: "ASTERISK_ASSIGNMENT",
+	TOKEN_SLASH_ASSIGNMENT:    "SLASH_ASSIGNMENT",
+	TOKEN_PERCENT_ASSIGNMENT:  "PERCENT_ASSIGNMENT",
+	TOKEN_INCREMENT:           "INCREMENT",
+	TOKEN_DECREMENT:           "DECREMENT",
+	TOKEN_WHILE:               "WHILE",
+	TOKEN_IMPORT:              "IMPORT",
+	TOKEN_EXPORT:              "EXPORT",
+	TOKEN_AS:                  "AS",
+	TOKEN_LTE:                 "LTE",
+	TOKEN_GTE:                 "GTE",
+	TOKEN_CONST:               "CONST",
+	TOKEN_BACKTICK:            "BACKTICK",
+	TOKEN_TEMPLATE_TEXT:       "TEMPLATE_TEXT",
+	TOKEN_INTERPOLATION:       "INTERPOLATION",
+}
+
+// String returns the name of the token type without its TOKEN_ prefix, e.g.
+// "IDENTIFIER". The zero TokenType, used by tokens that are absent, is "".
+func (t TokenType) String() string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	if t == 0 {
+		return ""
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
+// MarshalText encodes a token type as its name, so that tokens serialize to
+// readable JSON.
+func (t TokenType) MarshalText() ([]byte, error) {
+	if _, ok := tokenNames[t]; !ok && t != 0 {
+		return nil, fmt.Errorf("unknown token type %d", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+func (t *TokenType) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*t = 0
+		return nil
+	}
+	for tokenType, name := range tokenNames {
+		if name == string(text) {
+			*t = tokenType
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown token type %q", text)
+}
@@ -1,5 +1,7 @@
 package token
 
+import "strings"
+
 type TokenType int
 
 const (
@@ -38,6 +40,27 @@ const (
 	TOKEN_STRING
 	TOKEN_LBRACKET
 	TOKEN_RBRACKET
+	TOKEN_MATCH
+	TOKEN_ARROW
+	TOKEN_ELLIPSIS
+	TOKEN_PERCENT
+	TOKEN_PLUS_ASSIGNMENT
+	TOKEN_MINUS_ASSIGNMENT
+	TOKEN_ASTERISK_ASSIGNMENT
+	TOKEN_SLASH_ASSIGNMENT
+	TOKEN_PERCENT_ASSIGNMENT
+	TOKEN_INCREMENT
+	TOKEN_DECREMENT
+	TOKEN_WHILE
+	TOKEN_IMPORT
+	TOKEN_EXPORT
+	TOKEN_AS
+	TOKEN_LTE
+	TOKEN_GTE
+	TOKEN_CONST
+	TOKEN_BACKTICK
+	TOKEN_TEMPLATE_TEXT
+	TOKEN_INTERPOLATION
 )
 
 var charToToken = map[byte]TokenType{
@@ -50,6 +73,7 @@ var charToToken = map[byte]TokenType{
 	'-': TOKEN_MINUS,
 	'*': TOKEN_ASTERISK,
 	'/': TOKEN_SLASH,
+	'%': TOKEN_PERCENT,
 	'{': TOKEN_LBRACE,
 	'}': TOKEN_RBRACE,
 	'\'': TOKEN_SQUOTE,
@@ -62,6 +86,24 @@ var charToToken = map[byte]TokenType{
 	']': TOKEN_RBRACKET,
 }
 
+var compoundAssignmentTokens = map[byte]TokenType{
+	'+': TOKEN_PLUS_ASSIGNMENT,
+	'-': TOKEN_MINUS_ASSIGNMENT,
+	'*': TOKEN_ASTERISK_ASSIGNMENT,
+	'/': TOKEN_SLASH_ASSIGNMENT,
+	'%': TOKEN_PERCENT_ASSIGNMENT,
+}
+
+// IsAssignment reports whether t is = or a compound assignment such as +=.
+func IsAssignment(t TokenType) bool {
+	for _, assignment := range compoundAssignmentTokens {
+		if t == assignment {
+			return true
+		}
+	}
+	return t == TOKEN_ASSIGNMENT
+}
+
 var keywords = map[string]TokenType{
 	"fn": TOKEN_FUNCTION,
 	"let": TOKEN_LET,
@@ -70,11 +112,28 @@ var keywords = map[string]TokenType{
 	"if": TOKEN_IF,
 	"else": TOKEN_ELSE,
 	"return": TOKEN_RETURN,
+	"match": TOKEN_MATCH,
+	"while": TOKEN_WHILE,
+	"import": TOKEN_IMPORT,
+	"export": TOKEN_EXPORT,
+	"as": TOKEN_AS,
+	"const": TOKEN_CONST,
 }
 
+// Token is a lexeme together with the 1-based line and column of its first
+// character.
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
+}
+
+// Comment is a // line comment, which the lexer skips but keeps a record of.
+type Comment struct {
+	Text   string `json:"text"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
 }
 
 func newToken(tokenType TokenType, literal string) Token {
@@ -88,15 +147,32 @@ type Lexer struct {
 	input string
 	pos int
 	ch byte
+	line int
+	column int
+	comments []Comment
+	// templates has an entry for each template string being read, innermost
+	// last: -1 while reading its text, otherwise the number of unclosed
+	// braces in the ${} being read.
+	templates []int
 }
 
 func NewLexer(input string) Lexer {
-	l := Lexer{input: input, pos: -1}
+	l := Lexer{input: input, pos: -1, line: 1}
 	l.readChar()
 	return l
 }
 
+// Comments returns the comments skipped so far, in source order.
+func (l *Lexer) Comments() []Comment {
+	return l.comments
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	l.pos++
 	if l.pos >= len(l.input) {
 		l.ch = 0
@@ -141,13 +217,86 @@ func (l *Lexer) readString() string {
 }
 
 func (l *Lexer) eatWhitespace() {
-	for l.ch == ' ' || l.ch == '\n' || l.ch == '\t' {
+	for {
+		if l.ch == ' ' || l.ch == '\n' || l.ch == '\t' || l.ch == '\r' {
+			l.readChar()
+		} else if l.ch == '/' && l.peekChar() == '/' {
+			l.eatComment()
+		} else {
+			return
+		}
+	}
+}
+
+func (l *Lexer) eatComment() {
+	comment := Comment{Line: l.line, Column: l.column}
+	lastPos := l.pos
+	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	comment.Text = l.input[lastPos:l.pos]
+	l.comments = append(l.comments, comment)
 }
 
 func (l *Lexer) NextToken() Token {
-	l.eatWhitespace()
+	inText := l.inTemplateText()
+	if !inText {
+		l.eatWhitespace()
+	}
+	line, column := l.line, l.column
+	var tok Token
+	if inText {
+		tok = l.readTemplateToken()
+	} else {
+		tok = l.readToken()
+	}
+	tok.Line = line
+	tok.Column = column
+	return tok
+}
+
+func (l *Lexer) inTemplateText() bool {
+	return len(l.templates) > 0 && l.templates[len(l.templates)-1] < 0
+}
+
+// readTemplateToken reads the text of a template string up to the next ${ or
+// the closing backtick, or either of those. In the text, \`, \$ and \\ stand
+// for the character after the backslash.
+func (l *Lexer) readTemplateToken() Token {
+	switch {
+	case l.ch == 0:
+		return newToken(TOKEN_EOF, "")
+	case l.ch == '`':
+		l.readChar()
+		l.templates = l.templates[:len(l.templates)-1]
+		return newToken(TOKEN_BACKTICK, "`")
+	case l.ch == '$' && l.peekChar() == '{':
+		l.readChar()
+		l.readChar()
+		l.templates[len(l.templates)-1] = 0
+		return newToken(TOKEN_INTERPOLATION, "${")
+	}
+	var text strings.Builder
+	for l.ch != 0 && l.ch != '`' && !(l.ch == '$' && l.peekChar() == '{') {
+		if l.ch == '\\' && (l.peekChar() == '`' || l.peekChar() == '$' || l.peekChar() == '\\') {
+			l.readChar()
+		}
+		text.WriteByte(l.ch)
+		l.readChar()
+	}
+	return newToken(TOKEN_TEMPLATE_TEXT, text.String())
+}
+
+func (l *Lexer) readToken() Token {
+	if n := len(l.templates); n > 0 {
+		switch l.ch {
+		case '{':
+			l.templates[n-1]++
+		case '}':
+			// The brace closing a ${} goes back to the template's text.
+			l.templates[n-1]--
+		}
+	}
 	if isAlpha(l.ch) {
 		str := l.readIdentifier()
 		var tokenType TokenType
@@ -165,6 +314,9 @@ func (l *Lexer) NextToken() Token {
 		if l.ch == '=' {
 			l.readChar()
 			return newToken(TOKEN_EQUAL, "==")
+		} else if l.ch == '>' {
+			l.readChar()
+			return newToken(TOKEN_ARROW, "=>")
 		} else {
 			return newToken(TOKEN_ASSIGNMENT, "=")
 		}
@@ -176,9 +328,42 @@ func (l *Lexer) NextToken() Token {
 		} else {
 			return newToken(TOKEN_NOT, "!")
 		}
+	} else if (l.ch == '<' || l.ch == '>') && l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		l.readChar()
+		if ch == '<' {
+			return newToken(TOKEN_LTE, "<=")
+		}
+		return newToken(TOKEN_GTE, ">=")
+	} else if tokenType, ok := compoundAssignmentTokens[l.ch]; ok && l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		l.readChar()
+		return newToken(tokenType, string(ch)+"=")
+	} else if (l.ch == '+' || l.ch == '-') && l.peekChar() == l.ch {
+		ch := l.ch
+		l.readChar()
+		l.readChar()
+		if ch == '+' {
+			return newToken(TOKEN_INCREMENT, "++")
+		}
+		return newToken(TOKEN_DECREMENT, "--")
+	} else if l.ch == '.' && l.peekChar() == '.' {
+		l.readChar()
+		l.readChar()
+		if l.ch != '.' {
+			return newToken(TOKEN_ILLEGAL, "")
+		}
+		l.readChar()
+		return newToken(TOKEN_ELLIPSIS, "...")
 	} else if l.ch == '"' {
 		str := l.readString()
 		return newToken(TOKEN_STRING, str)
+	} else if l.ch == '`' {
+		l.readChar()
+		l.templates = append(l.templates, -1)
+		return newToken(TOKEN_BACKTICK, "`")
 	} else {
 		tokenType, ok := charToToken[l.ch]
 		ch := l.ch
@@ -191,7 +376,7 @@ func (l *Lexer) NextToken() Token {
 }
 
 func isAlpha(b byte) bool {
-	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z'
+	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b == '_'
 }
 
 func isDigit(b byte) bool {
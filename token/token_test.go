@@ -7,7 +7,7 @@ import (
 
 func TestLexer_NextToken(t *testing.T) {
 	var tok Token
-	l := NewLexer(",. foo let 0123 == != = !foo \"hello\"")
+	l := NewLexer(",. foo let const 0123 == != = !foo \"hello\"")
 	assert.Equal(t, TOKEN_COMMA, l.NextToken().Type)
 	assert.Equal(t, TOKEN_DOT, l.NextToken().Type)
 	tok = l.NextToken()
@@ -16,6 +16,7 @@ func TestLexer_NextToken(t *testing.T) {
 	tok = l.NextToken()
 	assert.Equal(t, TOKEN_LET, tok.Type)
 	assert.Equal(t, "let", tok.Literal)
+	assert.Equal(t, TOKEN_CONST, l.NextToken().Type)
 	tok = l.NextToken()
 	assert.Equal(t, TOKEN_NUMBER, tok.Type)
 	assert.Equal(t, "0123", tok.Literal)
@@ -29,3 +30,122 @@ func TestLexer_NextToken(t *testing.T) {
 	assert.Equal(t, "hello", tok.Literal)
 	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
 }
+
+func TestLexer_NextToken_Comparison(t *testing.T) {
+	l := NewLexer("a <= b >= c < d > e <=f")
+	var types []TokenType
+	for tok := l.NextToken(); tok.Type != TOKEN_EOF; tok = l.NextToken() {
+		types = append(types, tok.Type)
+	}
+	assert.Equal(t, []TokenType{
+		TOKEN_IDENTIFIER, TOKEN_LTE, TOKEN_IDENTIFIER, TOKEN_GTE, TOKEN_IDENTIFIER, TOKEN_LT,
+		TOKEN_IDENTIFIER, TOKEN_GT, TOKEN_IDENTIFIER, TOKEN_LTE, TOKEN_IDENTIFIER,
+	}, types)
+}
+
+func TestLexer_NextToken_Match(t *testing.T) {
+	var tok Token
+	l := NewLexer("match _ => [a, ...rest]")
+	assert.Equal(t, TOKEN_MATCH, l.NextToken().Type)
+	tok = l.NextToken()
+	assert.Equal(t, TOKEN_IDENTIFIER, tok.Type)
+	assert.Equal(t, "_", tok.Literal)
+	assert.Equal(t, TOKEN_ARROW, l.NextToken().Type)
+	assert.Equal(t, TOKEN_LBRACKET, l.NextToken().Type)
+	assert.Equal(t, TOKEN_IDENTIFIER, l.NextToken().Type)
+	assert.Equal(t, TOKEN_COMMA, l.NextToken().Type)
+	assert.Equal(t, TOKEN_ELLIPSIS, l.NextToken().Type)
+	assert.Equal(t, TOKEN_IDENTIFIER, l.NextToken().Type)
+	assert.Equal(t, TOKEN_RBRACKET, l.NextToken().Type)
+	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
+}
+
+func TestLexer_NextToken_CompoundAssignment(t *testing.T) {
+	l := NewLexer("+= -= *= /= %= x++ a[0]-- % + -")
+	assert.Equal(t, TOKEN_PLUS_ASSIGNMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_MINUS_ASSIGNMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_ASTERISK_ASSIGNMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_SLASH_ASSIGNMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_PERCENT_ASSIGNMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_IDENTIFIER, l.NextToken().Type)
+	assert.Equal(t, TOKEN_INCREMENT, l.NextToken().Type)
+	for _, tokenType := range []TokenType{TOKEN_IDENTIFIER, TOKEN_LBRACKET, TOKEN_NUMBER, TOKEN_RBRACKET} {
+		assert.Equal(t, tokenType, l.NextToken().Type)
+	}
+	assert.Equal(t, TOKEN_DECREMENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_PERCENT, l.NextToken().Type)
+	assert.Equal(t, TOKEN_PLUS, l.NextToken().Type)
+	assert.Equal(t, TOKEN_MINUS, l.NextToken().Type)
+	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
+}
+
+func TestIsAssignment(t *testing.T) {
+	assert.True(t, IsAssignment(TOKEN_ASSIGNMENT))
+	assert.True(t, IsAssignment(TOKEN_PERCENT_ASSIGNMENT))
+	assert.False(t, IsAssignment(TOKEN_EQUAL))
+}
+
+func TestLexer_NextToken_Comment(t *testing.T) {
+	l := NewLexer("// comment\n1 // trailing\n/ 2 //")
+	assert.Equal(t, TOKEN_NUMBER, l.NextToken().Type)
+	assert.Equal(t, TOKEN_SLASH, l.NextToken().Type)
+	assert.Equal(t, TOKEN_NUMBER, l.NextToken().Type)
+	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
+}
+
+func TestLexer_NextToken_Template(t *testing.T) {
+	l := NewLexer("`a ${x + {\"k\": `${1}`}.k} $b \\` // \\${c}\\\\`;`` x")
+	var tokens []string
+	for tok := l.NextToken(); tok.Type != TOKEN_EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok.Type.String()+" "+tok.Literal)
+	}
+	assert.Equal(t, []string{
+		"BACKTICK `", "TEMPLATE_TEXT a ", "INTERPOLATION ${", "IDENTIFIER x", "PLUS +",
+		"LBRACE {", "STRING k", "COLON :", "BACKTICK `", "INTERPOLATION ${", "NUMBER 1", "RBRACE }", "BACKTICK `", "RBRACE }",
+		"DOT .", "IDENTIFIER k", "RBRACE }", "TEMPLATE_TEXT  $b ` // ${c}\\", "BACKTICK `",
+		"SEMICOLON ;", "BACKTICK `", "BACKTICK `", "IDENTIFIER x",
+	}, tokens)
+	assert.Empty(t, l.Comments())
+
+	l = NewLexer("`a\n  b")
+	l.NextToken()
+	tok := l.NextToken()
+	assert.Equal(t, TOKEN_TEMPLATE_TEXT, tok.Type)
+	assert.Equal(t, "a\n  b", tok.Literal)
+	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
+}
+
+func TestLexer_NextToken_Position(t *testing.T) {
+	l := NewLexer("let x =\n  \"a\"; // note\n// own line\nx")
+	tok := l.NextToken()
+	assert.Equal(t, 1, tok.Line)
+	assert.Equal(t, 1, tok.Column)
+	tok = l.NextToken()
+	assert.Equal(t, 1, tok.Line)
+	assert.Equal(t, 5, tok.Column)
+	l.NextToken()
+	tok = l.NextToken()
+	assert.Equal(t, TOKEN_STRING, tok.Type)
+	assert.Equal(t, 2, tok.Line)
+	assert.Equal(t, 3, tok.Column)
+	l.NextToken()
+	tok = l.NextToken()
+	assert.Equal(t, "x", tok.Literal)
+	assert.Equal(t, 4, tok.Line)
+	assert.Equal(t, TOKEN_EOF, l.NextToken().Type)
+	assert.Equal(t, []Comment{{Text: "// note", Line: 2, Column: 8}, {Text: "// own line", Line: 3, Column: 1}}, l.Comments())
+}
+
+func TestTokenType_Text(t *testing.T) {
+	assert.Equal(t, "IDENTIFIER", TOKEN_IDENTIFIER.String())
+	assert.Equal(t, "", TokenType(0).String())
+
+	text, err := TOKEN_PLUS_ASSIGNMENT.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "PLUS_ASSIGNMENT", string(text))
+
+	var tokenType TokenType
+	assert.NoError(t, tokenType.UnmarshalText([]byte("ARROW")))
+	assert.Equal(t, TOKEN_ARROW, tokenType)
+	assert.Error(t, tokenType.UnmarshalText([]byte("NOPE")))
+}
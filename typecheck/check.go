@@ -0,0 +1,447 @@
+// Package typecheck reports type errors in Monkey programs before they run.
+//
+// Types come from optional annotations, as in let x: int = 1 and
+// fn(a: string): bool, and from local inference over literals and operators.
+// Anything else, such as an unannotated parameter, has type Any and is left
+// to be checked at runtime.
+package typecheck
+
+import (
+	"fmt"
+	"github.com/carsonip/monkey-interpreter/ast"
+	"github.com/carsonip/monkey-interpreter/token"
+)
+
+// Error is a type error at a position in the source.
+type Error struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+type scope struct {
+	parent *scope
+	types  map[string]Type
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, types: make(map[string]Type)}
+}
+
+func (s *scope) lookup(name string) Type {
+	for ; s != nil; s = s.parent {
+		if t, ok := s.types[name]; ok {
+			return t
+		}
+	}
+	return Any
+}
+
+type checker struct {
+	errors []Error
+	// reassigned holds the names assigned to anywhere in the program. An
+	// unannotated variable of that name may change type, so it is not given
+	// the inferred type of its initial value.
+	reassigned map[string]bool
+	// results holds the declared result types of the enclosing functions.
+	results []Type
+}
+
+// Check checks program and returns the type errors found, in source order.
+func Check(program *ast.Program) []Error {
+	c := &checker{reassigned: make(map[string]bool)}
+	ast.Inspect(program, func(node ast.Node) bool {
+		var target ast.Expression
+		switch node := node.(type) {
+		case *ast.InfixExpression:
+			if token.IsAssignment(node.Token.Type) {
+				target = node.Left
+			}
+		case *ast.PostfixExpression:
+			target = node.Left
+		}
+		if ident, ok := target.(*ast.Identifier); ok {
+			c.reassigned[ident.TokenLiteral()] = true
+		}
+		return true
+	})
+	universe := newScope(nil)
+	for name, t := range Builtins {
+		universe.types[name] = t
+	}
+	c.statements(program.Statements, newScope(universe))
+	return c.errors
+}
+
+func (c *checker) errorf(node ast.Node, format string, args ...interface{}) {
+	tok := ast.Start(node)
+	c.errors = append(c.errors, Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+// annotation returns the type an annotation names, or Any if there is none.
+func (c *checker) annotation(t *ast.TypeAnnotation) Type {
+	if t == nil {
+		return Any
+	}
+	basic, ok := basicTypes[t.TokenLiteral()]
+	if !ok {
+		c.errorf(t, "unknown type %s", t.TokenLiteral())
+		return Any
+	}
+	return basic
+}
+
+func (c *checker) statements(nodes []ast.Node, s *scope) {
+	for _, node := range nodes {
+		if statement, ok := node.(ast.Statement); ok {
+			c.statement(statement, s)
+		} else if expr, ok := node.(ast.Expression); ok {
+			c.expression(expr, s)
+		}
+	}
+}
+
+func (c *checker) statement(statement ast.Statement, s *scope) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		c.let(statement, s)
+	case *ast.ExportStatement:
+		c.let(statement.Statement, s)
+	case *ast.ImportStatement:
+		s.types[statement.Alias.TokenLiteral()] = Any
+	case *ast.ReturnStatement:
+		t := c.expression(statement.Value, s)
+		if len(c.results) > 0 {
+			if result := c.results[len(c.results)-1]; !assignable(t, result) {
+				c.errorf(statement.Value, "cannot return %s from function returning %s", t, result)
+			}
+		}
+	case *ast.IfStatement:
+		c.expression(statement.Condition, s)
+		c.statements(statement.Then, newScope(s))
+		c.statements(statement.Else, newScope(s))
+	case *ast.WhileStatement:
+		c.expression(statement.Condition, s)
+		c.statements(statement.Body, newScope(s))
+	}
+}
+
+func (c *checker) let(statement *ast.LetStatement, s *scope) {
+	t := c.expression(statement.Value, s)
+	declared := c.annotation(statement.Type)
+	ident, ok := statement.Name.(*ast.Identifier)
+	if !assignable(t, declared) {
+		if ok {
+			c.errorf(statement.Value, "cannot use %s as %s in declaration of %s", t, declared, ident.TokenLiteral())
+		} else {
+			c.errorf(statement.Value, "cannot use %s as %s in declaration", t, declared)
+		}
+	}
+	if !ok {
+		c.bindPattern(statement.Name, s)
+		return
+	}
+	switch {
+	case statement.Type != nil:
+		t = declared
+	case c.reassigned[ident.TokenLiteral()]:
+		t = Any
+	}
+	s.types[ident.TokenLiteral()] = t
+}
+
+// bindPattern gives the names in a destructuring pattern type Any.
+func (c *checker) bindPattern(pattern ast.Pattern, s *scope) {
+	ast.Inspect(pattern, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			s.types[node.TokenLiteral()] = Any
+		case *ast.MapPattern:
+			for _, pair := range node.Pairs {
+				c.bindPattern(pair.Value, s)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func (c *checker) function(fn *ast.Function, s *scope) Type {
+	fnScope := newScope(s)
+	t := &Func{Result: c.annotation(fn.ReturnType)}
+	for _, param := range fn.Params {
+		paramType := c.annotation(param.Type)
+		if param.Default != nil {
+			if defaultType := c.expression(param.Default, fnScope); !assignable(defaultType, paramType) {
+				c.errorf(param.Default, "cannot use %s as %s in default of %s", defaultType, paramType, param.Pattern.TokenLiteral())
+			}
+		}
+		ident, ok := param.Pattern.(*ast.Identifier)
+		switch {
+		case param.Variadic:
+			fnScope.types[ident.TokenLiteral()] = Array
+		case ok:
+			fnScope.types[ident.TokenLiteral()] = paramType
+		default:
+			c.bindPattern(param.Pattern, fnScope)
+		}
+		name := ""
+		if ok {
+			name = ident.TokenLiteral()
+		}
+		t.Params = append(t.Params, Param{Name: name, Type: paramType, Variadic: param.Variadic})
+	}
+	c.results = append(c.results, t.Result)
+	c.statements(fn.Body, fnScope)
+	c.results = c.results[:len(c.results)-1]
+	return t
+}
+
+func (c *checker) expression(expr ast.Expression, s *scope) Type {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return s.lookup(expr.TokenLiteral())
+	case *ast.NumberLiteral:
+		return Int
+	case *ast.String:
+		return String
+	case *ast.Template:
+		for _, value := range expr.Values {
+			c.expression(value, s)
+		}
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.PrefixExpression:
+		if expr.Token.Type == token.TOKEN_NOT {
+			return Bool
+		}
+		return Int
+	case *ast.InfixExpression:
+		return c.infix(expr, s)
+	case *ast.PostfixExpression:
+		if t := c.expression(expr.Left, s); !assignable(t, Int) {
+			c.errorf(expr, "invalid operation: %s on %s", expr.Token.Literal, t)
+		}
+		return Int
+	case *ast.Array:
+		for _, element := range expr.Elements {
+			c.expression(element, s)
+		}
+		return Array
+	case *ast.Map:
+		for _, pair := range expr.Pairs {
+			c.expression(pair[0], s)
+			c.expression(pair[1], s)
+		}
+		return Map
+	case *ast.Index:
+		t := c.expression(expr.Left, s)
+		c.expression(expr.Index, s)
+		if t != Any && t != Array && t != Map && t != String {
+			c.errorf(expr, "cannot index %s", t)
+		}
+		if t == String {
+			return String
+		}
+		return Any
+	case *ast.Slice:
+		t := c.expression(expr.Left, s)
+		for _, bound := range []ast.Expression{expr.Start, expr.End, expr.Step} {
+			if bound == nil {
+				continue
+			}
+			if boundType := c.expression(bound, s); !assignable(boundType, Int) {
+				c.errorf(bound, "cannot use %s as int in slice", boundType)
+			}
+		}
+		if t != Any && t != Array && t != String {
+			c.errorf(expr, "cannot slice %s", t)
+			return Any
+		}
+		return t
+	case *ast.Dot:
+		if t := c.expression(expr.Left, s); t != Any && t != Map {
+			c.errorf(expr, "cannot access %s of %s", expr.Name.TokenLiteral(), t)
+		}
+		return Any
+	case *ast.Function:
+		return c.function(expr, s)
+	case *ast.FunctionCall:
+		return c.call(expr, s)
+	case *ast.MatchExpression:
+		c.expression(expr.Value, s)
+		var result Type
+		for _, arm := range expr.Arms {
+			armScope := newScope(s)
+			c.bindPattern(arm.Pattern, armScope)
+			if arm.Guard != nil {
+				c.expression(arm.Guard, armScope)
+			}
+			t := c.expression(arm.Body, armScope)
+			if result == nil {
+				result = t
+			} else if t != result {
+				result = Any
+			}
+		}
+		if result == nil {
+			return Any
+		}
+		return result
+	}
+	return Any
+}
+
+func (c *checker) infix(expr *ast.InfixExpression, s *scope) Type {
+	op := expr.Token.Type
+	if token.IsAssignment(op) {
+		return c.assignment(expr, s)
+	}
+	left := c.expression(expr.Left, s)
+	right := c.expression(expr.Right, s)
+	switch op {
+	case token.TOKEN_EQUAL, token.TOKEN_NOTEQUAL:
+		// Values of different types are never equal, which is most likely
+		// a mistake.
+		if !assignable(left, right) {
+			c.errorf(expr, "invalid operation: %s %s %s", left, expr.Token.Literal, right)
+		}
+		return Bool
+	case token.TOKEN_LT, token.TOKEN_GT, token.TOKEN_LTE, token.TOKEN_GTE:
+		if !ordered(left, right) {
+			c.errorf(expr, "invalid operation: %s %s %s", left, expr.Token.Literal, right)
+		}
+		return Bool
+	}
+	t, ok := arithmetic(left, right, op)
+	if !ok {
+		c.errorf(expr, "invalid operation: %s %s %s", left, expr.Token.Literal, right)
+	}
+	return t
+}
+
+// arithmetic returns the result type of an arithmetic operator, following
+// the evaluator: every operator applies to ints, and + also joins strings.
+func arithmetic(left Type, right Type, op token.TokenType) (Type, bool) {
+	if op == token.TOKEN_PLUS {
+		switch {
+		case left == Int && right == Int:
+			return Int, true
+		case left == String && right == String:
+			return String, true
+		case left == Any && (right == Int || right == String):
+			return right, true
+		case right == Any && (left == Int || left == String):
+			return left, true
+		case left == Any && right == Any:
+			return Any, true
+		}
+		return Any, false
+	}
+	if assignable(left, Int) && assignable(right, Int) {
+		return Int, true
+	}
+	return Any, false
+}
+
+// comparable reports whether the evaluator supports == on values of type t.
+// ordered reports whether values of types left and right may be compared
+// with < and the like: ints, strings and arrays, each with their own type.
+func ordered(left Type, right Type) bool {
+	isOrdered := func(t Type) bool {
+		return t == Any || t == Int || t == String || t == Array
+	}
+	return isOrdered(left) && isOrdered(right) && (left == Any || right == Any || left == right)
+}
+
+func (c *checker) assignment(expr *ast.InfixExpression, s *scope) Type {
+	target := c.expression(expr.Left, s)
+	value := c.expression(expr.Right, s)
+	if op, ok := compoundAssignmentOperators[expr.Token.Type]; ok {
+		t, ok := arithmetic(target, value, op)
+		if !ok {
+			c.errorf(expr, "invalid operation: %s %s %s", target, expr.Token.Literal, value)
+			return Any
+		}
+		value = t
+	}
+	if !assignable(value, target) {
+		c.errorf(expr.Right, "cannot assign %s to %s of type %s", value, expr.Left.TokenLiteral(), target)
+	}
+	return value
+}
+
+func (c *checker) call(call *ast.FunctionCall, s *scope) Type {
+	callee := c.expression(call.FunctionExpr, s)
+	name := "function"
+	if ident, ok := call.FunctionExpr.(*ast.Identifier); ok {
+		name = ident.TokenLiteral()
+	}
+	fn, isFunc := callee.(*Func)
+	if !isFunc && callee != Any && callee != Fn {
+		c.errorf(call, "cannot call %s", callee)
+	}
+	positional := 0
+	spread := false
+	for _, arg := range call.Arguments {
+		var param *Param
+		var t Type
+		switch arg := arg.(type) {
+		case *ast.Spread:
+			c.expression(arg.Value, s)
+			spread = true
+			continue
+		case *ast.KeywordArgument:
+			t = c.expression(arg.Value, s)
+			if isFunc {
+				param = fn.param(arg.Name.TokenLiteral())
+			}
+		default:
+			t = c.expression(arg, s)
+			if isFunc && !spread {
+				param = fn.positional(positional)
+			}
+			positional++
+		}
+		if param != nil && !assignable(t, param.Type) {
+			c.errorf(arg, "cannot use %s as %s in argument %s to %s", t, param.Type, param.Name, name)
+		}
+	}
+	if isFunc {
+		return fn.Result
+	}
+	return Any
+}
+
+// positional returns the parameter the i-th positional argument binds to.
+func (f *Func) positional(i int) *Param {
+	if i < len(f.Params) && !f.Params[i].Variadic {
+		return &f.Params[i]
+	}
+	if n := len(f.Params); n > 0 && f.Params[n-1].Variadic {
+		return &f.Params[n-1]
+	}
+	return nil
+}
+
+func (f *Func) param(name string) *Param {
+	for i, param := range f.Params {
+		if param.Name == name && !param.Variadic {
+			return &f.Params[i]
+		}
+	}
+	return nil
+}
+
+var compoundAssignmentOperators = map[token.TokenType]token.TokenType{
+	token.TOKEN_PLUS_ASSIGNMENT:     token.TOKEN_PLUS,
+	token.TOKEN_MINUS_ASSIGNMENT:    token.TOKEN_MINUS,
+	token.TOKEN_ASTERISK_ASSIGNMENT: token.TOKEN_ASTERISK,
+	token.TOKEN_SLASH_ASSIGNMENT:    token.TOKEN_SLASH,
+	token.TOKEN_PERCENT_ASSIGNMENT:  token.TOKEN_PERCENT,
+}
@@ -0,0 +1,85 @@
+package typecheck
+
+import (
+	"github.com/carsonip/monkey-interpreter/parser"
+	"github.com/carsonip/monkey-interpreter/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func check(str string) []string {
+	lex := token.NewLexer(str)
+	p := parser.NewParser(&lex)
+	var errors []string
+	for _, err := range Check(p.ParseProgram()) {
+		errors = append(errors, err.Error())
+	}
+	return errors
+}
+
+func TestCheck_Dynamic(t *testing.T) {
+	assert.Empty(t, check(`
+let add = fn(a, b) { return a + b; };
+add(1, 2); add("a", "b");
+let x = 1; x = "one"; x + "s";
+let [a, b] = [1, "2"]; a + b;
+let m = {"k": 1}; m.k + m["k"]; m["k"] = "v";
+let n = match (x) { 1 => 1, _ => "other" }; n + 1;
+`))
+}
+
+func TestCheck_Let(t *testing.T) {
+	assert.Empty(t, check(`let x: int = 1 + 2; let s: string = "a" + "b"; let b: bool = 1 < 2; let a: any = [];`))
+	assert.Equal(t, []string{"1:14: cannot use string as int in declaration of x"}, check(`let x: int = "one";`))
+	assert.Equal(t, []string{"1:21: cannot use int as array in declaration"}, check(`let [a, b]: array = 1;`))
+	assert.Equal(t, []string{"1:8: unknown type float"}, check(`let x: float = 1;`))
+	assert.Equal(t, []string{"1:21: cannot assign string to x of type int"}, check(`let x: int = 1; x = "s";`))
+	assert.Equal(t, []string{"1:14: invalid operation: string + int"}, check(`let s = "a"; s + 1;`))
+	assert.Empty(t, check(`let f: fn = fn(x: int): int { return x; }; let g: fn = f;`))
+}
+
+func TestCheck_Operators(t *testing.T) {
+	assert.Equal(t, []string{"1:1: invalid operation: int + string"}, check(`1 + "a";`))
+	assert.Equal(t, []string{"1:1: invalid operation: string - string"}, check(`"a" - "b";`))
+	assert.Equal(t, []string{"1:1: invalid operation: bool < bool"}, check(`true < false;`))
+	assert.Equal(t, []string{"1:1: invalid operation: int == string"}, check(`1 == "1";`))
+	assert.Empty(t, check(`[] == []; {} != {}; "a" <= "b"; [1] >= [2]; let f = fn(x) { return x < "a"; };`))
+	assert.Equal(t, []string{"1:1: invalid operation: array < string"}, check(`[] < "a";`))
+	assert.Equal(t, []string{"1:1: invalid operation: map >= map"}, check(`{} >= {};`))
+	assert.Equal(t, []string{"1:22: invalid operation: string += int"}, check(`let s: string = "a"; s += 1;`))
+	assert.Equal(t, []string{"1:22: invalid operation: ++ on string"}, check(`let s: string = "a"; s++;`))
+	assert.Equal(t, []string{"1:1: cannot index int", "1:7: cannot access k of bool"}, check(`1[0]; true.k;`))
+	assert.Equal(t, []string{"1:29: invalid operation: int * bool"}, check(`let f = fn(x: int) { return x * true; };`))
+}
+
+func TestCheck_Template(t *testing.T) {
+	assert.Empty(t, check("let n = 1; let s: string = `${n} and ${[n]}`;"))
+	assert.Equal(t, []string{"1:14: cannot use string as int in declaration of x"}, check("let x: int = `${1}`;"))
+	assert.Equal(t, []string{"1:4: invalid operation: int + string"}, check("`${1 + \"a\"}`;"))
+}
+
+func TestCheck_Slice(t *testing.T) {
+	assert.Empty(t, check(`let s: string = "abc"[1:]; let t: string = s[-1]; let a: array = [1, 2][::-1]; let x = [1][0] + 1;`))
+	assert.Equal(t, []string{"1:1: cannot slice map"}, check(`{}[1:2];`))
+	assert.Equal(t, []string{"1:5: cannot use string as int in slice", "1:17: cannot use bool as int in slice"},
+		check(`[1]["a":]; [][::true];`))
+	assert.Equal(t, []string{"1:20: invalid operation: string + int"}, check(`let s = "abc"[:2]; s + 1;`))
+}
+
+func TestCheck_Functions(t *testing.T) {
+	assert.Equal(t, []string{"1:34: cannot return string from function returning int"},
+		check(`let f = fn(x: int): int { return "s"; };`))
+	assert.Equal(t, []string{"1:41: cannot use int as string in argument s to f"},
+		check(`let f = fn(s: string, n: int) { s; }; f(1, 2);`))
+	assert.Equal(t, []string{"1:46: cannot use string as int in argument n to f"},
+		check(`let f = fn(s: string, n: int) { s; }; f("a", n: "b");`))
+	assert.Equal(t, []string{"1:38: cannot use string as int in argument xs to f"},
+		check(`let f = fn(...xs: int) { xs; }; f(1, "2");`))
+	assert.Equal(t, []string{"1:21: cannot use string as int in default of n"}, check(`let f = fn(n: int = "a") {};`))
+	assert.Equal(t, []string{"1:39: invalid operation: string + int"},
+		check(`let f = fn(): string { return "a"; }; f() + 1;`))
+	assert.Equal(t, []string{"1:16: cannot use int as string in argument string to chars"},
+		check(`let cs = chars(1); len(cs) + 1;`))
+	assert.Equal(t, []string{"1:1: cannot call int"}, check(`1();`))
+	assert.Empty(t, check(`let f = fn(a: int) {}; f(...["a"]); let g = fn(x) { x(1); }; g(f);`))
+}
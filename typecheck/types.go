@@ -0,0 +1,103 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is the static type of an expression.
+type Type interface {
+	String() string
+}
+
+// Basic is a type written as a plain name in annotations.
+type Basic string
+
+const (
+	// Any is the type of dynamically typed values, such as unannotated
+	// parameters. It is compatible with every other type.
+	Any    Basic = "any"
+	Int    Basic = "int"
+	String Basic = "string"
+	Bool   Basic = "bool"
+	Array  Basic = "array"
+	Map    Basic = "map"
+	// Fn is any function, whatever its parameters.
+	Fn Basic = "fn"
+)
+
+var basicTypes = map[string]Basic{
+	"any":    Any,
+	"int":    Int,
+	"string": String,
+	"bool":   Bool,
+	"array":  Array,
+	"map":    Map,
+	"fn":     Fn,
+}
+
+func (b Basic) String() string {
+	return string(b)
+}
+
+// Param is a parameter of a function type.
+type Param struct {
+	Name     string
+	Type     Type
+	Variadic bool
+}
+
+// Func is the type of a function whose parameters are known, inferred from a
+// function literal.
+type Func struct {
+	Params []Param
+	Result Type
+}
+
+func (f *Func) String() string {
+	var params []string
+	for _, param := range f.Params {
+		if param.Variadic {
+			params = append(params, "..."+param.Type.String())
+		} else {
+			params = append(params, param.Type.String())
+		}
+	}
+	return fmt.Sprintf("fn(%s): %s", strings.Join(params, ", "), f.Result)
+}
+
+// assignable reports whether a value of type t may be used where a value of
+// type to is expected.
+func assignable(t Type, to Type) bool {
+	if t == Any || to == Any || t == to {
+		return true
+	}
+	_, tFunc := t.(*Func)
+	_, toFunc := to.(*Func)
+	return (tFunc || t == Fn) && (toFunc || to == Fn)
+}
+
+// Builtins are the types of the evaluator's builtin functions.
+var Builtins = map[string]Type{
+	"len":    &Func{Params: []Param{{Name: "value", Type: Any}}, Result: Int},
+	"arity":  &Func{Params: []Param{{Name: "fn", Type: Fn}}, Result: Array},
+	"push":   &Func{Params: []Param{{Name: "array", Type: Array}, {Name: "value", Type: Any}}, Result: Array},
+	"keys":   &Func{Params: []Param{{Name: "map", Type: Map}}, Result: Array},
+	"chars":  &Func{Params: []Param{{Name: "string", Type: String}}, Result: Array},
+	"freeze": &Func{Params: []Param{{Name: "value", Type: Any}}, Result: Any},
+	"str":    &Func{Params: []Param{{Name: "value", Type: Any}}, Result: String},
+	"print":   &Func{Params: []Param{{Name: "values", Type: Any, Variadic: true}}, Result: Any},
+	"println": &Func{Params: []Param{{Name: "values", Type: Any, Variadic: true}}, Result: Any},
+	"printf": &Func{
+		Params: []Param{{Name: "format", Type: String}, {Name: "values", Type: Any, Variadic: true}},
+		Result: Any,
+	},
+	"eprint": &Func{Params: []Param{{Name: "values", Type: Any, Variadic: true}}, Result: Any},
+	"input":  &Func{Params: []Param{{Name: "prompt", Type: Any}}, Result: Any},
+	"assert": &Func{Params: []Param{{Name: "condition", Type: Any}, {Name: "message", Type: String}}, Result: Any},
+	"assertEqual": &Func{
+		Params: []Param{{Name: "actual", Type: Any}, {Name: "expected", Type: Any}, {Name: "message", Type: String}},
+		Result: Any,
+	},
+	"assertThrows": &Func{Params: []Param{{Name: "fn", Type: Fn}, {Name: "message", Type: String}}, Result: String},
+}